@@ -24,13 +24,16 @@ import (
 	"os"
 	"path/filepath"
 	_ "todo-go-backend/docs" // Swagger documentation
+	"todo-go-backend/internal/auth"
 	"todo-go-backend/internal/config"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/handlers"
 	"todo-go-backend/internal/middleware"
 	"todo-go-backend/internal/notifications"
+	"todo-go-backend/internal/realtime"
 	"todo-go-backend/internal/repositories"
 	"todo-go-backend/internal/services"
+	"todo-go-backend/internal/version"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -55,11 +58,8 @@ func main() {
 	tagRepo := repositories.NewTagRepository()
 	commentRepo := repositories.NewCommentRepository()
 
-	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	taskService := services.NewTaskService(taskRepo, userRepo, tagRepo)
-	tagService := services.NewTagService(tagRepo)
-	commentService := services.NewCommentService(commentRepo, taskRepo)
+	// Live updates hub shared by the services that publish events
+	hub := realtime.NewHub()
 
 	// Initialize notification services
 	emailService := notifications.NewEmailService(
@@ -68,32 +68,66 @@ func main() {
 		cfg.SMTPUser,
 		cfg.SMTPPassword,
 		cfg.SMTPFrom,
+		cfg.SMTPMode,
+		cfg.FrontendBaseURL,
+		cfg.SMTPKeepAlive,
 	)
-	telegramService := notifications.NewTelegramService(cfg.TelegramBotToken)
+	telegramService := notifications.NewTelegramService(cfg.TelegramBotToken, cfg.FrontendBaseURL)
 	notificationRepo := repositories.NewNotificationRepository()
+	telegramLinkRepo := repositories.NewTelegramLinkRepository()
+	settingRepo := repositories.NewSettingRepository()
 	notificationService := notifications.NewNotificationService(
-		emailService,
-		telegramService,
+		[]notifications.Notifier{emailService, telegramService},
 		notificationRepo,
 		taskRepo,
+		commentRepo,
 		userRepo,
+		cfg.NotificationDueSoonDays,
 	)
 
+	// Initialize services
+	jwtKeys := auth.NewKeySet(cfg.JWTKeyID, cfg.JWTSecret, cfg.PreviousJWTKeys())
+	authService := services.NewAuthService(userRepo, jwtKeys)
+	taskService := services.NewTaskService(taskRepo, userRepo, tagRepo, hub, notificationService, services.TaskServiceOptions{
+		MaxTags:               cfg.TaskMaxTags,
+		ClearCompletedArchive: cfg.TaskClearCompletedArchive,
+		ListAllMax:            cfg.TaskListAllMax,
+		HideInaccessible:      cfg.TaskHideInaccessible,
+		CommentLockClosed:     cfg.CommentLockClosedTask,
+	})
+	tagService := services.NewTagService(tagRepo, cfg.TagColors())
+	commentService := services.NewCommentService(commentRepo, taskRepo, hub, notificationService, services.CommentServiceOptions{
+		MaxPinned:        cfg.CommentMaxPinned,
+		LockClosed:       cfg.CommentLockClosedTask,
+		NotifyAll:        cfg.NotificationCommentAll,
+		HideInaccessible: cfg.TaskHideInaccessible,
+		MaxLength:        cfg.CommentMaxLength,
+	})
+	dataExportService := services.NewDataExportService(userRepo, taskRepo, tagRepo, commentRepo)
+
+	// Start notification scheduler
+	scheduler := notifications.NewScheduler(cfg, notificationService, settingRepo)
+	scheduler.Start()
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	taskHandler := handlers.NewTaskHandler(taskService)
 	tagHandler := handlers.NewTagHandler(tagService)
 	commentHandler := handlers.NewCommentHandler(commentService)
-	userHandler := handlers.NewUserHandler(notificationService, userRepo)
-
-	// Start notification scheduler
-	go notifications.StartScheduler(cfg, notificationService)
+	userHandler := handlers.NewUserHandler(notificationService, scheduler, userRepo, notificationRepo, taskRepo, dataExportService)
+	adminHandler := handlers.NewAdminHandler(scheduler)
+	telegramHandler := handlers.NewTelegramHandler(telegramService, telegramLinkRepo, userRepo, cfg.TelegramWebhookSecret)
+	realtimeHandler := handlers.NewRealtimeHandler(hub)
 
 	// Setup router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.LoggerMiddleware())
+	router.Use(middleware.RecoveryMiddleware())
+	router.Use(middleware.BodySizeLimitMiddleware(cfg.MaxRequestBodyBytes))
 
 	// Apply CORS middleware
-	router.Use(middleware.CORSMiddleware(cfg))
+	router.Use(middleware.CORSMiddleware(cfg, router.Routes))
 
 	// Health check endpoint
 	// @Summary     Health check endpoint
@@ -179,18 +213,44 @@ func main() {
 	// Public routes
 	api := router.Group("/api/v1")
 	{
+		// Version endpoint, to check which build a deployment is running
+		// @Summary     API version
+		// @Description Returns the version, git commit and build time of the running build, set at build time with -ldflags
+		// @Tags        health
+		// @Produce     json
+		// @Success     200  {object}  version.Info
+		// @Router      /version [get]
+		api.GET("/version", func(c *gin.Context) {
+			c.JSON(200, version.Get())
+		})
+
 		api.POST("/auth/register", authHandler.Register)
 		api.POST("/auth/login", authHandler.Login)
+		api.POST("/telegram/webhook", telegramHandler.Webhook)
+
+		// WebSocket and EventSource clients can't send headers, so the token may come as a query parameter
+		api.GET("/ws", middleware.QueryTokenAuthMiddleware(jwtKeys, cfg.AuthVerifyUser), realtimeHandler.WebSocket)
+		api.GET("/events", middleware.QueryTokenAuthMiddleware(jwtKeys, cfg.AuthVerifyUser), realtimeHandler.Events)
 	}
 
 	// Protected routes
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	protected.Use(middleware.AuthMiddleware(jwtKeys, cfg.AuthVerifyUser))
 	{
+		// Auth routes
+		protected.GET("/auth/me", authHandler.Me)
+
 		// Tasks routes
 		protected.GET("/tasks", taskHandler.GetTasks)
 		protected.GET("/tasks/assigned", taskHandler.GetAssignedTasks)
+		protected.GET("/tasks/assigned-to-me", taskHandler.GetAssignedToMeTasks)
+		protected.GET("/tasks/assigned-to-me/count", taskHandler.CountAssignedToMeTasks)
 		protected.POST("/tasks", taskHandler.CreateTask)
+		protected.POST("/tasks/batch", taskHandler.CreateTasksBatch)
+		protected.GET("/tasks/upcoming", taskHandler.GetUpcomingTasks)
+		protected.GET("/tasks/stats", taskHandler.GetTaskStats)
+		protected.GET("/tasks/export", taskHandler.ExportTasks)
+		protected.POST("/tasks/import", taskHandler.ImportTasks)
 
 		// Comments routes for tasks (must be before /tasks/:id to avoid route conflict)
 		// Using /tasks/:id/comments with same parameter name to avoid Gin route conflict
@@ -198,15 +258,24 @@ func main() {
 
 		// Tasks routes with ID (must be after /tasks/:id/comments)
 		protected.GET("/tasks/:id", taskHandler.GetTask)
+		protected.HEAD("/tasks/:id", taskHandler.HeadTask)
 		protected.PUT("/tasks/:id", taskHandler.UpdateTask)
+		protected.DELETE("/tasks/completed", taskHandler.DeleteCompletedTasks)
 		protected.DELETE("/tasks/:id", taskHandler.DeleteTask)
+		protected.PUT("/tasks/:id/archive", taskHandler.ArchiveTask)
+		protected.PUT("/tasks/:id/unarchive", taskHandler.UnarchiveTask)
 		protected.POST("/tasks/:id/share", taskHandler.ShareTask)
 		protected.DELETE("/tasks/:id/share/:user_id", taskHandler.UnshareTask)
 
 		// Tags routes
 		protected.GET("/tags", tagHandler.GetTags)
+		protected.GET("/tags/palette", tagHandler.GetPalette)
 		protected.GET("/tags/:id", tagHandler.GetTag)
+		protected.GET("/tags/:id/tasks", taskHandler.GetTagTasks)
+		protected.POST("/tags/:id/assign", taskHandler.AssignTag)
+		protected.POST("/tags/:id/unassign", taskHandler.UnassignTag)
 		protected.POST("/tags", tagHandler.CreateTag)
+		protected.POST("/tags/ensure", tagHandler.EnsureTag)
 		protected.PUT("/tags/:id", tagHandler.UpdateTag)
 		protected.DELETE("/tags/:id", tagHandler.DeleteTag)
 
@@ -215,19 +284,44 @@ func main() {
 		protected.GET("/comments/:id", commentHandler.GetComment)
 		protected.PUT("/comments/:id", commentHandler.UpdateComment)
 		protected.DELETE("/comments/:id", commentHandler.DeleteComment)
+		protected.PUT("/comments/:id/pin", commentHandler.PinComment)
+		protected.PUT("/comments/:id/unpin", commentHandler.UnpinComment)
 
 		// User routes
 		protected.GET("/users", userHandler.GetUsers)
+		protected.GET("/users/assignable", userHandler.GetAssignableUsers)
 		protected.PUT("/users/telegram-chat-id", userHandler.UpdateTelegramChatID)
+		protected.POST("/users/telegram-link-code", telegramHandler.CreateLinkCode)
 		protected.PUT("/users/notifications-enabled", userHandler.UpdateNotificationsEnabled)
+		protected.PUT("/users/notification-channels", userHandler.UpdatePreferredChannels)
+		protected.PUT("/users/notification-types", userHandler.UpdateNotificationTypes)
+		protected.PUT("/users/language", userHandler.UpdateLanguage)
+		protected.GET("/users/me/export", userHandler.ExportData)
 
 		// Notification test routes (for testing)
+		protected.GET("/notifications", userHandler.GetNotifications)
 		protected.POST("/notifications/test", userHandler.TestNotifications)
+		protected.POST("/notifications/test-channel", userHandler.TestChannels)
+		protected.POST("/notifications/test-email", userHandler.TestEmail)
+		protected.POST("/notifications/test-telegram", userHandler.TestTelegram)
 		protected.GET("/notifications/debug", userHandler.GetNotificationDebugInfo)
+		protected.GET("/notifications/:id", userHandler.GetNotification)
+		protected.POST("/notifications/:id/resend", userHandler.ResendNotification)
+	}
+
+	// Admin routes
+	admin := protected.Group("/admin")
+	admin.Use(middleware.AdminMiddleware(cfg.AdminUsernames))
+	{
+		admin.GET("/scheduler", adminHandler.GetSchedulerStatus)
+		admin.POST("/scheduler/pause", adminHandler.PauseScheduler)
+		admin.POST("/scheduler/resume", adminHandler.ResumeScheduler)
+		admin.PUT("/scheduler/interval", adminHandler.UpdateSchedulerInterval)
 	}
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
+	info := version.Get()
+	log.Printf("Server starting on port %s (version %s, commit %s, built %s)", cfg.Port, info.Version, info.Commit, info.BuildTime)
 	if err := router.Run(":" + cfg.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
@@ -0,0 +1,199 @@
+// Command seed fills the configured database with demo users, tags and tasks.
+// It goes through the services, so validation and password hashing apply, and it is
+// idempotent: users, tags and tasks that already exist are skipped.
+//
+//	go run ./cmd/seed -users 3 -tasks 6 -password password123
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+	"todo-go-backend/internal/auth"
+	"todo-go-backend/internal/config"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
+	"todo-go-backend/internal/repositories"
+	"todo-go-backend/internal/services"
+)
+
+// sampleTag is a tag created for every seeded user
+type sampleTag struct {
+	Name  string
+	Color string
+}
+
+// sampleTask is a task template; DueIn is relative to the seeding time (nil = no due date)
+type sampleTask struct {
+	Title       string
+	Description string
+	Type        models.TaskType
+	Priority    models.Priority
+	DueIn       *time.Duration
+	Tags        []string
+}
+
+func dueIn(d time.Duration) *time.Duration {
+	return &d
+}
+
+var sampleTags = []sampleTag{
+	{Name: "Urgente", Color: "#EF4444"},
+	{Name: "Pessoal", Color: "#10B981"},
+	{Name: "Projeto", Color: "#3B82F6"},
+}
+
+var sampleTasks = []sampleTask{
+	{Title: "Limpar a casa", Description: "Limpar todos os cômodos", Type: models.TaskTypeCasa, Priority: models.PriorityMedia, DueIn: dueIn(24 * time.Hour), Tags: []string{"Pessoal"}},
+	{Title: "Entregar relatório mensal", Description: "Enviar o relatório para a equipe", Type: models.TaskTypeTrabalho, Priority: models.PriorityUrgente, DueIn: dueIn(3 * time.Hour), Tags: []string{"Urgente", "Projeto"}},
+	{Title: "Consulta médica", Description: "Check-up anual", Type: models.TaskTypeSaude, Priority: models.PriorityAlta, DueIn: dueIn(7 * 24 * time.Hour)},
+	{Title: "Assistir a um filme", Type: models.TaskTypeLazer, Priority: models.PriorityBaixa},
+	{Title: "Pagar contas atrasadas", Description: "Luz e internet", Type: models.TaskTypeCasa, Priority: models.PriorityAlta, DueIn: dueIn(-24 * time.Hour), Tags: []string{"Urgente"}},
+	{Title: "Revisar pull requests", Type: models.TaskTypeTrabalho, Priority: models.PriorityMedia, DueIn: dueIn(48 * time.Hour), Tags: []string{"Projeto"}},
+	{Title: "Caminhada no parque", Type: models.TaskTypeSaude, Priority: models.PriorityBaixa, DueIn: dueIn(72 * time.Hour), Tags: []string{"Pessoal"}},
+	{Title: "Planejar viagem de férias", Description: "Pesquisar passagens e hospedagem", Type: models.TaskTypeLazer, Priority: models.PriorityMedia, DueIn: dueIn(30 * 24 * time.Hour)},
+}
+
+// seeder creates the demo data through the services
+type seeder struct {
+	userRepo    repositories.UserRepository
+	authService services.AuthService
+	tagService  services.TagService
+	taskService services.TaskService
+	password    string
+	now         time.Time
+}
+
+func main() {
+	users := flag.Int("users", 3, "Number of demo users (demo1, demo2, ...)")
+	tasks := flag.Int("tasks", len(sampleTasks), "Number of sample tasks per user")
+	password := flag.String("password", "password123", "Password of the demo users")
+	flag.Parse()
+
+	if *users < 1 || *tasks < 0 {
+		log.Fatal("-users must be at least 1 and -tasks can't be negative")
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	// Connect to database
+	if err := database.Connect(cfg); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	userRepo := repositories.NewUserRepository()
+	taskRepo := repositories.NewTaskRepository()
+	tagRepo := repositories.NewTagRepository()
+	jwtKeys := auth.NewKeySet(cfg.JWTKeyID, cfg.JWTSecret, cfg.PreviousJWTKeys())
+
+	s := &seeder{
+		userRepo:    userRepo,
+		authService: services.NewAuthService(userRepo, jwtKeys),
+		tagService:  services.NewTagService(tagRepo, cfg.TagColors()),
+		taskService: services.NewTaskService(taskRepo, userRepo, tagRepo, realtime.NewHub(), nil, services.TaskServiceOptions{
+			MaxTags:               cfg.TaskMaxTags,
+			ClearCompletedArchive: cfg.TaskClearCompletedArchive,
+			ListAllMax:            cfg.TaskListAllMax,
+			HideInaccessible:      cfg.TaskHideInaccessible,
+			CommentLockClosed:     cfg.CommentLockClosedTask,
+		}),
+		password: *password,
+		now:      time.Now(),
+	}
+
+	for i := 1; i <= *users; i++ {
+		if err := s.seedUser(fmt.Sprintf("demo%d", i), *tasks); err != nil {
+			log.Fatalf("Failed to seed user demo%d: %v", i, err)
+		}
+	}
+
+	log.Println("Seeding completed")
+}
+
+// seedUser creates the user if needed, then their tags and the first count sample tasks
+func (s *seeder) seedUser(username string, count int) error {
+	user, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		user, _, err = s.authService.Register(username, username+"@example.com", s.password)
+		if err != nil {
+			return err
+		}
+		log.Printf("Created user %s", username)
+	} else {
+		log.Printf("User %s already exists", username)
+	}
+
+	tagIDs := make(map[string]uint, len(sampleTags))
+	for _, sample := range sampleTags {
+		tag, _, err := s.tagService.EnsureByName(user.ID, sample.Name, sample.Color)
+		if err != nil {
+			return err
+		}
+		tagIDs[sample.Name] = tag.ID
+	}
+
+	for i := 0; i < count; i++ {
+		sample := sampleTasks[i%len(sampleTasks)]
+		title := sample.Title
+		if i >= len(sampleTasks) {
+			title = fmt.Sprintf("%s (%d)", sample.Title, i/len(sampleTasks)+1)
+		}
+
+		exists, err := s.hasTask(user.ID, title)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		req := &services.CreateTaskRequest{
+			Title:       title,
+			Description: sample.Description,
+			Type:        sample.Type,
+			Priority:    &sample.Priority,
+		}
+		if sample.DueIn != nil {
+			dueDate := s.now.Add(*sample.DueIn)
+			req.DueDate = &dueDate
+		}
+		for _, name := range sample.Tags {
+			req.TagIDs = append(req.TagIDs, tagIDs[name])
+		}
+
+		if _, err := s.taskService.Create(context.Background(), user.ID, req); err != nil {
+			return err
+		}
+		log.Printf("Created task %q for %s", title, username)
+	}
+
+	return nil
+}
+
+// hasTask checks whether the user already has a task with exactly this title
+func (s *seeder) hasTask(userID uint, title string) (bool, error) {
+	result, err := s.taskService.GetByUserID(context.Background(), userID, &services.TaskFilters{
+		Search:          &title,
+		IncludeArchived: true,
+		Include:         []string{},
+		Page:            1,
+		Limit:           100,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, task := range result.Tasks {
+		if task.Title == title {
+			return true, nil
+		}
+	}
+	return false, nil
+}
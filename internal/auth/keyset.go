@@ -0,0 +1,57 @@
+// Package auth holds the keys used to sign and verify the API tokens
+package auth
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the key used to sign new tokens and the keys still accepted when verifying them,
+// identified by the "kid" token header. It allows rotating JWT_SECRET without invalidating the
+// tokens signed with the previous secrets until they expire.
+type KeySet struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// NewKeySet creates a key set that signs with currentSecret (identified by currentID) and also
+// verifies tokens signed with the previous keys, given as kid => secret
+func NewKeySet(currentID, currentSecret string, previous map[string]string) *KeySet {
+	keys := make(map[string][]byte, len(previous)+1)
+	for kid, secret := range previous {
+		keys[kid] = []byte(secret)
+	}
+	keys[currentID] = []byte(currentSecret)
+
+	return &KeySet{currentID: currentID, keys: keys}
+}
+
+// SigningKey returns the ID and secret of the key used to sign new tokens
+func (k *KeySet) SigningKey() (string, []byte) {
+	return k.currentID, k.keys[k.currentID]
+}
+
+// Keyfunc selects the verification key by the token's kid header. Tokens without a kid were issued
+// before key IDs were used, so every known key is tried. Only HS256, the method tokens are signed
+// with, is accepted: "none" and any other algorithm are rejected.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodHS256 {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Header["alg"])
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		keySet := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, 0, len(k.keys))}
+		for _, key := range k.keys {
+			keySet.Keys = append(keySet.Keys, key)
+		}
+		return keySet, nil
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func signToken(t *testing.T, kid, secret string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return signed
+}
+
+func TestKeySet(t *testing.T) {
+	keys := NewKeySet("v2", "new-secret", map[string]string{"v1": "old-secret"})
+	parse := func(tokenString string) error {
+		_, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, keys.Keyfunc)
+		return err
+	}
+
+	t.Run("Signs with the current key", func(t *testing.T) {
+		kid, secret := keys.SigningKey()
+		assert.Equal(t, "v2", kid)
+		assert.Equal(t, []byte("new-secret"), secret)
+	})
+
+	t.Run("Accepts tokens signed with current and previous keys", func(t *testing.T) {
+		assert.NoError(t, parse(signToken(t, "v2", "new-secret")))
+		assert.NoError(t, parse(signToken(t, "v1", "old-secret")))
+	})
+
+	t.Run("Tokens without kid are checked against every key", func(t *testing.T) {
+		assert.NoError(t, parse(signToken(t, "", "old-secret")))
+		assert.Error(t, parse(signToken(t, "", "unknown-secret")))
+	})
+
+	t.Run("Rejects unknown kids and keys that don't match the kid", func(t *testing.T) {
+		assert.Error(t, parse(signToken(t, "v0", "old-secret")))
+		assert.Error(t, parse(signToken(t, "v2", "old-secret")))
+	})
+}
+
+func TestKeySetRejectsOtherSigningMethods(t *testing.T) {
+	keys := NewKeySet("v1", "secret", nil)
+	claims := &jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	parse := func(tokenString string) error {
+		_, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, keys.Keyfunc)
+		return err
+	}
+
+	t.Run("alg none", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		assert.NoError(t, err)
+		assert.Error(t, parse(signed))
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+		assert.NoError(t, err)
+		assert.Error(t, parse(signed))
+	})
+
+	t.Run("Other HMAC methods signed with a valid secret", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+		token.Header["kid"] = "v1"
+		signed, err := token.SignedString([]byte("secret"))
+		assert.NoError(t, err)
+		assert.Error(t, parse(signed))
+	})
+
+	t.Run("HS256", func(t *testing.T) {
+		assert.NoError(t, parse(signToken(t, "v1", "secret")))
+	})
+}
@@ -1,41 +1,75 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// commentMaxLengthLimit is the highest COMMENT_MAX_LENGTH accepted: comments are stored in a MySQL
+// TEXT column, which holds 65,535 bytes, and a character takes up to 4 bytes in utf8mb4
+const commentMaxLengthLimit = 65535 / 4
+
 type Config struct {
-	Port         string
-	JWTSecret    string
-	DatabasePath string
+	Port                string
+	JWTSecret           string
+	JWTKeyID            string // ID (kid header) of JWT_SECRET, used to sign new tokens (default: "default")
+	JWTPreviousKeys     string // Comma-separated kid:secret pairs of rotated secrets still accepted until their tokens expire
+	AuthVerifyUser      bool   // Check on every authenticated request that the token's user still exists (default: true)
+	DatabasePath        string
+	DatabaseAutoMigrate bool   // Also run GORM AutoMigrate after the versioned migrations (development only, default: false)
+	DatabaseLogLevel    string // GORM log level: "silent", "error", "warn" or "info" (every SQL statement). Default: "warn" with GIN_MODE=release, "info" otherwise
+	MaxRequestBodyBytes int64  // Maximum request body size in bytes (default: 1MB)
+	// Admin configuration
+	AdminUsernames string // Comma-separated list of usernames allowed to use the /admin endpoints
+	// Tags configuration
+	TagColorPalette string // Comma-separated list of hex colors allowed for tags (empty allows any hex color)
+	TaskMaxTags     int    // Maximum number of tags attached to a task (default: 20)
+	// Tasks configuration
+	TaskClearCompletedArchive bool // Clearing completed tasks archives them instead of deleting them (default: false)
+	TaskListAllMax            int  // Maximum number of tasks returned at once by GET /tasks?limit=0 (default: 1000)
+	TaskHideInaccessible      bool // Answer 404 instead of 403 for tasks the user can't access, hiding that they exist (default: false)
+	// Comments configuration
+	CommentMaxPinned      int  // Maximum number of pinned comments per task (default: 3)
+	CommentLockClosedTask bool // Reject new comments on tasks that are completed and archived (default: false)
+	CommentMaxLength      int  // Maximum number of characters in a comment (default: 5000, at most 16383)
 	// MySQL configuration
-	DatabaseHost     string
-	DatabasePort     string
-	DatabaseUser     string
-	DatabasePassword string
-	DatabaseName     string
+	DatabaseHost           string
+	DatabasePort           string
+	DatabaseUser           string
+	DatabasePassword       string
+	DatabaseName           string
+	DatabaseLegacyTimezone string // IANA timezone the timestamps were written in before being stored in UTC, read once by the UTC timestamps migration (default: "Local", the server timezone)
 	// CORS configuration
 	CORSAllowedOrigins   string // Comma-separated list of allowed origins (e.g., "http://localhost:3000,https://example.com")
 	CORSAllowedMethods   string // Comma-separated list of allowed methods (default: "GET,POST,PUT,DELETE,OPTIONS")
 	CORSAllowedHeaders   string // Comma-separated list of allowed headers (default: "Content-Type,Authorization")
-	CORSExposedHeaders   string // Comma-separated list of exposed headers
+	CORSExposedHeaders   string // Comma-separated list of exposed headers (default: the pagination headers)
 	CORSAllowCredentials bool   // Whether to allow credentials (default: true)
 	CORSMaxAge           int    // Max age for preflight requests in seconds (default: 3600)
+	CORSStrictPreflight  bool   // Reject disallowed preflights with 403 and echo only the requested method/headers (default: false)
 	// Notifications configuration
 	NotificationsEnabled      bool   // Enable/disable notifications (default: true)
 	NotificationCheckInterval string // Cron expression for notification check (default: "0 * * * *" - every hour)
+	NotificationDueSoonDays   int    // Tasks due within this many days (excluding today) get a due soon notification (default: 1)
+	NotificationCommentAll    bool   // Notify the assigner and shared users about new comments, not only the task owner (default: false)
+	FrontendBaseURL           string // Base URL of the web app, used to link to tasks in notifications (e.g. "https://todo.example.com"). Empty omits the links
 	// Email SMTP configuration
-	SMTPHost     string
-	SMTPPort     string
-	SMTPUser     string
-	SMTPPassword string
-	SMTPFrom     string
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUser      string
+	SMTPPassword  string
+	SMTPFrom      string
+	SMTPMode      string // TLS mode: "none", "starttls" or "tls" (implicit TLS). Default: "tls" on port 465, "starttls" otherwise
+	SMTPKeepAlive bool   // Send the emails of a notification run over one connection (default: false)
 	// Telegram Bot configuration
-	TelegramBotToken string // Telegram bot token
+	TelegramBotToken      string // Telegram bot token
+	TelegramWebhookSecret string // Secret expected in the X-Telegram-Bot-Api-Secret-Token header of webhook calls (optional)
 }
 
 func Load() (*Config, error) {
@@ -59,48 +93,291 @@ func Load() (*Config, error) {
 		corsAllowCredentials = allowCredsStr == "true" || allowCredsStr == "1"
 	}
 
+	// Parse CORS strict preflight
+	corsStrictPreflight := false // Default: answer every OPTIONS request with 204
+	if strictStr := getEnv("CORS_STRICT_PREFLIGHT", ""); strictStr != "" {
+		corsStrictPreflight = strictStr == "true" || strictStr == "1"
+	}
+
+	// Parse auth user verification
+	authVerifyUser := true // Default: reject tokens of deleted users
+	if verifyUserStr := getEnv("AUTH_VERIFY_USER", ""); verifyUserStr != "" {
+		authVerifyUser = verifyUserStr == "true" || verifyUserStr == "1"
+	}
+
+	// Parse database auto migrate
+	databaseAutoMigrate := false // Default: schema changes only through versioned migrations
+	if autoMigrateStr := getEnv("DATABASE_AUTO_MIGRATE", ""); autoMigrateStr != "" {
+		databaseAutoMigrate = autoMigrateStr == "true" || autoMigrateStr == "1"
+	}
+
+	// Parse the GORM log level
+	databaseLogLevel := "info" // Default: log every SQL statement during development
+	if getEnv("GIN_MODE", "") == "release" {
+		databaseLogLevel = "warn" // Only slow queries and errors in production
+	}
+	if levelStr := strings.ToLower(getEnv("DATABASE_LOG_LEVEL", "")); levelStr != "" {
+		switch levelStr {
+		case "silent", "error", "warn", "info":
+			databaseLogLevel = levelStr
+		default:
+			log.Printf("Invalid DATABASE_LOG_LEVEL %q (expected silent, error, warn or info), using %q", levelStr, databaseLogLevel)
+		}
+	}
+
+	// Parse the timezone of the timestamps written before they were stored in UTC
+	databaseLegacyTimezone := "Local" // Default: the server timezone, which the DSN used before
+	if tzStr := getEnv("DATABASE_LEGACY_TIMEZONE", ""); tzStr != "" {
+		if _, err := time.LoadLocation(tzStr); err == nil {
+			databaseLegacyTimezone = tzStr
+		} else {
+			log.Printf("Invalid DATABASE_LEGACY_TIMEZONE %q (%v), using %q", tzStr, err, databaseLegacyTimezone)
+		}
+	}
+
+	// Parse tags per task limit
+	taskMaxTags := 20 // Default: 20 tags per task
+	if maxTagsStr := getEnv("TASK_MAX_TAGS", ""); maxTagsStr != "" {
+		if parsed, err := parseInt(maxTagsStr); err == nil && parsed > 0 {
+			taskMaxTags = parsed
+		}
+	}
+
+	// Parse clear completed mode
+	taskClearCompletedArchive := false // Default: clearing completed tasks deletes them
+	if archiveStr := getEnv("TASK_CLEAR_COMPLETED_ARCHIVE", ""); archiveStr != "" {
+		taskClearCompletedArchive = archiveStr == "true" || archiveStr == "1"
+	}
+
+	// Parse the cap of unpaginated task lists
+	taskListAllMax := 1000 // Default: 1000 tasks
+	if listAllMaxStr := getEnv("TASK_LIST_ALL_MAX", ""); listAllMaxStr != "" {
+		if parsed, err := parseInt(listAllMaxStr); err == nil && parsed > 0 {
+			taskListAllMax = parsed
+		}
+	}
+
+	// Parse the inaccessible tasks policy
+	taskHideInaccessible := false // Default: inaccessible tasks are answered with 403
+	if hideStr := getEnv("TASK_HIDE_INACCESSIBLE", ""); hideStr != "" {
+		taskHideInaccessible = hideStr == "true" || hideStr == "1"
+	}
+
+	// Parse comment pin limit
+	commentMaxPinned := 3 // Default: 3 pinned comments per task
+	if maxPinnedStr := getEnv("COMMENT_MAX_PINNED", ""); maxPinnedStr != "" {
+		if parsed, err := parseInt(maxPinnedStr); err == nil && parsed > 0 {
+			commentMaxPinned = parsed
+		}
+	}
+
+	// Parse comment length limit
+	commentMaxLength := 5000 // Default: 5000 characters
+	if maxLengthStr := getEnv("COMMENT_MAX_LENGTH", ""); maxLengthStr != "" {
+		if parsed, err := parseInt(maxLengthStr); err == nil && parsed > 0 {
+			commentMaxLength = parsed
+		}
+	}
+	if commentMaxLength > commentMaxLengthLimit {
+		log.Printf("COMMENT_MAX_LENGTH %d doesn't fit the comments column, using %d", commentMaxLength, commentMaxLengthLimit)
+		commentMaxLength = commentMaxLengthLimit
+	}
+
+	// Parse comment lock on closed tasks
+	commentLockClosedTask := false // Default: closed tasks still accept comments
+	if lockStr := getEnv("COMMENT_LOCK_CLOSED_TASKS", ""); lockStr != "" {
+		commentLockClosedTask = lockStr == "true" || lockStr == "1"
+	}
+
 	// Parse notifications enabled
 	notificationsEnabled := true // Default: enabled
 	if enabledStr := getEnv("NOTIFICATIONS_ENABLED", ""); enabledStr != "" {
 		notificationsEnabled = enabledStr == "true" || enabledStr == "1"
 	}
 
+	// Parse due soon window
+	notificationDueSoonDays := 1 // Default: tomorrow only
+	if dueSoonStr := getEnv("NOTIFICATION_DUE_SOON_DAYS", ""); dueSoonStr != "" {
+		if parsed, err := parseInt(dueSoonStr); err == nil && parsed > 0 {
+			notificationDueSoonDays = parsed
+		}
+	}
+
+	// Parse comment notification recipients
+	notificationCommentAll := false // Default: only the task owner is notified
+	if commentAllStr := getEnv("NOTIFICATION_COMMENT_ALL", ""); commentAllStr != "" {
+		notificationCommentAll = commentAllStr == "true" || commentAllStr == "1"
+	}
+
+	// Parse SMTP TLS mode
+	smtpPort := getEnv("SMTP_PORT", "587")
+	smtpMode := "starttls" // Default: upgrade the connection with STARTTLS
+	if smtpPort == "465" {
+		smtpMode = "tls" // Port 465 expects implicit TLS
+	}
+	if modeStr := strings.ToLower(getEnv("SMTP_MODE", "")); modeStr != "" {
+		switch modeStr {
+		case "none", "starttls", "tls":
+			smtpMode = modeStr
+		default:
+			log.Printf("Invalid SMTP_MODE %q (expected none, starttls or tls), using %q", modeStr, smtpMode)
+		}
+	}
+
+	// Parse SMTP connection reuse
+	smtpKeepAlive := false // Default: one connection per email
+	if keepAliveStr := getEnv("SMTP_KEEP_ALIVE", ""); keepAliveStr != "" {
+		smtpKeepAlive = keepAliveStr == "true" || keepAliveStr == "1"
+	}
+
+	// Parse max request body size
+	maxRequestBodyBytes := int64(1 << 20) // Default: 1MB
+	if maxBodyStr := getEnv("MAX_REQUEST_BODY_BYTES", ""); maxBodyStr != "" {
+		if parsed, err := strconv.ParseInt(maxBodyStr, 10, 64); err == nil && parsed > 0 {
+			maxRequestBodyBytes = parsed
+		}
+	}
+
 	config := &Config{
 		Port:                      getEnv("PORT", "8080"),
 		JWTSecret:                 getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		JWTKeyID:                  getEnv("JWT_KEY_ID", "default"),
+		JWTPreviousKeys:           getEnv("JWT_PREVIOUS_KEYS", ""),
+		AuthVerifyUser:            authVerifyUser,
 		DatabasePath:              getEnv("DATABASE_PATH", "todo.db"),
+		DatabaseAutoMigrate:       databaseAutoMigrate,
+		DatabaseLogLevel:          databaseLogLevel,
+		MaxRequestBodyBytes:       maxRequestBodyBytes,
+		AdminUsernames:            getEnv("ADMIN_USERNAMES", ""),
+		TagColorPalette:           getEnv("TAG_COLOR_PALETTE", ""),
+		TaskMaxTags:               taskMaxTags,
+		TaskClearCompletedArchive: taskClearCompletedArchive,
+		TaskListAllMax:            taskListAllMax,
+		TaskHideInaccessible:      taskHideInaccessible,
+		CommentMaxPinned:          commentMaxPinned,
+		CommentLockClosedTask:     commentLockClosedTask,
+		CommentMaxLength:          commentMaxLength,
 		DatabaseHost:              getEnv("DATABASE_HOST", ""),
 		DatabasePort:              getEnv("DATABASE_PORT", "3306"),
 		DatabaseUser:              getEnv("DATABASE_USER", ""),
 		DatabasePassword:          getEnv("DATABASE_PASSWORD", ""),
 		DatabaseName:              getEnv("DATABASE_NAME", ""),
+		DatabaseLegacyTimezone:    databaseLegacyTimezone,
 		CORSAllowedOrigins:        getEnv("CORS_ALLOWED_ORIGINS", "*"), // Default: allow all origins (including same-origin)
 		CORSAllowedMethods:        getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS,PATCH"),
 		CORSAllowedHeaders:        getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,Accept,Origin"),
-		CORSExposedHeaders:        getEnv("CORS_EXPOSED_HEADERS", ""),
+		CORSExposedHeaders:        getEnv("CORS_EXPOSED_HEADERS", "X-Total-Count,X-Page,X-Per-Page,Link"), // Pagination headers readable by browsers
 		CORSAllowCredentials:      corsAllowCredentials,
 		CORSMaxAge:                corsMaxAge,
+		CORSStrictPreflight:       corsStrictPreflight,
 		NotificationsEnabled:      notificationsEnabled,
 		NotificationCheckInterval: getEnv("NOTIFICATION_CHECK_INTERVAL", "0 * * * *"), // Default: every hour
+		NotificationDueSoonDays:   notificationDueSoonDays,
+		NotificationCommentAll:    notificationCommentAll,
+		FrontendBaseURL:           strings.TrimRight(strings.TrimSpace(getEnv("FRONTEND_BASE_URL", "")), "/"),
 		SMTPHost:                  getEnv("SMTP_HOST", ""),
-		SMTPPort:                  getEnv("SMTP_PORT", "587"),
+		SMTPPort:                  smtpPort,
 		SMTPUser:                  getEnv("SMTP_USER", ""),
 		SMTPPassword:              getEnv("SMTP_PASSWORD", ""),
 		SMTPFrom:                  getEnv("SMTP_FROM", ""),
+		SMTPMode:                  smtpMode,
+		SMTPKeepAlive:             smtpKeepAlive,
 		TelegramBotToken:          getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramWebhookSecret:     getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
 	}
 
 	// Log configuration status (without sensitive data)
 	logConfigStatus(config)
+	for _, warning := range notificationChannelWarnings(config) {
+		log.Printf("Warning: %s", warning)
+	}
 
 	return config, nil
 }
 
+// notificationChannelWarnings reports partially configured notification channels. Such a channel
+// is unavailable (the email and Telegram services check the same settings before sending), so
+// the warnings make it obvious at startup instead of on the first failed send.
+func notificationChannelWarnings(cfg *Config) []string {
+	var warnings []string
+
+	if cfg.SMTPHost == "" {
+		var set []string
+		for _, setting := range []struct{ name, value string }{
+			{"SMTP_USER", cfg.SMTPUser},
+			{"SMTP_PASSWORD", cfg.SMTPPassword},
+			{"SMTP_FROM", cfg.SMTPFrom},
+		} {
+			if setting.value != "" {
+				set = append(set, setting.name)
+			}
+		}
+		if len(set) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s set but SMTP_HOST missing, email notifications disabled", strings.Join(set, ", ")))
+		}
+	} else {
+		var missing []string
+		if cfg.SMTPMode != "none" && cfg.SMTPUser == "" {
+			missing = append(missing, "SMTP_USER")
+		}
+		if cfg.SMTPMode != "none" && cfg.SMTPPassword == "" {
+			missing = append(missing, "SMTP_PASSWORD")
+		}
+		if cfg.SMTPFrom == "" {
+			missing = append(missing, "SMTP_FROM")
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, fmt.Sprintf("SMTP_HOST set but %s missing, email notifications disabled", strings.Join(missing, ", ")))
+		}
+	}
+
+	if cfg.TelegramBotToken == "" && cfg.TelegramWebhookSecret != "" {
+		warnings = append(warnings, "TELEGRAM_WEBHOOK_SECRET set but TELEGRAM_BOT_TOKEN missing, Telegram notifications disabled")
+	}
+
+	return warnings
+}
+
 // UseMySQL returns true if MySQL configuration is provided
 func (c *Config) UseMySQL() bool {
 	return c.DatabaseHost != "" && c.DatabaseUser != "" && c.DatabaseName != ""
 }
 
+// PreviousJWTKeys parses JWT_PREVIOUS_KEYS ("kid:secret,kid:secret") into kid => secret.
+// Malformed entries are ignored.
+func (c *Config) PreviousJWTKeys() map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(c.JWTPreviousKeys, ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || kid == "" || secret == "" {
+			continue
+		}
+		keys[kid] = secret
+	}
+	return keys
+}
+
+// LegacyTimezone returns the location of DATABASE_LEGACY_TIMEZONE, falling back to the server
+// timezone if it can't be loaded
+func (c *Config) LegacyTimezone() *time.Location {
+	loc, err := time.LoadLocation(c.DatabaseLegacyTimezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// TagColors returns the allowed tag colors from TAG_COLOR_PALETTE, or nil if any color is allowed
+func (c *Config) TagColors() []string {
+	var colors []string
+	for _, color := range strings.Split(c.TagColorPalette, ",") {
+		if color = strings.TrimSpace(color); color != "" {
+			colors = append(colors, color)
+		}
+	}
+	return colors
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -116,18 +393,41 @@ func parseInt(s string) (int, error) {
 func logConfigStatus(cfg *Config) {
 	log.Println("=== Configuration Status ===")
 	log.Printf("Port: %s", cfg.Port)
+	log.Printf("JWT Key ID: %s", cfg.JWTKeyID)
+	log.Printf("JWT Previous Keys: %d", len(cfg.PreviousJWTKeys()))
+	log.Printf("Auth Verify User: %v", cfg.AuthVerifyUser)
+	log.Printf("Database Auto Migrate: %v", cfg.DatabaseAutoMigrate)
+	log.Printf("Database Log Level: %s", cfg.DatabaseLogLevel)
+	log.Printf("Database Legacy Timezone: %s", cfg.DatabaseLegacyTimezone)
+	log.Printf("Max Request Body Bytes: %d", cfg.MaxRequestBodyBytes)
+	log.Printf("Admin Usernames: %s", maskIfEmpty(cfg.AdminUsernames))
+	log.Printf("Tag Color Palette: %s", cfg.TagColorPalette)
+	log.Printf("Task Max Tags: %d", cfg.TaskMaxTags)
+	log.Printf("Task Clear Completed Archive: %v", cfg.TaskClearCompletedArchive)
+	log.Printf("Task List All Max: %d", cfg.TaskListAllMax)
+	log.Printf("Task Hide Inaccessible: %v", cfg.TaskHideInaccessible)
+	log.Printf("Comment Max Pinned: %d", cfg.CommentMaxPinned)
+	log.Printf("Comment Lock Closed Tasks: %v", cfg.CommentLockClosedTask)
+	log.Printf("Comment Max Length: %d", cfg.CommentMaxLength)
 	log.Printf("CORS Allowed Origins: %s", cfg.CORSAllowedOrigins)
 	log.Printf("CORS Allow Credentials: %v", cfg.CORSAllowCredentials)
 	log.Printf("CORS Allowed Methods: %s", cfg.CORSAllowedMethods)
 	log.Printf("CORS Allowed Headers: %s", cfg.CORSAllowedHeaders)
+	log.Printf("CORS Strict Preflight: %v", cfg.CORSStrictPreflight)
 	log.Printf("Notifications Enabled: %v", cfg.NotificationsEnabled)
 	log.Printf("Notification Interval: %s", cfg.NotificationCheckInterval)
+	log.Printf("Notification Due Soon Days: %d", cfg.NotificationDueSoonDays)
+	log.Printf("Notification Comment All Collaborators: %v", cfg.NotificationCommentAll)
+	log.Printf("Frontend Base URL: %s", maskIfEmpty(cfg.FrontendBaseURL))
 	log.Printf("SMTP Host: %s", maskIfEmpty(cfg.SMTPHost))
 	log.Printf("SMTP Port: %s", cfg.SMTPPort)
 	log.Printf("SMTP User: %s", maskIfEmpty(cfg.SMTPUser))
 	log.Printf("SMTP Password: %s", maskIfEmpty(cfg.SMTPPassword))
 	log.Printf("SMTP From: %s", maskIfEmpty(cfg.SMTPFrom))
+	log.Printf("SMTP Mode: %s", cfg.SMTPMode)
+	log.Printf("SMTP Keep Alive: %v", cfg.SMTPKeepAlive)
 	log.Printf("Telegram Bot Token: %s", maskIfEmpty(cfg.TelegramBotToken))
+	log.Printf("Telegram Webhook Secret: %s", maskIfEmpty(cfg.TelegramWebhookSecret))
 	log.Println("===========================")
 }
 
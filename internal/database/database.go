@@ -2,8 +2,9 @@ package database
 
 import (
 	"fmt"
+	"strings"
+	"time"
 	"todo-go-backend/internal/config"
-	"todo-go-backend/internal/models"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
@@ -21,7 +22,7 @@ func Connect(cfg *config.Config) error {
 	// Use MySQL if configured, otherwise use SQLite
 	if cfg.UseMySQL() {
 		dsn := fmt.Sprintf(
-			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
 			cfg.DatabaseUser,
 			cfg.DatabasePassword,
 			cfg.DatabaseHost,
@@ -30,26 +31,60 @@ func Connect(cfg *config.Config) error {
 		)
 		dialector = mysql.Open(dsn)
 	} else {
-		dialector = sqlite.Open(cfg.DatabasePath)
+		dialector = sqlite.Open(sqliteDSN(cfg.DatabasePath))
 	}
 
 	DB, err = gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:  logger.Default.LogMode(logLevel(cfg.DatabaseLogLevel)),
+		NowFunc: NowUTC,
 	})
 
 	if err != nil {
 		return err
 	}
 
-	// Auto migrate
-	err = DB.AutoMigrate(
-		&models.User{},
-		&models.Task{},
-		&models.TaskSharedWith{},
-		&models.Tag{},
-		&models.Comment{},
-		&models.Notification{},
-	)
-
-	return err
+	if err := setupJoinTables(DB); err != nil {
+		return err
+	}
+
+	legacyTimezone = cfg.LegacyTimezone()
+	if err := Migrate(DB); err != nil {
+		return err
+	}
+
+	// Development fallback: sync the tables with the models without writing a migration
+	if cfg.DatabaseAutoMigrate {
+		return DB.AutoMigrate(allModels...)
+	}
+
+	return nil
+}
+
+// logLevel maps config.Config.DatabaseLogLevel to the GORM log level
+func logLevel(level string) logger.LogLevel {
+	switch level {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "warn":
+		return logger.Warn
+	default:
+		return logger.Info
+	}
+}
+
+// NowUTC is used by GORM to fill CreatedAt/UpdatedAt, so every timestamp is stored in UTC
+// regardless of the server timezone
+func NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+// sqliteDSN enables foreign key enforcement, which SQLite leaves off by default,
+// so ON DELETE CASCADE constraints are applied, and reads timestamps back in UTC
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&_foreign_keys=on&_loc=UTC"
+	}
+	return path + "?_foreign_keys=on&_loc=UTC"
 }
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change. Migrations run in the order they are listed, each in
+// its own transaction, and are recorded in the schema_migrations table so they run only once.
+type Migration struct {
+	ID      string // Unique version, prefixed with the date so the list stays sorted (e.g. "20261016_add_task_status")
+	Migrate func(tx *gorm.DB) error
+}
+
+// initSchemaID is recorded when the schema is created by initSchema or an existing database is
+// taken as the baseline, so the database is never considered unmigrated again
+const initSchemaID = "00000000_init_schema"
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	ID        string    `gorm:"type:varchar(255);primaryKey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for schemaMigration
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrate brings the schema up to date by running the pending versioned migrations
+func Migrate(db *gorm.DB) error {
+	return runMigrations(db, initSchema, migrations)
+}
+
+// runMigrations applies the pending migrations. On a new database, initSchema creates the current
+// schema instead and every migration is recorded as applied, since the models already include
+// their changes. A database created by AutoMigrate before versioned migrations were introduced
+// first gets what initSchema adds to it (the tables and columns added to the models while the
+// schema was still auto-migrated), is then taken as the baseline and gets every migration.
+func runMigrations(db *gorm.DB, initSchema func(tx *gorm.DB) error, migrations []Migration) error {
+	if err := validateMigrations(migrations); err != nil {
+		return err
+	}
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var applied []schemaMigration
+	if err := db.Find(&applied).Error; err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	if len(applied) == 0 {
+		if isNewDatabase(tables) {
+			return db.Transaction(func(tx *gorm.DB) error {
+				if err := initSchema(tx); err != nil {
+					return fmt.Errorf("failed to initialize schema: %w", err)
+				}
+				if err := recordMigration(tx, initSchemaID); err != nil {
+					return err
+				}
+				for _, migration := range migrations {
+					if err := recordMigration(tx, migration.ID); err != nil {
+						return err
+					}
+				}
+				log.Printf("Initialized database schema (%d migrations marked as applied)", len(migrations))
+				return nil
+			})
+		}
+
+		err := withoutForeignKeys(db, func(conn *gorm.DB) error {
+			return conn.Transaction(func(tx *gorm.DB) error {
+				if err := initSchema(tx); err != nil {
+					return fmt.Errorf("failed to update existing schema: %w", err)
+				}
+				return recordMigration(tx, initSchemaID)
+			})
+		})
+		if err != nil {
+			return err
+		}
+		log.Println("Existing database updated and recorded as the migrations baseline")
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, migration := range applied {
+		done[migration.ID] = true
+	}
+
+	for _, migration := range migrations {
+		if done[migration.ID] {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Migrate(tx); err != nil {
+				return err
+			}
+			return recordMigration(tx, migration.ID)
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.ID, err)
+		}
+		log.Printf("Applied migration %s", migration.ID)
+	}
+
+	return nil
+}
+
+// withoutForeignKeys runs fc on a single connection with SQLite foreign key enforcement turned off.
+// SQLite changes a column by rebuilding its table, and dropping a table that others reference fails
+// while enforcement is on. The PRAGMA has no effect inside a transaction, so it is set before.
+func withoutForeignKeys(db *gorm.DB, fc func(conn *gorm.DB) error) error {
+	if db.Dialector.Name() != "sqlite" {
+		return fc(db)
+	}
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+		return fc(conn)
+	})
+}
+
+// isNewDatabase reports whether the database had no tables besides schema_migrations
+func isNewDatabase(tables []string) bool {
+	for _, table := range tables {
+		if table != (schemaMigration{}).TableName() {
+			return false
+		}
+	}
+	return true
+}
+
+// validateMigrations rejects empty, duplicate or out of order migration IDs
+func validateMigrations(migrations []Migration) error {
+	previous := initSchemaID
+	for _, migration := range migrations {
+		if migration.ID == "" || migration.Migrate == nil {
+			return fmt.Errorf("migration after %q must have an ID and a Migrate function", previous)
+		}
+		if migration.ID <= previous {
+			return fmt.Errorf("migration %q must sort after %q", migration.ID, previous)
+		}
+		previous = migration.ID
+	}
+	return nil
+}
+
+// recordMigration marks a migration as applied
+func recordMigration(tx *gorm.DB, id string) error {
+	if err := tx.Create(&schemaMigration{ID: id, AppliedAt: time.Now().UTC()}).Error; err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", id, err)
+	}
+	return nil
+}
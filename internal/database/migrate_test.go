@@ -0,0 +1,214 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo-go-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type migrationTestItem struct {
+	ID   uint
+	Name string
+}
+
+// legacyMigrationTestItem is migrationTestItem before the Name column was added
+type legacyMigrationTestItem struct {
+	ID uint
+}
+
+// TableName makes legacyMigrationTestItem use the migrationTestItem table
+func (legacyMigrationTestItem) TableName() string {
+	return "migration_test_items"
+}
+
+func openMigrationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	return db
+}
+
+func appliedMigrationIDs(t *testing.T, db *gorm.DB) []string {
+	var ids []string
+	assert.NoError(t, db.Model(&schemaMigration{}).Order("id").Pluck("id", &ids).Error)
+	return ids
+}
+
+func TestRunMigrations(t *testing.T) {
+	initSchema := func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&migrationTestItem{})
+	}
+	addItem := func(name string) func(tx *gorm.DB) error {
+		return func(tx *gorm.DB) error {
+			return tx.Create(&migrationTestItem{Name: name}).Error
+		}
+	}
+
+	t.Run("New database is initialized and every migration is marked as applied", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		migrations := []Migration{{ID: "0001_first", Migrate: addItem("first")}}
+
+		assert.NoError(t, runMigrations(db, initSchema, migrations))
+
+		var count int64
+		db.Model(&migrationTestItem{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, []string{initSchemaID, "0001_first"}, appliedMigrationIDs(t, db))
+	})
+
+	t.Run("Database initialized before any migration existed still runs later ones", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		assert.NoError(t, runMigrations(db, initSchema, nil))
+
+		assert.NoError(t, runMigrations(db, initSchema, []Migration{{ID: "0001_first", Migrate: addItem("first")}}))
+
+		var names []string
+		db.Model(&migrationTestItem{}).Pluck("name", &names)
+		assert.Equal(t, []string{"first"}, names)
+	})
+
+	t.Run("Database created before versioned migrations gets every migration", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		assert.NoError(t, db.AutoMigrate(&legacyMigrationTestItem{}))
+
+		assert.NoError(t, runMigrations(db, initSchema, []Migration{{ID: "0001_first", Migrate: addItem("first")}}))
+
+		var names []string
+		db.Model(&migrationTestItem{}).Pluck("name", &names)
+		assert.Equal(t, []string{"first"}, names)
+		assert.Equal(t, []string{initSchemaID, "0001_first"}, appliedMigrationIDs(t, db))
+	})
+
+	t.Run("Only pending migrations run, in order", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		assert.NoError(t, runMigrations(db, initSchema, []Migration{{ID: "0001_first", Migrate: addItem("first")}}))
+
+		migrations := []Migration{
+			{ID: "0001_first", Migrate: addItem("first")},
+			{ID: "0002_second", Migrate: addItem("second")},
+			{ID: "0003_third", Migrate: addItem("third")},
+		}
+		assert.NoError(t, runMigrations(db, initSchema, migrations))
+		assert.NoError(t, runMigrations(db, initSchema, migrations))
+
+		var names []string
+		db.Model(&migrationTestItem{}).Order("id").Pluck("name", &names)
+		assert.Equal(t, []string{"second", "third"}, names)
+		assert.Equal(t, []string{initSchemaID, "0001_first", "0002_second", "0003_third"}, appliedMigrationIDs(t, db))
+	})
+
+	t.Run("Failed migration is rolled back and not recorded", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		assert.NoError(t, runMigrations(db, initSchema, []Migration{{ID: "0001_first", Migrate: addItem("first")}}))
+
+		failing := func(tx *gorm.DB) error {
+			if err := tx.Create(&migrationTestItem{Name: "partial"}).Error; err != nil {
+				return err
+			}
+			return tx.Exec("SELECT * FROM missing_table").Error
+		}
+		err := runMigrations(db, initSchema, []Migration{
+			{ID: "0001_first", Migrate: addItem("first")},
+			{ID: "0002_broken", Migrate: failing},
+		})
+
+		assert.ErrorContains(t, err, "0002_broken")
+		var count int64
+		db.Model(&migrationTestItem{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, []string{initSchemaID, "0001_first"}, appliedMigrationIDs(t, db))
+	})
+
+	t.Run("Duplicate or unordered IDs are rejected", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+
+		err := runMigrations(db, initSchema, []Migration{
+			{ID: "0002_second", Migrate: addItem("second")},
+			{ID: "0001_first", Migrate: addItem("first")},
+		})
+		assert.Error(t, err)
+
+		err = runMigrations(db, initSchema, []Migration{
+			{ID: "0001_first", Migrate: addItem("first")},
+			{ID: "0001_first", Migrate: addItem("first")},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrateUTCTimestamps(t *testing.T) {
+	t.Run("Rewrites every row of large tables in UTC", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		assert.NoError(t, db.Exec("CREATE TABLE settings (key TEXT PRIMARY KEY, updated_at DATETIME)").Error)
+
+		saoPaulo := time.FixedZone("-03", -3*60*60)
+		written := time.Date(2024, 1, 1, 10, 0, 0, 0, saoPaulo)
+		rows := utcTimestampsBatchSize*2 + 1
+		assert.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			for i := 0; i < rows; i++ {
+				if err := tx.Exec("INSERT INTO settings (key, updated_at) VALUES (?, ?)", fmt.Sprintf("key-%04d", i), written).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+		assert.NoError(t, migrateUTCTimestamps(db))
+
+		var values []string
+		assert.NoError(t, db.Raw("SELECT updated_at FROM settings").Scan(&values).Error)
+		assert.Len(t, values, rows)
+		for _, value := range values {
+			assert.Contains(t, value, "13:00:00")
+		}
+	})
+
+	t.Run("MySQL wall clock times are read in the legacy timezone", func(t *testing.T) {
+		stored := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC), toUTC(stored, time.FixedZone("-03", -3*60*60)))
+		assert.Equal(t, stored, toUTC(stored, nil))
+	})
+}
+
+func TestMigrateTagNameKey(t *testing.T) {
+	db := openMigrationTestDB(t)
+	assert.NoError(t, db.Exec("CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT, color TEXT, default_color NUMERIC, "+
+		"user_id INTEGER, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)").Error)
+	assert.NoError(t, db.Exec("CREATE TABLE task_tags (task_id INTEGER, tag_id INTEGER, PRIMARY KEY (task_id, tag_id))").Error)
+
+	// Tags 1 and 2 are the same for user 1; tag 3 belongs to another user
+	for _, tag := range []struct {
+		id, userID uint
+		name       string
+	}{{1, 1, "Work"}, {2, 1, "work"}, {3, 2, "WORK"}} {
+		assert.NoError(t, db.Exec("INSERT INTO tags (id, name, user_id) VALUES (?, ?, ?)", tag.id, tag.name, tag.userID).Error)
+	}
+	for _, taskTag := range [][2]uint{{10, 1}, {10, 2}, {11, 2}} {
+		assert.NoError(t, db.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?)", taskTag[0], taskTag[1]).Error)
+	}
+
+	assert.NoError(t, db.Transaction(migrateTagNameKey))
+
+	var live []models.Tag
+	assert.NoError(t, db.Order("id").Find(&live).Error)
+	if assert.Len(t, live, 2) {
+		assert.Equal(t, uint(1), live[0].ID)
+		assert.Equal(t, "work", *live[0].NameKey)
+		assert.Equal(t, uint(3), live[1].ID)
+	}
+
+	var taskIDs []uint
+	assert.NoError(t, db.Table("task_tags").Where("tag_id = ?", 1).Order("task_id").Pluck("task_id", &taskIDs).Error)
+	assert.Equal(t, []uint{10, 11}, taskIDs)
+
+	// The index now rejects a second tag with the same name for the user
+	assert.Error(t, db.Create(&models.Tag{Name: "WORK", UserID: 1}).Error)
+	assert.NoError(t, db.Create(&models.Tag{Name: "Home", UserID: 1}).Error)
+}
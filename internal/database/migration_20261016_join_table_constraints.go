@@ -0,0 +1,56 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateJoinTableConstraints adds the composite unique indexes and the ON DELETE CASCADE foreign
+// keys of task_shared_with and task_tags, removing the rows that already point to missing records
+func migrateJoinTableConstraints(tx *gorm.DB) error {
+	orphans := []string{
+		"DELETE FROM task_shared_with WHERE task_id NOT IN (SELECT id FROM tasks) OR user_id NOT IN (SELECT id FROM users)",
+		"DELETE FROM task_tags WHERE task_id NOT IN (SELECT id FROM tasks) OR tag_id NOT IN (SELECT id FROM tags)",
+	}
+	for _, query := range orphans {
+		if err := tx.Exec(query).Error; err != nil {
+			return err
+		}
+	}
+
+	joinTables := []struct {
+		model       interface{}
+		constraints []string
+		indexes     []string
+	}{
+		{&models.TaskSharedWith{}, []string{"Task", "User"}, []string{"idx_task_shared_with_task_user", "idx_task_shared_with_user_id"}},
+		{&models.TaskTag{}, []string{"Task", "Tag"}, []string{"idx_task_tags_task_tag", "idx_task_tags_tag_id"}},
+	}
+
+	migrator := tx.Migrator()
+	for _, table := range joinTables {
+		// Existing foreign keys were created without ON DELETE CASCADE, so they are replaced
+		for _, constraint := range table.constraints {
+			if migrator.HasConstraint(table.model, constraint) {
+				if err := migrator.DropConstraint(table.model, constraint); err != nil {
+					return err
+				}
+			}
+			if err := migrator.CreateConstraint(table.model, constraint); err != nil {
+				return err
+			}
+		}
+
+		// SQLite rebuilds the table to change a constraint, which drops its indexes, so they come last
+		for _, index := range table.indexes {
+			if !migrator.HasIndex(table.model, index) {
+				if err := migrator.CreateIndex(table.model, index); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
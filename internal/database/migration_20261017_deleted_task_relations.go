@@ -0,0 +1,25 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// migrateDeletedTaskRelations cleans up what tasks deleted before the repository removed their
+// relations left behind: shares and tag associations are removed, comments and notifications
+// are soft-deleted at the time of the task's deletion
+func migrateDeletedTaskRelations(tx *gorm.DB) error {
+	queries := []string{
+		"DELETE FROM task_shared_with WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL)",
+		"DELETE FROM task_tags WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL)",
+		"UPDATE comments SET deleted_at = (SELECT tasks.deleted_at FROM tasks WHERE tasks.id = comments.task_id) " +
+			"WHERE deleted_at IS NULL AND task_id IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL)",
+		"UPDATE notifications SET deleted_at = (SELECT tasks.deleted_at FROM tasks WHERE tasks.id = notifications.task_id) " +
+			"WHERE deleted_at IS NULL AND task_id IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL)",
+	}
+	for _, query := range queries {
+		if err := tx.Exec(query).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateCommentPinned adds the comments.pinned column
+func migrateCommentPinned(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.Comment{}, "Pinned") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&models.Comment{}, "Pinned")
+}
@@ -0,0 +1,15 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTaskUpdatedBy adds the tasks.updated_by column
+func migrateTaskUpdatedBy(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.Task{}, "UpdatedBy") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&models.Task{}, "UpdatedBy")
+}
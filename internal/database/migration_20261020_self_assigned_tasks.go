@@ -0,0 +1,11 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// migrateSelfAssignedTasks clears assigned_by on tasks their owner created for themselves, which
+// used to be recorded as a self-assignment and listed among the tasks assigned to others
+func migrateSelfAssignedTasks(tx *gorm.DB) error {
+	return tx.Exec("UPDATE tasks SET assigned_by = NULL WHERE assigned_by = user_id").Error
+}
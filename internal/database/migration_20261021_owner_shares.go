@@ -0,0 +1,11 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// migrateOwnerShares removes share rows that point to the task's own owner, which give no access
+// the owner doesn't already have
+func migrateOwnerShares(tx *gorm.DB) error {
+	return tx.Exec("DELETE FROM task_shared_with WHERE user_id = (SELECT tasks.user_id FROM tasks WHERE tasks.id = task_shared_with.task_id)").Error
+}
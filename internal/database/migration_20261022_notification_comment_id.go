@@ -0,0 +1,20 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateNotificationCommentID adds the notifications.comment_id column and its index
+func migrateNotificationCommentID(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&models.Notification{}, "CommentID") {
+		if err := tx.Migrator().AddColumn(&models.Notification{}, "CommentID"); err != nil {
+			return err
+		}
+	}
+	if tx.Migrator().HasIndex(&models.Notification{}, "CommentID") {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(&models.Notification{}, "CommentID")
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateUserPreferredChannels adds the users.preferred_channels column. Existing users get the
+// column default "both", which keeps sending to every channel.
+func migrateUserPreferredChannels(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.User{}, "PreferredChannels") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&models.User{}, "PreferredChannels")
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateUserNotificationTypes adds the users.notify_due_soon, notify_due_today and notify_overdue
+// columns. Existing users get the column default true, so they keep receiving every reminder.
+func migrateUserNotificationTypes(tx *gorm.DB) error {
+	for _, field := range []string{"NotifyDueSoon", "NotifyDueToday", "NotifyOverdue"} {
+		if tx.Migrator().HasColumn(&models.User{}, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&models.User{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
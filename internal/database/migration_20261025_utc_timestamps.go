@@ -0,0 +1,100 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// timestampColumns lists the timestamp columns of each table, after the table's primary key
+var timestampColumns = []struct {
+	table   string
+	key     string
+	columns []string
+}{
+	{"users", "id", []string{"created_at", "updated_at", "deleted_at"}},
+	{"tasks", "id", []string{"due_date", "created_at", "updated_at", "deleted_at"}},
+	{"tags", "id", []string{"created_at", "updated_at", "deleted_at"}},
+	{"comments", "id", []string{"created_at", "updated_at", "deleted_at"}},
+	{"notifications", "id", []string{"sent_at", "created_at", "updated_at", "deleted_at"}},
+	{"telegram_link_codes", "id", []string{"expires_at", "created_at"}},
+	{"settings", "key", []string{"updated_at"}},
+}
+
+// utcTimestampsBatchSize is how many rows migrateUTCTimestamps loads and rewrites at a time
+const utcTimestampsBatchSize = 500
+
+// legacyTimezone is the timezone MySQL timestamps were written in before they were stored in UTC.
+// Connect sets it from DATABASE_LEGACY_TIMEZONE.
+var legacyTimezone = time.Local
+
+// migrateUTCTimestamps rewrites the stored timestamps in UTC. MySQL DATETIME columns hold the wall
+// clock time of the server that wrote them (the DSN used loc=Local), so they are read as UTC now and
+// converted from legacyTimezone. SQLite keeps the offset and only needs the values rewritten, so
+// every row compares the same way against UTC query arguments. Rows are loaded in batches ordered
+// by the table's key, so large tables aren't read into memory at once.
+func migrateUTCTimestamps(tx *gorm.DB) error {
+	var wallClock *time.Location
+	if tx.Dialector.Name() == "mysql" {
+		wallClock = legacyTimezone
+		log.Printf("Converting stored timestamps from %s to UTC", wallClock)
+	}
+
+	for _, table := range timestampColumns {
+		if !tx.Migrator().HasTable(table.table) {
+			continue
+		}
+
+		var last interface{}
+		for {
+			query := tx.Table(table.table).
+				Select(append([]string{table.key}, table.columns...)).
+				Order(clause.OrderByColumn{Column: clause.Column{Name: table.key}}).
+				Limit(utcTimestampsBatchSize)
+			if last != nil {
+				query = query.Where(clause.Gt{Column: clause.Column{Name: table.key}, Value: last})
+			}
+
+			var rows []map[string]interface{}
+			if err := query.Find(&rows).Error; err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				updates := make(map[string]interface{})
+				for _, column := range table.columns {
+					if value, ok := row[column].(time.Time); ok {
+						updates[column] = toUTC(value, wallClock)
+					}
+				}
+				if len(updates) == 0 {
+					continue
+				}
+
+				err := tx.Table(table.table).
+					Where(clause.Eq{Column: clause.Column{Name: table.key}, Value: row[table.key]}).
+					UpdateColumns(updates).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(rows) < utcTimestampsBatchSize {
+				break
+			}
+			last = rows[len(rows)-1][table.key]
+		}
+	}
+	return nil
+}
+
+// toUTC converts a stored timestamp to UTC. With a wallClock location, t holds the wall clock time
+// of that location labeled as UTC.
+func toUTC(t time.Time, wallClock *time.Location) time.Time {
+	if wallClock != nil {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), wallClock)
+	}
+	return t.UTC()
+}
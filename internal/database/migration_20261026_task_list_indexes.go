@@ -0,0 +1,22 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTaskListIndexes adds the composite indexes used by the task lists on
+// (user_id, completed, due_date) and (assigned_by, completed, due_date), which narrow the rows by
+// owner or assigner and completion (see models.Task)
+func migrateTaskListIndexes(tx *gorm.DB) error {
+	for _, name := range []string{"idx_tasks_user_completed_due", "idx_tasks_assigned_completed_due"} {
+		if tx.Migrator().HasIndex(&models.Task{}, name) {
+			continue
+		}
+		if err := tx.Migrator().CreateIndex(&models.Task{}, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
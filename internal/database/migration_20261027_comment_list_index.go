@@ -0,0 +1,15 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateCommentListIndex adds the (task_id, pinned DESC, created_at) index used by the comment list
+func migrateCommentListIndex(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&models.Comment{}, "idx_comments_task_pinned_created") {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(&models.Comment{}, "idx_comments_task_pinned_created")
+}
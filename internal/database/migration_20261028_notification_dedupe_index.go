@@ -0,0 +1,16 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateNotificationDedupeIndex adds the (user_id, task_id, type, channel, sent_at) index used by
+// the notification dedupe checks
+func migrateNotificationDedupeIndex(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&models.Notification{}, "idx_notifications_dedupe") {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(&models.Notification{}, "idx_notifications_dedupe")
+}
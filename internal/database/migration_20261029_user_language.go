@@ -0,0 +1,16 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateUserLanguage adds the users.language column. Existing users get the column default "pt",
+// so their notifications stay in Portuguese.
+func migrateUserLanguage(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.User{}, "Language") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&models.User{}, "Language")
+}
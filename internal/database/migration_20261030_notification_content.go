@@ -0,0 +1,21 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateNotificationContent adds the notifications.subject and notifications.body columns. Notifications
+// sent before have them empty.
+func migrateNotificationContent(tx *gorm.DB) error {
+	for _, field := range []string{"Subject", "Body"} {
+		if tx.Migrator().HasColumn(&models.Notification{}, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&models.Notification{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
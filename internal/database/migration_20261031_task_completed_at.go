@@ -0,0 +1,22 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTaskCompletedAt adds the tasks.completed_at column. The completion time of tasks completed
+// before isn't known, so their last update is used, which is when they were completed unless they
+// were edited afterwards.
+func migrateTaskCompletedAt(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.Task{}, "CompletedAt") {
+		return nil
+	}
+	if err := tx.Migrator().AddColumn(&models.Task{}, "CompletedAt"); err != nil {
+		return err
+	}
+	return tx.Model(&models.Task{}).Unscoped().
+		Where("completed = ?", true).
+		UpdateColumn("completed_at", gorm.Expr("updated_at")).Error
+}
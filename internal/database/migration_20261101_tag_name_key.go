@@ -0,0 +1,60 @@
+package database
+
+import (
+	"log"
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTagNameKey adds the tags.name_key column and its unique (user_id, name_key) index, which
+// keeps tag names unique per user ignoring case. Tags created before with the same name in
+// different case are merged into the oldest one: their tasks get the oldest tag and they are deleted.
+func migrateTagNameKey(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&models.Tag{}, "idx_tags_user_name_key") {
+		return nil
+	}
+	if !tx.Migrator().HasColumn(&models.Tag{}, "NameKey") {
+		if err := tx.Migrator().AddColumn(&models.Tag{}, "NameKey"); err != nil {
+			return err
+		}
+	}
+
+	var tags []models.Tag
+	if err := tx.Select("id", "user_id", "name").Order("id").Find(&tags).Error; err != nil {
+		return err
+	}
+
+	type userTagKey struct {
+		userID uint
+		key    string
+	}
+	kept := make(map[userTagKey]uint, len(tags))
+	for _, tag := range tags {
+		key := models.TagNameKey(tag.Name)
+		keptID, duplicate := kept[userTagKey{tag.UserID, key}]
+		if !duplicate {
+			kept[userTagKey{tag.UserID, key}] = tag.ID
+			if err := tx.Model(&models.Tag{}).Where("id = ?", tag.ID).UpdateColumn("name_key", key).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		log.Printf("Merging tag %d (%q) into tag %d of user %d", tag.ID, tag.Name, keptID, tag.UserID)
+		err := tx.Exec("INSERT INTO task_tags (task_id, tag_id) SELECT task_id, ? FROM task_tags "+
+			"WHERE tag_id = ? AND task_id NOT IN (SELECT task_id FROM task_tags WHERE tag_id = ?)",
+			keptID, tag.ID, keptID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.TaskTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Tag{}, tag.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Migrator().CreateIndex(&models.Tag{}, "idx_tags_user_name_key")
+}
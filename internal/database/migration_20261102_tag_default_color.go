@@ -0,0 +1,16 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTagDefaultColor adds the tags.default_color column. Whether the color of existing tags was
+// chosen by the user isn't known, so they keep the column default false and their color is kept.
+func migrateTagDefaultColor(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.Tag{}, "DefaultColor") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&models.Tag{}, "DefaultColor")
+}
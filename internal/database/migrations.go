@@ -0,0 +1,63 @@
+package database
+
+import (
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// allModels lists every table managed by the application
+var allModels = []interface{}{
+	&models.User{},
+	&models.Task{},
+	&models.TaskSharedWith{},
+	&models.TaskTag{},
+	&models.Tag{},
+	&models.Comment{},
+	&models.Notification{},
+	&models.TelegramLinkCode{},
+	&models.Setting{},
+}
+
+// migrations is the ordered list of schema changes. To change the schema, update the models and
+// append a migration here (defined in its own migration_<id>.go file) that applies the same change
+// to existing databases, including any data backfill. Never edit or reorder applied migrations.
+var migrations = []Migration{
+	{ID: "20261016_join_table_constraints", Migrate: migrateJoinTableConstraints},
+	{ID: "20261017_deleted_task_relations", Migrate: migrateDeletedTaskRelations},
+	{ID: "20261018_comment_pinned", Migrate: migrateCommentPinned},
+	{ID: "20261019_task_updated_by", Migrate: migrateTaskUpdatedBy},
+	{ID: "20261020_self_assigned_tasks", Migrate: migrateSelfAssignedTasks},
+	{ID: "20261021_owner_shares", Migrate: migrateOwnerShares},
+	{ID: "20261022_notification_comment_id", Migrate: migrateNotificationCommentID},
+	{ID: "20261023_user_preferred_channels", Migrate: migrateUserPreferredChannels},
+	{ID: "20261024_user_notification_types", Migrate: migrateUserNotificationTypes},
+	{ID: "20261025_utc_timestamps", Migrate: migrateUTCTimestamps},
+	{ID: "20261026_task_list_indexes", Migrate: migrateTaskListIndexes},
+	{ID: "20261027_comment_list_index", Migrate: migrateCommentListIndex},
+	{ID: "20261028_notification_dedupe_index", Migrate: migrateNotificationDedupeIndex},
+	{ID: "20261029_user_language", Migrate: migrateUserLanguage},
+	{ID: "20261030_notification_content", Migrate: migrateNotificationContent},
+	{ID: "20261031_task_completed_at", Migrate: migrateTaskCompletedAt},
+	{ID: "20261101_tag_name_key", Migrate: migrateTagNameKey},
+	{ID: "20261102_tag_default_color", Migrate: migrateTagDefaultColor},
+}
+
+// setupJoinTables makes the many2many relations use the join models, so their indexes and
+// foreign keys are part of the schema
+func setupJoinTables(db *gorm.DB) error {
+	if err := db.SetupJoinTable(&models.Task{}, "SharedWithUsers", &models.TaskSharedWith{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&models.Task{}, "Tags", &models.TaskTag{}); err != nil {
+		return err
+	}
+	return db.SetupJoinTable(&models.Tag{}, "Tasks", &models.TaskTag{})
+}
+
+// initSchema creates the schema of a database that has no recorded migrations. On a database
+// created by AutoMigrate before versioned migrations were introduced it only adds the missing
+// tables, columns and indexes; the versioned migrations run afterwards.
+func initSchema(tx *gorm.DB) error {
+	return tx.AutoMigrate(allModels...)
+}
@@ -11,6 +11,8 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrTaskNotFound      = errors.New("task not found")
+	ErrTagNotFound       = errors.New("tag not found")
+	ErrNotificationNotFound = errors.New("notification not found")
 	ErrUnauthorized      = errors.New("unauthorized")
 	ErrForbidden         = errors.New("forbidden")
 	ErrInvalidInput      = errors.New("invalid input")
@@ -56,6 +58,14 @@ func NewTaskNotFoundError() *AppError {
 	return NewAppError(ErrTaskNotFound, "Task not found", http.StatusNotFound)
 }
 
+func NewTagNotFoundError() *AppError {
+	return NewAppError(ErrTagNotFound, "Tag not found", http.StatusNotFound)
+}
+
+func NewNotificationNotFoundError() *AppError {
+	return NewAppError(ErrNotificationNotFound, "Notification not found", http.StatusNotFound)
+}
+
 func NewUnauthorizedError() *AppError {
 	return NewAppError(ErrUnauthorized, "Unauthorized", http.StatusUnauthorized)
 }
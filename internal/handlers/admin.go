@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	stdErrors "errors"
+	"net/http"
+	"todo-go-backend/internal/errors"
+	"todo-go-backend/internal/notifications"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler manages operator handlers
+type AdminHandler struct {
+	scheduler *notifications.Scheduler
+}
+
+// NewAdminHandler creates a new instance of AdminHandler
+func NewAdminHandler(scheduler *notifications.Scheduler) *AdminHandler {
+	return &AdminHandler{
+		scheduler: scheduler,
+	}
+}
+
+// UpdateSchedulerIntervalRequest represents a request to change the notification check interval
+type UpdateSchedulerIntervalRequest struct {
+	Interval string `json:"interval" binding:"required" example:"0 */6 * * *"` // Cron expression (5 fields)
+}
+
+// GetSchedulerStatus returns the notification scheduler state
+// @Summary      Get notification scheduler status
+// @Description  Returns whether the notification check is scheduled and its cron interval. Admin only.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  notifications.SchedulerStatus
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /admin/scheduler [get]
+func (h *AdminHandler) GetSchedulerStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.scheduler.Status())
+}
+
+// PauseScheduler pauses the notification scheduler
+// @Summary      Pause notification scheduler
+// @Description  Stops the periodic notification check. The override is persisted and survives restarts. Admin only.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  notifications.SchedulerStatus
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/scheduler/pause [post]
+func (h *AdminHandler) PauseScheduler(c *gin.Context) {
+	if err := h.scheduler.Pause(); err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.Status())
+}
+
+// ResumeScheduler resumes the notification scheduler
+// @Summary      Resume notification scheduler
+// @Description  Schedules the periodic notification check again. The override is persisted and survives restarts. Not possible while NOTIFICATIONS_ENABLED=false (409). Admin only.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  notifications.SchedulerStatus
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/scheduler/resume [post]
+func (h *AdminHandler) ResumeScheduler(c *gin.Context) {
+	if err := h.scheduler.Resume(); err != nil {
+		if stdErrors.Is(err, notifications.ErrDisabledByConfig) {
+			handleError(c, errors.NewAppError(err, "Notifications are disabled by configuration (NOTIFICATIONS_ENABLED=false)", http.StatusConflict))
+			return
+		}
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.Status())
+}
+
+// UpdateSchedulerInterval changes the notification check interval
+// @Summary      Update notification scheduler interval
+// @Description  Replaces the cron expression of the notification check without restarting. The override is persisted and survives restarts. Admin only.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      UpdateSchedulerIntervalRequest  true  "New cron interval"
+// @Success      200      {object}  notifications.SchedulerStatus
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /admin/scheduler/interval [put]
+func (h *AdminHandler) UpdateSchedulerInterval(c *gin.Context) {
+	var req UpdateSchedulerIntervalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	if err := notifications.ValidateInterval(req.Interval); err != nil {
+		handleError(c, errors.NewInvalidInputError(err.Error()))
+		return
+	}
+
+	if err := h.scheduler.SetInterval(req.Interval); err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.Status())
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"time"
+	"todo-go-backend/internal/models"
 	"todo-go-backend/internal/services"
+	"todo-go-backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,7 +24,7 @@ func NewAuthHandler(authService services.AuthService) *AuthHandler {
 
 // RegisterRequest represents a user registration request
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50" example:"johndoe"`
+	Username string `json:"username" binding:"required,min=3,max=50,excludes=@" example:"johndoe"` // Can't contain '@', which identifies emails on login
 	Email    string `json:"email" binding:"required,email" example:"john@example.com"`
 	Password string `json:"password" binding:"required,min=6" example:"password123"`
 }
@@ -35,20 +38,48 @@ type LoginRequest struct {
 
 // AuthResponse represents an authentication response
 type AuthResponse struct {
-	Message string      `json:"message" example:"Login successful"`
-	Token   string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User    interface{} `json:"user"`
+	Message   string      `json:"message" example:"Login successful"`
+	Token     string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	IssuedAt  time.Time   `json:"issued_at" example:"2024-12-01T10:00:00Z"`
+	ExpiresAt time.Time   `json:"expires_at" example:"2024-12-02T10:00:00Z"`
+	ExpiresIn int64       `json:"expires_in" example:"86400"`              // Seconds until the token expires
+	MatchedBy string      `json:"matched_by,omitempty" example:"username"` // Login only: whether the identifier matched the "username" or the "email"
+	User      interface{} `json:"user"`
+}
+
+// MeResponse represents the authenticated user
+type MeResponse struct {
+	ID       uint         `json:"id" example:"1"`
+	Username string       `json:"username" example:"johndoe"`
+	User     *models.User `json:"user,omitempty"` // Only with full=true
+}
+
+// newAuthResponse builds the authentication response for the user and their new token
+func newAuthResponse(message string, user *models.User, token *utils.SignedToken) AuthResponse {
+	return AuthResponse{
+		Message:   message,
+		Token:     token.Value,
+		IssuedAt:  token.IssuedAt,
+		ExpiresAt: token.ExpiresAt,
+		ExpiresIn: int64(token.ExpiresAt.Sub(token.IssuedAt).Seconds()),
+		User: gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+		},
+	}
 }
 
 // Register registers a new user
 // @Summary      Register a new user
-// @Description  Creates a new user account and returns a JWT token
+// @Description  Creates a new user account and returns a JWT token with its issue and expiry times (expires_in in seconds)
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body      RegisterRequest  true  "User registration data"
 // @Success      201      {object}  AuthResponse
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      409      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /auth/register [post]
@@ -65,26 +96,19 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, AuthResponse{
-		Message: "User created successfully",
-		Token:   token,
-		User: gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-	})
+	c.JSON(http.StatusCreated, newAuthResponse("User created successfully", user, token))
 }
 
 // Login authenticates a user
 // @Summary      Login user
-// @Description  Authenticates a user by username or email and returns a JWT token. The username field accepts either username or email address.
+// @Description  Authenticates a user by username or email and returns a JWT token with its issue and expiry times (expires_in in seconds). The username field accepts either username or email address: a value containing '@' matches an exact email first and then a username, any other value only matches a username. matched_by tells which one matched.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body      LoginRequest  true  "User login credentials. The 'username' field accepts either username (e.g., 'johndoe') or email address (e.g., 'john@example.com')."
 // @Success      200      {object}  AuthResponse
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /auth/login [post]
@@ -95,19 +119,44 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.authService.Login(req.Username, req.Password)
+	user, token, matchedBy, err := h.authService.Login(req.Username, req.Password)
 	if err != nil {
 		handleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, AuthResponse{
-		Message: "Login successful",
-		Token:   token,
-		User: gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-	})
+	response := newAuthResponse("Login successful", user, token)
+	response.MatchedBy = matchedBy
+	c.JSON(http.StatusOK, response)
+}
+
+// Me returns the authenticated user
+// @Summary      Get the authenticated user
+// @Description  Returns the id and username of the authenticated user, taken from the token without querying the database. With full=true the full user is loaded and returned in the user field.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        full  query     bool  false  "Also return the full user (default: false)"
+// @Success      200   {object}  MeResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /auth/me [get]
+func (h *AuthHandler) Me(c *gin.Context) {
+	response := MeResponse{
+		ID:       c.GetUint("user_id"),
+		Username: c.GetString("username"),
+	}
+
+	if c.Query("full") == "true" {
+		user, err := h.authService.GetUser(response.ID)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+		response.Username = user.Username
+		response.User = user
+	}
+
+	c.JSON(http.StatusOK, response)
 }
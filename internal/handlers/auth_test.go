@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
 	"todo-go-backend/pkg/utils"
@@ -69,7 +70,10 @@ func TestRegister(t *testing.T) {
 
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response ValidationErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "email must be a valid email address", response.Fields["email"])
 	})
 }
 
@@ -105,6 +109,22 @@ func TestLogin(t *testing.T) {
 		assert.NotNil(t, response["token"])
 	})
 
+	t.Run("Response includes the token expiry", func(t *testing.T) {
+		jsonValue, _ := json.Marshal(LoginRequest{Username: "testuser", Password: "password123"})
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response AuthResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, int64(utils.TokenTTL.Seconds()), response.ExpiresIn)
+		assert.Equal(t, response.IssuedAt.Add(utils.TokenTTL), response.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(utils.TokenTTL), response.ExpiresAt, time.Minute)
+	})
+
 	t.Run("Invalid credentials", func(t *testing.T) {
 		reqBody := LoginRequest{
 			Username: "testuser",
@@ -122,3 +142,123 @@ func TestLogin(t *testing.T) {
 	})
 }
 
+
+func TestLoginIdentifierResolution(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+
+	// A legacy username that looks like another user's email
+	legacyPassword, _ := utils.HashPassword("legacy-password")
+	legacy := models.User{Username: "alice@example.com", Email: "legacy@example.com", Password: legacyPassword}
+	database.DB.Create(&legacy)
+
+	alicePassword, _ := utils.HashPassword("alice-password")
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: alicePassword}
+	database.DB.Create(&alice)
+
+	login := func(identifier, password string) (*httptest.ResponseRecorder, AuthResponse) {
+		jsonValue, _ := json.Marshal(LoginRequest{Username: identifier, Password: password})
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response AuthResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("Identifier with '@' prefers the exact email match", func(t *testing.T) {
+		w, response := login("alice@example.com", "alice-password")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "email", response.MatchedBy)
+
+		w, _ = login("alice@example.com", "legacy-password")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Identifier with '@' falls back to a legacy username", func(t *testing.T) {
+		legacy.Email = "other@example.com"
+		database.DB.Save(&legacy)
+		database.DB.Model(&alice).Update("email", "alice@example.org")
+
+		w, response := login("alice@example.com", "legacy-password")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "username", response.MatchedBy)
+	})
+
+	t.Run("Identifier without '@' only matches usernames", func(t *testing.T) {
+		w, response := login("alice", "alice-password")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "username", response.MatchedBy)
+	})
+
+	t.Run("Registration rejects usernames with '@'", func(t *testing.T) {
+		jsonValue, _ := json.Marshal(RegisterRequest{Username: "bob@example.com", Email: "bob@example.com", Password: "password123"})
+		req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestDeletedUserTokenRejected(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	getTasks := func() int {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, getTasks())
+
+	database.DB.Delete(&user)
+	assert.Equal(t, http.StatusUnauthorized, getTasks())
+}
+
+func TestMe(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	getMe := func(query string) (int, MeResponse) {
+		req, _ := http.NewRequest("GET", "/api/v1/auth/me"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response MeResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	t.Run("Returns the id and username from the token", func(t *testing.T) {
+		code, response := getMe("")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, user.ID, response.ID)
+		assert.Equal(t, user.Username, response.Username)
+		assert.Nil(t, response.User)
+	})
+
+	t.Run("full=true also returns the user", func(t *testing.T) {
+		code, response := getMe("?full=true")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, response.User) {
+			assert.Equal(t, user.Email, response.User.Email)
+		}
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/auth/me", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
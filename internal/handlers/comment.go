@@ -23,18 +23,18 @@ func NewCommentHandler(commentService services.CommentService) *CommentHandler {
 
 // CreateCommentRequest represents a comment creation request
 type CreateCommentRequest struct {
-	Content string `json:"content" binding:"required,min=1,max=5000" example:"This is a comment on the task"`
+	Content string `json:"content" binding:"required" example:"This is a comment on the task"` // At most COMMENT_MAX_LENGTH characters (default: 5000), checked by the service
 	TaskID  uint   `json:"task_id" binding:"required" example:"1"`
 }
 
 // UpdateCommentRequest represents a comment update request
 type UpdateCommentRequest struct {
-	Content *string `json:"content" binding:"omitempty,min=1,max=5000" example:"Updated comment text"`
+	Content *string `json:"content" example:"Updated comment text"` // At most COMMENT_MAX_LENGTH characters (default: 5000), checked by the service
 }
 
 // CreateComment creates a new comment on a task
 // @Summary      Create a comment on a task
-// @Description  Creates a new comment on a task. User must own the task or have assigned it.
+// @Description  Creates a new comment on a task. Any user with access to the task (owner, assigner or shared user) can comment. When COMMENT_LOCK_CLOSED_TASKS is enabled, tasks that are completed and archived reject new comments with 403.
 // @Tags         comments
 // @Accept       json
 // @Produce      json
@@ -42,6 +42,7 @@ type UpdateCommentRequest struct {
 // @Param        request  body      CreateCommentRequest  true  "Comment creation data"
 // @Success      201      {object}  models.Comment
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      403      {object}  ErrorResponse
 // @Failure      404      {object}  ErrorResponse
@@ -72,12 +73,13 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 
 // GetComments retrieves all comments for a task
 // @Summary      Get comments for a task
-// @Description  Retrieves all comments for a specific task. User must own the task or have assigned it.
+// @Description  Retrieves all comments for a specific task, pinned comments first, then oldest first. Any user with access to the task (owner, assigner or shared user) can list them. With include_deleted=true, deleted comments stay in the thread as placeholders with content "[deleted]" and deleted=true.
 // @Tags         comments
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id       path      int  true  "Task ID"
+// @Param        id               path   int   true   "Task ID"
+// @Param        include_deleted  query  bool  false  "Include deleted comments as \"[deleted]\" placeholders (default: false)"
 // @Success      200      {array}   models.Comment
 // @Failure      400      {object}  ErrorResponse
 // @Failure      401      {object}  ErrorResponse
@@ -93,7 +95,9 @@ func (h *CommentHandler) GetComments(c *gin.Context) {
 		return
 	}
 
-	comments, err := h.commentService.GetByTaskID(userID, uint(taskID))
+	includeDeleted := c.Query("include_deleted") == "true"
+
+	comments, err := h.commentService.GetByTaskID(userID, uint(taskID), includeDeleted)
 	if err != nil {
 		handleError(c, err)
 		return
@@ -144,6 +148,7 @@ func (h *CommentHandler) GetComment(c *gin.Context) {
 // @Param        request  body      UpdateCommentRequest true  "Comment update data"
 // @Success      200      {object}  models.Comment
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      403      {object}  ErrorResponse
 // @Failure      404      {object}  ErrorResponse
@@ -207,3 +212,58 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 	handleSuccess(c, http.StatusOK, "Comment deleted successfully", nil)
 }
 
+// PinComment pins a comment
+// @Summary      Pin a comment
+// @Description  Pins a comment so it is listed first in the task's comments. Only the owner of the task can pin, and a task can have a limited number of pinned comments (COMMENT_MAX_PINNED, default 3).
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Comment ID"
+// @Success      200  {object}  models.Comment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /comments/{id}/pin [put]
+func (h *CommentHandler) PinComment(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+// UnpinComment unpins a comment
+// @Summary      Unpin a comment
+// @Description  Unpins a comment. Only the owner of the task can unpin.
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Comment ID"
+// @Success      200  {object}  models.Comment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /comments/{id}/unpin [put]
+func (h *CommentHandler) UnpinComment(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+// setPinned pins or unpins the comment identified by the id path parameter
+func (h *CommentHandler) setPinned(c *gin.Context, pinned bool) {
+	userID := c.GetUint("user_id")
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid comment ID"))
+		return
+	}
+
+	comment, err := h.commentService.SetPinned(userID, uint(commentID), pinned)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
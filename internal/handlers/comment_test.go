@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
+	"todo-go-backend/internal/repositories"
+	"todo-go-backend/internal/services"
+	"todo-go-backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentsOnSharedTask(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	owner, _ := createTestUser(t)
+
+	collaborator := models.User{Username: "collaborator", Email: "collaborator@example.com", Password: "hashed"}
+	database.DB.Create(&collaborator)
+	collaboratorToken, _ := utils.GenerateToken(collaborator.ID, collaborator.Username, "test-secret")
+
+	outsider := models.User{Username: "outsider", Email: "outsider@example.com", Password: "hashed"}
+	database.DB.Create(&outsider)
+	outsiderToken, _ := utils.GenerateToken(outsider.ID, outsider.Username, "test-secret")
+
+	task := models.Task{Title: "Shared Task", Type: models.TaskTypeCasa, UserID: owner.ID}
+	database.DB.Create(&task)
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: collaborator.ID})
+
+	postComment := func(token string) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(CreateCommentRequest{Content: "Looks good", TaskID: task.ID})
+		req, _ := http.NewRequest("POST", "/api/v1/comments", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Shared user can comment and list comments", func(t *testing.T) {
+		w := postComment(collaboratorToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/tasks/%d/comments", task.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+collaboratorToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var comments []models.Comment
+		json.Unmarshal(w.Body.Bytes(), &comments)
+		assert.Len(t, comments, 1)
+	})
+
+	t.Run("User without access cannot comment", func(t *testing.T) {
+		w := postComment(outsiderToken)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestGetCommentsIncludeDeleted(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	task := models.Task{Title: "Task", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+	kept := models.Comment{Content: "First", TaskID: task.ID, UserID: user.ID}
+	database.DB.Create(&kept)
+	deleted := models.Comment{Content: "Second", TaskID: task.ID, UserID: user.ID}
+	database.DB.Create(&deleted)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/comments/%d", deleted.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	getComments := func(query string) []models.Comment {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/tasks/%d/comments%s", task.ID, query), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var comments []models.Comment
+		json.Unmarshal(w.Body.Bytes(), &comments)
+		return comments
+	}
+
+	t.Run("Deleted comments are omitted by default", func(t *testing.T) {
+		comments := getComments("")
+		assert.Len(t, comments, 1)
+		assert.Equal(t, "First", comments[0].Content)
+	})
+
+	t.Run("Deleted comments are listed as placeholders", func(t *testing.T) {
+		comments := getComments("?include_deleted=true")
+		assert.Len(t, comments, 2)
+		assert.False(t, comments[0].Deleted)
+		assert.Equal(t, "First", comments[0].Content)
+		assert.True(t, comments[1].Deleted)
+		assert.Equal(t, "[deleted]", comments[1].Content)
+	})
+}
+
+func TestPinComments(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	owner, ownerToken := createTestUser(t)
+
+	collaborator := models.User{Username: "collaborator", Email: "collaborator@example.com", Password: "hashed"}
+	database.DB.Create(&collaborator)
+	collaboratorToken, _ := utils.GenerateToken(collaborator.ID, collaborator.Username, "test-secret")
+
+	task := models.Task{Title: "Long-running task", Type: models.TaskTypeTrabalho, UserID: owner.ID}
+	database.DB.Create(&task)
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: collaborator.ID})
+
+	comments := make([]models.Comment, 4)
+	for i := range comments {
+		comments[i] = models.Comment{Content: fmt.Sprintf("Comment %d", i+1), TaskID: task.ID, UserID: collaborator.ID}
+		database.DB.Create(&comments[i])
+	}
+
+	pin := func(action string, commentID uint, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/comments/%d/%s", commentID, action), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Only the task owner can pin", func(t *testing.T) {
+		w := pin("pin", comments[0].ID, collaboratorToken)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Pinned comments are listed first", func(t *testing.T) {
+		w := pin("pin", comments[2].ID, ownerToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var pinned models.Comment
+		json.Unmarshal(w.Body.Bytes(), &pinned)
+		assert.True(t, pinned.Pinned)
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/tasks/%d/comments", task.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+ownerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var listed []models.Comment
+		json.Unmarshal(w.Body.Bytes(), &listed)
+		if assert.Len(t, listed, 4) {
+			assert.Equal(t, "Comment 3", listed[0].Content)
+			assert.Equal(t, "Comment 1", listed[1].Content)
+		}
+	})
+
+	t.Run("Pins per task are limited", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, pin("pin", comments[0].ID, ownerToken).Code)
+		assert.Equal(t, http.StatusBadRequest, pin("pin", comments[1].ID, ownerToken).Code)
+
+		// Pinning an already pinned comment doesn't count against the limit
+		assert.Equal(t, http.StatusOK, pin("pin", comments[0].ID, ownerToken).Code)
+
+		assert.Equal(t, http.StatusOK, pin("unpin", comments[2].ID, ownerToken).Code)
+		assert.Equal(t, http.StatusOK, pin("pin", comments[1].ID, ownerToken).Code)
+	})
+}
+
+func TestCommentsOnClosedTask(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret") // Locks comments on completed and archived tasks
+	user, token := createTestUser(t)
+
+	postComment := func(taskID uint) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(CreateCommentRequest{Content: "One more thing", TaskID: taskID})
+		req, _ := http.NewRequest("POST", "/api/v1/comments", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Completed and archived task rejects comments", func(t *testing.T) {
+		task := models.Task{Title: "Closed", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true, Archived: true}
+		database.DB.Create(&task)
+
+		w := postComment(task.ID)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Comments are closed")
+	})
+
+	t.Run("Completed task that is not archived still accepts comments", func(t *testing.T) {
+		task := models.Task{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true}
+		database.DB.Create(&task)
+
+		w := postComment(task.ID)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestCreateCommentNotifiesCollaborators(t *testing.T) {
+	setupTestDB()
+	owner, _ := createTestUser(t)
+	assigner := models.User{Username: "assigner", Email: "assigner@example.com", Password: "hashed"}
+	database.DB.Create(&assigner)
+	collaborator := models.User{Username: "collaborator", Email: "collaborator@example.com", Password: "hashed"}
+	database.DB.Create(&collaborator)
+
+	task := models.Task{Title: "Shared Task", Type: models.TaskTypeCasa, UserID: owner.ID, AssignedBy: &assigner.ID}
+	database.DB.Create(&task)
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: assigner.ID})
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: collaborator.ID})
+
+	recipientIDs := func(notification commentNotification) []uint {
+		var ids []uint
+		for _, user := range notification.recipients {
+			ids = append(ids, user.ID)
+		}
+		return ids
+	}
+
+	t.Run("Only the task owner is notified by default", func(t *testing.T) {
+		notifier := &recordingNotifier{}
+		commentService := services.NewCommentService(repositories.NewCommentRepository(), repositories.NewTaskRepository(), realtime.NewHub(), notifier, services.CommentServiceOptions{MaxPinned: 2, LockClosed: false, NotifyAll: false, HideInaccessible: false, MaxLength: 5000})
+
+		comment, err := commentService.Create(collaborator.ID, &services.CreateCommentRequest{Content: "Looks good", TaskID: task.ID})
+		assert.NoError(t, err)
+		if assert.Len(t, notifier.comments, 1) {
+			assert.Equal(t, comment.ID, notifier.comments[0].comment.ID)
+			assert.Equal(t, []uint{owner.ID}, recipientIDs(notifier.comments[0]))
+		}
+
+		// The owner commenting on their own task notifies nobody
+		_, err = commentService.Create(owner.ID, &services.CreateCommentRequest{Content: "Thanks", TaskID: task.ID})
+		assert.NoError(t, err)
+		assert.Len(t, notifier.comments, 1)
+
+		// Editing a comment doesn't notify again
+		content := "Looks great"
+		_, err = commentService.Update(collaborator.ID, comment.ID, &services.UpdateCommentRequest{Content: &content})
+		assert.NoError(t, err)
+		assert.Len(t, notifier.comments, 1)
+	})
+
+	t.Run("Assigner and shared users are notified when enabled, except the author", func(t *testing.T) {
+		notifier := &recordingNotifier{}
+		commentService := services.NewCommentService(repositories.NewCommentRepository(), repositories.NewTaskRepository(), realtime.NewHub(), notifier, services.CommentServiceOptions{MaxPinned: 2, LockClosed: false, NotifyAll: true, HideInaccessible: false, MaxLength: 5000})
+
+		_, err := commentService.Create(collaborator.ID, &services.CreateCommentRequest{Content: "Looks good", TaskID: task.ID})
+		assert.NoError(t, err)
+		if assert.Len(t, notifier.comments, 1) {
+			assert.ElementsMatch(t, []uint{owner.ID, assigner.ID}, recipientIDs(notifier.comments[0]))
+		}
+	})
+}
+
+func TestCommentWhitespaceContent(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	task := models.Task{Title: "Task", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Whitespace-only comments are rejected", func(t *testing.T) {
+		w := send("POST", "/api/v1/comments", CreateCommentRequest{Content: "   ", TaskID: task.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var count int64
+		database.DB.Model(&models.Comment{}).Where("task_id = ?", task.ID).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Content is stored trimmed", func(t *testing.T) {
+		w := send("POST", "/api/v1/comments", CreateCommentRequest{Content: "  Looks good \n", TaskID: task.ID})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var comment models.Comment
+		json.Unmarshal(w.Body.Bytes(), &comment)
+		assert.Equal(t, "Looks good", comment.Content)
+
+		blank := "\n\t "
+		w = send("PUT", fmt.Sprintf("/api/v1/comments/%d", comment.ID), UpdateCommentRequest{Content: &blank})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var stored models.Comment
+		database.DB.First(&stored, comment.ID)
+		assert.Equal(t, "Looks good", stored.Content)
+	})
+}
+
+func TestCommentMaxLength(t *testing.T) {
+	setupTestDB()
+	user, _ := createTestUser(t)
+	task := models.Task{Title: "Task", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+
+	commentService := services.NewCommentService(repositories.NewCommentRepository(), repositories.NewTaskRepository(), realtime.NewHub(), nil, services.CommentServiceOptions{MaxPinned: 2, LockClosed: false, NotifyAll: false, HideInaccessible: false, MaxLength: 10})
+
+	_, err := commentService.Create(user.ID, &services.CreateCommentRequest{TaskID: task.ID, Content: "çãé ôõ àü!"})
+	assert.NoError(t, err, "the limit counts characters, not bytes")
+
+	comment, err := commentService.Create(user.ID, &services.CreateCommentRequest{TaskID: task.ID, Content: "  Ten chars  "})
+	assert.NoError(t, err, "surrounding whitespace doesn't count")
+
+	_, err = commentService.Create(user.ID, &services.CreateCommentRequest{TaskID: task.ID, Content: "Eleven char"})
+	assert.EqualError(t, err, "Comment content must be between 1 and 10 characters")
+
+	longer := "Way past ten characters"
+	_, err = commentService.Update(user.ID, comment.ID, &services.UpdateCommentRequest{Content: &longer})
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"todo-go-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// sseKeepAliveInterval is how often a comment line is sent so proxies don't close idle event streams
+const sseKeepAliveInterval = 15 * time.Second
+
+// RealtimeHandler streams task and comment events to connected clients
+type RealtimeHandler struct {
+	hub *realtime.Hub
+}
+
+// NewRealtimeHandler creates a new instance of RealtimeHandler
+func NewRealtimeHandler(hub *realtime.Hub) *RealtimeHandler {
+	return &RealtimeHandler{hub: hub}
+}
+
+// WebSocket pushes live updates of the tasks the user can access
+// @Summary      Live updates over WebSocket
+// @Description  Upgrades to a WebSocket that pushes a JSON event (type, task_id, data) when a task the user can access (owned, assigned by them or shared with them) is created, updated, completed or commented on. Event types: task.created, task.updated, task.completed, comment.created. Browsers can't set headers on WebSocket connections, so the JWT is passed in the token query parameter. Messages sent by the client are ignored.
+// @Tags         realtime
+// @Param        token  query     string  true  "JWT token"
+// @Success      101    {object}  realtime.Event
+// @Failure      401    {object}  ErrorResponse
+// @Router       /ws [get]
+func (h *RealtimeHandler) WebSocket(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	server := websocket.Server{
+		// The token travels in the URL rather than in a cookie, so cross-origin connections are safe to accept
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			h.serveWebSocket(conn, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serveWebSocket forwards the user's events to the connection until either side closes it
+func (h *RealtimeHandler) serveWebSocket(conn *websocket.Conn, userID uint) {
+	events, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	// Reading is only used to notice when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var message string
+		for websocket.Message.Receive(conn, &message) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			if err := websocket.JSON.Send(conn, event); err != nil {
+				log.Printf("Failed to send %s event to user %d: %v", event.Type, userID, err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Events streams live updates of the tasks the user can access as Server-Sent Events
+// @Summary      Live updates over Server-Sent Events
+// @Description  Read-only alternative to the WebSocket endpoint. Keeps the connection open and emits the same events (task.created, task.updated, task.completed, comment.created) as SSE messages whose event name is the event type and whose data is the JSON event. A ": keep-alive" comment is sent every 15 seconds. The JWT is passed in the token query parameter since EventSource can't set headers.
+// @Tags         realtime
+// @Produce      text/event-stream
+// @Param        token  query     string  true  "JWT token"
+// @Success      200    {object}  realtime.Event
+// @Failure      401    {object}  ErrorResponse
+// @Router       /events [get]
+func (h *RealtimeHandler) Events(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	events, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable response buffering in nginx
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
+	"todo-go-backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	server := httptest.NewServer(router)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+
+	owner, ownerToken := createTestUser(t)
+	collaborator := models.User{Username: "collaborator", Email: "collaborator@example.com", Password: "hashed"}
+	database.DB.Create(&collaborator)
+	collaboratorToken, _ := utils.GenerateToken(collaborator.ID, collaborator.Username, "test-secret")
+
+	task := models.Task{Title: "Shared Task", Type: models.TaskTypeCasa, UserID: owner.ID}
+	database.DB.Create(&task)
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: collaborator.ID})
+
+	t.Run("Connection without a valid token is rejected", func(t *testing.T) {
+		_, err := websocket.Dial(wsURL+"?token=invalid", "", server.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("Shared user receives completion and comment events", func(t *testing.T) {
+		conn, err := websocket.Dial(wsURL+"?token="+collaboratorToken, "", server.URL)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		// Give the server time to subscribe the connection before publishing
+		time.Sleep(100 * time.Millisecond)
+
+		completed := true
+		jsonValue, _ := json.Marshal(UpdateTaskRequest{Completed: &completed})
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/tasks/%d", task.ID), bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+ownerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		jsonValue, _ = json.Marshal(CreateCommentRequest{Content: "Done!", TaskID: task.ID})
+		req, _ = http.NewRequest("POST", "/api/v1/comments", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+ownerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var event realtime.Event
+		assert.NoError(t, websocket.JSON.Receive(conn, &event))
+		assert.Equal(t, realtime.EventTaskCompleted, event.Type)
+		assert.Equal(t, task.ID, event.TaskID)
+
+		assert.NoError(t, websocket.JSON.Receive(conn, &event))
+		assert.Equal(t, realtime.EventCommentCreated, event.Type)
+		assert.Equal(t, task.ID, event.TaskID)
+	})
+}
+
+func TestEventStream(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	user, token := createTestUser(t)
+	task := models.Task{Title: "Task", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+
+	t.Run("Stream without a token is rejected", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/v1/events")
+		if !assert.NoError(t, err) {
+			return
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Task updates are streamed as events", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/v1/events?token=" + token)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		// The headers are flushed after subscribing, so the update below can't be missed
+		title := "Renamed"
+		jsonValue, _ := json.Marshal(UpdateTaskRequest{Title: &title})
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/tasks/%d", task.ID), bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		reader := bufio.NewReader(resp.Body)
+		eventLine, _ := reader.ReadString('\n')
+		dataLine, _ := reader.ReadString('\n')
+		assert.Equal(t, "event:task.updated\n", eventLine)
+
+		var event realtime.Event
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data:")), &event))
+		assert.Equal(t, task.ID, event.TaskID)
+	})
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	stdErrors "errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"todo-go-backend/internal/errors"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 // ErrorResponse represents a standardized error response
@@ -36,6 +41,14 @@ func handleError(c *gin.Context, err error) {
 	})
 }
 
+// errorStatus returns the HTTP status handleError would respond with, for responses without a body
+func errorStatus(err error) int {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
 // handleSuccess returns a standardized success response
 func handleSuccess(c *gin.Context, statusCode int, message string, data interface{}) {
 	response := SuccessResponse{
@@ -47,8 +60,111 @@ func handleSuccess(c *gin.Context, statusCode int, message string, data interfac
 	c.JSON(statusCode, response)
 }
 
-// handleValidationError handles Gin validation errors
+// parsePagination reads the page and limit query parameters. Absent parameters are returned as 0
+// so the caller applies its defaults; present ones must be positive integers or a 400 is returned.
+// allowAll also accepts an explicit limit=0, for lists that can return every item at once.
+func parsePagination(c *gin.Context, allowAll bool) (int, int, error) {
+	page, limit := 0, 0
+	if pageStr := c.Query("page"); pageStr != "" {
+		parsed, err := strconv.Atoi(pageStr)
+		if err != nil || parsed <= 0 {
+			return 0, 0, errors.NewInvalidInputError("Invalid page parameter: must be a positive integer")
+		}
+		page = parsed
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		// Only a plain "0" asks for every item, so "00" or "-0" can't bypass the default limit by accident
+		if err != nil || parsed < 0 || (parsed == 0 && (!allowAll || limitStr != "0")) {
+			return 0, 0, errors.NewInvalidInputError("Invalid limit parameter: must be a positive integer")
+		}
+		limit = parsed
+	}
+	return page, limit, nil
+}
+
+// parseBoolQuery reads an optional boolean query parameter (true/false, 1/0 or t/f); nil means it
+// wasn't given. Any other value is rejected so a typo doesn't silently fall back to the default.
+func parseBoolQuery(c *gin.Context, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.NewInvalidInputError("Invalid " + name + " parameter: must be true or false")
+	}
+	return &parsed, nil
+}
+
+// setPaginationHeaders adds GitHub-style pagination headers mirroring the body envelope:
+// X-Total-Count, X-Page, X-Per-Page and an RFC 5988 Link header with first, prev, next and last pages
+func setPaginationHeaders(c *gin.Context, page, limit int, total int64, totalPages int) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Page", strconv.Itoa(page))
+	c.Header("X-Per-Page", strconv.Itoa(limit))
+
+	links := []string{pageLink(c, 1, "first")}
+	if page > 1 {
+		prev := page - 1
+		if prev > totalPages {
+			prev = totalPages
+		}
+		links = append(links, pageLink(c, prev, "prev"))
+	}
+	if page < totalPages {
+		links = append(links, pageLink(c, page+1, "next"))
+	}
+	links = append(links, pageLink(c, totalPages, "last"))
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// pageLink returns a Link header entry pointing to the current URL with the given page
+func pageLink(c *gin.Context, page int, rel string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return fmt.Sprintf(`<%s://%s%s?%s>; rel="%s"`, scheme, c.Request.Host, c.Request.URL.Path, query.Encode(), rel)
+}
+
+// ValidationErrorResponse represents a validation error with a message per failing field
+type ValidationErrorResponse struct {
+	Error   string            `json:"error" example:"Validation failed"`
+	Message string            `json:"message,omitempty" example:"One or more fields are invalid"`
+	Fields  map[string]string `json:"fields"`
+}
+
+// handleValidationError handles Gin validation errors.
+// Field validation failures return 422 with a message per field, bodies over the size limit
+// return 413, and other binding errors (malformed JSON, wrong types) keep returning 400.
 func handleValidationError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if stdErrors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error:   "Request body too large",
+			Message: "The request body exceeds the maximum allowed size",
+		})
+		return
+	}
+
+	var validationErrors validator.ValidationErrors
+	if stdErrors.As(err, &validationErrors) {
+		fields := make(map[string]string, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			fields[fieldErr.Field()] = validationMessage(fieldErr)
+		}
+		c.JSON(http.StatusUnprocessableEntity, ValidationErrorResponse{
+			Error:   "Validation failed",
+			Message: "One or more fields are invalid",
+			Fields:  fields,
+		})
+		return
+	}
+
 	handleError(c, errors.NewInvalidInputError(err.Error()))
 }
 
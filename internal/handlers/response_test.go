@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-go-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleValidationErrorBodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.BodySizeLimitMiddleware(32))
+	router.POST("/tasks", func(c *gin.Context) {
+		var req CreateTaskRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handleValidationError(c, err)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		// Unknown length, so the limit is only hit while the body is bound
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("A body cut off while binding returns 413", func(t *testing.T) {
+		w := post(`{"title":"` + strings.Repeat("x", 64) + `","type":"casa"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "Request body too large")
+	})
+
+	t.Run("Malformed JSON within the limit still returns 400", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post(`{"title":`).Code)
+	})
+}
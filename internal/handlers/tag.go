@@ -24,18 +24,18 @@ func NewTagHandler(tagService services.TagService) *TagHandler {
 // CreateTagRequest represents a tag creation request
 type CreateTagRequest struct {
 	Name  string `json:"name" binding:"required,min=1,max=50" example:"Important"`
-	Color string `json:"color" example:"#FF5733"` // Optional: hex color code
+	Color string `json:"color" example:"#FF5733"` // Optional: hex color code; omitted or empty gets the default color
 }
 
 // UpdateTagRequest represents a tag update request
 type UpdateTagRequest struct {
 	Name  *string `json:"name" example:"Updated Tag"`
-	Color *string `json:"color" example:"#33FF57"`
+	Color *string `json:"color" example:"#33FF57"` // Optional: nil = no change, "" = reset to the default color
 }
 
 // CreateTag creates a new tag
 // @Summary      Create a new tag
-// @Description  Creates a new custom tag for the authenticated user
+// @Description  Creates a new custom tag for the authenticated user. When TAG_COLOR_PALETTE is set, the color must be one of the palette colors (see GET /tags/palette). Without a color the tag gets the default color (the first palette color, or #808080) and default_color is true in the response.
 // @Tags         tags
 // @Accept       json
 // @Produce      json
@@ -43,6 +43,7 @@ type UpdateTagRequest struct {
 // @Param        request  body      CreateTagRequest  true  "Tag creation data"
 // @Success      201      {object}  models.Tag
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /tags [post]
@@ -69,6 +70,67 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 	c.JSON(http.StatusCreated, tag)
 }
 
+// EnsureTag returns the tag with the given name, creating it if needed
+// @Summary      Get or create a tag by name
+// @Description  Returns the authenticated user's tag whose name matches (case-insensitive) with 200, or creates it with 201. The color is only used when the tag is created. Safe to call repeatedly and concurrently with the same name.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreateTagRequest  true  "Tag name and color for creation"
+// @Success      200      {object}  models.Tag
+// @Success      201      {object}  models.Tag
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /tags/ensure [post]
+func (h *TagHandler) EnsureTag(c *gin.Context) {
+	var req CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	tag, created, err := h.tagService.EnsureByName(userID, req.Name, req.Color)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, tag)
+}
+
+// TagPaletteResponse lists the colors allowed for tags
+type TagPaletteResponse struct {
+	Colors     []string `json:"colors" example:"#EF4444,#3B82F6"`
+	Restricted bool     `json:"restricted" example:"true"` // False when any hex color is allowed (colors is empty)
+}
+
+// GetPalette returns the allowed tag colors
+// @Summary      Get the tag color palette
+// @Description  Returns the hex colors allowed for tags (TAG_COLOR_PALETTE), so color pickers can be built from it. When no palette is configured, colors is empty, restricted is false and any hex color is accepted.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  TagPaletteResponse
+// @Failure      401  {object}  ErrorResponse
+// @Router       /tags/palette [get]
+func (h *TagHandler) GetPalette(c *gin.Context) {
+	colors := h.tagService.Palette()
+	c.JSON(http.StatusOK, TagPaletteResponse{
+		Colors:     append([]string{}, colors...),
+		Restricted: len(colors) > 0,
+	})
+}
+
 // GetTags lists user tags
 // @Summary      List user tags
 // @Description  Retrieves all tags for the authenticated user
@@ -124,7 +186,7 @@ func (h *TagHandler) GetTag(c *gin.Context) {
 
 // UpdateTag updates a tag
 // @Summary      Update a tag
-// @Description  Updates an existing tag
+// @Description  Updates an existing tag. When TAG_COLOR_PALETTE is set, the color must be one of the palette colors. An empty color ("") resets the tag to the default color, the same one used on creation, and sets default_color to true.
 // @Tags         tags
 // @Accept       json
 // @Produce      json
@@ -133,6 +195,7 @@ func (h *TagHandler) GetTag(c *gin.Context) {
 // @Param        request  body      UpdateTagRequest true  "Tag update data"
 // @Success      200      {object}  models.Tag
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      404      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"todo-go-backend/internal/auth"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/middleware"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/repositories"
+	"todo-go-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureTag(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	ensureTag := func(name, color string) (*httptest.ResponseRecorder, models.Tag) {
+		jsonValue, _ := json.Marshal(CreateTagRequest{Name: name, Color: color})
+		req, _ := http.NewRequest("POST", "/api/v1/tags/ensure", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var tag models.Tag
+		json.Unmarshal(w.Body.Bytes(), &tag)
+		return w, tag
+	}
+
+	t.Run("Creates the tag when it doesn't exist", func(t *testing.T) {
+		w, tag := ensureTag("Urgente", "#FF0000")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "Urgente", tag.Name)
+		assert.Equal(t, "#FF0000", tag.Color)
+	})
+
+	t.Run("Returns the existing tag ignoring case", func(t *testing.T) {
+		w, tag := ensureTag("urgente", "#00FF00")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Urgente", tag.Name)
+		assert.Equal(t, "#FF0000", tag.Color)
+
+		var count int64
+		database.DB.Model(&models.Tag{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Rejects an invalid color for a new tag", func(t *testing.T) {
+		w, _ := ensureTag("Casa", "red")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, tag := ensureTag("URGENTE", "red")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Urgente", tag.Name)
+	})
+
+	t.Run("Concurrent calls create the tag once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		codes := make([]int, 5)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				w, _ := ensureTag("Mercado", "")
+				codes[i] = w.Code
+			}(i)
+		}
+		wg.Wait()
+
+		created := 0
+		for _, code := range codes {
+			if code == http.StatusCreated {
+				created++
+			} else {
+				assert.Equal(t, http.StatusOK, code)
+			}
+		}
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("A deleted tag's name can be used again", func(t *testing.T) {
+		_, tag := ensureTag("Temporaria", "")
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/tags/%d", tag.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, recreated := ensureTag("temporaria", "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotEqual(t, tag.ID, recreated.ID)
+	})
+}
+
+func TestTagNameNormalization(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	_, token := createTestUser(t)
+
+	send := func(method, path string, body interface{}) (*httptest.ResponseRecorder, models.Tag) {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var tag models.Tag
+		json.Unmarshal(w.Body.Bytes(), &tag)
+		return w, tag
+	}
+
+	t.Run("Names are trimmed and inner whitespace collapsed", func(t *testing.T) {
+		w, tag := send("POST", "/api/v1/tags", CreateTagRequest{Name: "  Work "})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "Work", tag.Name)
+
+		w, tag = send("POST", "/api/v1/tags", CreateTagRequest{Name: "Side \t  project"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "Side project", tag.Name)
+	})
+
+	t.Run("Duplicates differing in case or whitespace are rejected", func(t *testing.T) {
+		w, _ := send("POST", "/api/v1/tags", CreateTagRequest{Name: "work"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = send("POST", "/api/v1/tags", CreateTagRequest{Name: "  side PROJECT"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Renaming to an existing name is rejected", func(t *testing.T) {
+		_, other := send("POST", "/api/v1/tags", CreateTagRequest{Name: "Home"})
+
+		name := " WORK  "
+		w, _ := send("PUT", fmt.Sprintf("/api/v1/tags/%d", other.ID), UpdateTagRequest{Name: &name})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		name = "  home "
+		w, tag := send("PUT", fmt.Sprintf("/api/v1/tags/%d", other.ID), UpdateTagRequest{Name: &name})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "home", tag.Name)
+	})
+
+	t.Run("Whitespace-only names are rejected", func(t *testing.T) {
+		w, _ := send("POST", "/api/v1/tags", CreateTagRequest{Name: "   "})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestTagColorPalette(t *testing.T) {
+	setupTestDB()
+	_, token := createTestUser(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	tagHandler := NewTagHandler(services.NewTagService(repositories.NewTagRepository(), []string{"#ef4444", "#3B82F6", "invalid"}))
+	protected := router.Group("/api/v1")
+	protected.Use(middleware.AuthMiddleware(auth.NewKeySet("default", "test-secret", nil), true))
+	protected.POST("/tags", tagHandler.CreateTag)
+	protected.GET("/tags/palette", tagHandler.GetPalette)
+
+	createTag := func(name, color string) (*httptest.ResponseRecorder, models.Tag) {
+		jsonValue, _ := json.Marshal(CreateTagRequest{Name: name, Color: color})
+		req, _ := http.NewRequest("POST", "/api/v1/tags", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var tag models.Tag
+		json.Unmarshal(w.Body.Bytes(), &tag)
+		return w, tag
+	}
+
+	t.Run("Palette lists the valid configured colors", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tags/palette", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response TagPaletteResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.True(t, response.Restricted)
+		assert.Equal(t, []string{"#EF4444", "#3B82F6"}, response.Colors)
+	})
+
+	t.Run("Colors outside the palette are rejected", func(t *testing.T) {
+		w, _ := createTag("Casa", "#808080")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Palette colors are accepted in any case", func(t *testing.T) {
+		w, _ := createTag("Trabalho", "#3b82f6")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Tags without color get the first palette color", func(t *testing.T) {
+		w, tag := createTag("Lazer", "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "#EF4444", tag.Color)
+	})
+}
+
+func TestTagDefaultColor(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	_, token := createTestUser(t)
+
+	sendTag := func(method, url string, body interface{}) models.Tag {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Less(t, w.Code, 300)
+
+		var tag models.Tag
+		json.Unmarshal(w.Body.Bytes(), &tag)
+		return tag
+	}
+
+	tag := sendTag("POST", "/api/v1/tags", CreateTagRequest{Name: "Casa"})
+	assert.Equal(t, services.DefaultTagColor, tag.Color)
+	assert.True(t, tag.DefaultColor)
+
+	url := fmt.Sprintf("/api/v1/tags/%d", tag.ID)
+	color := "#FF5733"
+	tag = sendTag("PUT", url, UpdateTagRequest{Color: &color})
+	assert.Equal(t, "#FF5733", tag.Color)
+	assert.False(t, tag.DefaultColor)
+
+	cleared := ""
+	tag = sendTag("PUT", url, UpdateTagRequest{Color: &cleared})
+	assert.Equal(t, services.DefaultTagColor, tag.Color)
+	assert.True(t, tag.DefaultColor)
+}
+
+func TestTagNotFound(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	_, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+	othersTag := models.Tag{Name: "Private", Color: services.DefaultTagColor, UserID: other.ID}
+	database.DB.Create(&othersTag)
+
+	name := "Renamed"
+	body, _ := json.Marshal(UpdateTagRequest{Name: &name})
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		t.Run(method+" another user's tag", func(t *testing.T) {
+			req, _ := http.NewRequest(method, fmt.Sprintf("/api/v1/tags/%d", othersTag.ID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			var response ErrorResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			assert.Equal(t, "Tag not found", response.Message)
+		})
+	}
+
+	var reloaded models.Tag
+	assert.NoError(t, database.DB.First(&reloaded, othersTag.ID).Error)
+	assert.Equal(t, "Private", reloaded.Name)
+}
+
+func TestGetTagTasks(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	work := models.Tag{Name: "Work", Color: "#3B82F6", UserID: user.ID}
+	database.DB.Create(&work)
+	home := models.Tag{Name: "Home", Color: "#EF4444", UserID: user.ID}
+	database.DB.Create(&home)
+	othersTag := models.Tag{Name: "Work", Color: "#3B82F6", UserID: other.ID}
+	database.DB.Create(&othersTag)
+
+	database.DB.Create(&models.Task{Title: "Report", Type: models.TaskTypeTrabalho, UserID: user.ID, Tags: []models.Tag{work}})
+	database.DB.Create(&models.Task{Title: "Budget", Type: models.TaskTypeTrabalho, UserID: user.ID, Tags: []models.Tag{work, home}})
+	database.DB.Create(&models.Task{Title: "Laundry", Type: models.TaskTypeCasa, UserID: user.ID, Tags: []models.Tag{home}})
+
+	getTagTasks := func(tagID uint, query string) (*httptest.ResponseRecorder, services.PaginatedTasksResponse) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/tags/%d/tasks%s", tagID, query), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("Lists the tasks with the tag, sorted and paginated", func(t *testing.T) {
+		w, response := getTagTasks(work.ID, "?sort_by=title&order=asc&limit=1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(2), response.Total)
+		assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+		if assert.Len(t, response.Tasks, 1) {
+			assert.Equal(t, "Budget", response.Tasks[0].Title)
+		}
+	})
+
+	t.Run("Another user's tag is not found", func(t *testing.T) {
+		w, _ := getTagTasks(othersTag.ID, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid tag ID", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tags/abc/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestBulkTagAssignment(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	q1 := models.Tag{Name: "Q1", Color: "#3B82F6", UserID: user.ID}
+	database.DB.Create(&q1)
+	othersTag := models.Tag{Name: "Q1", Color: "#3B82F6", UserID: other.ID}
+	database.DB.Create(&othersTag)
+
+	untagged := models.Task{Title: "Untagged", Type: models.TaskTypeTrabalho, UserID: user.ID}
+	database.DB.Create(&untagged)
+	tagged := models.Task{Title: "Tagged", Type: models.TaskTypeTrabalho, UserID: user.ID, Tags: []models.Tag{q1}}
+	database.DB.Create(&tagged)
+	shared := models.Task{Title: "Shared", Type: models.TaskTypeTrabalho, UserID: other.ID, Tags: []models.Tag{othersTag}}
+	database.DB.Create(&shared)
+	assert.NoError(t, repositories.NewTaskRepository().AddSharedWith(context.Background(), shared.ID, user.ID))
+	assignedByMe := models.Task{Title: "Assigned by me", Type: models.TaskTypeTrabalho, UserID: other.ID, AssignedBy: &user.ID}
+	database.DB.Create(&assignedByMe)
+	private := models.Task{Title: "Private", Type: models.TaskTypeTrabalho, UserID: other.ID}
+	database.DB.Create(&private)
+
+	bulkTag := func(action string, tagID uint, taskIDs []uint) (*httptest.ResponseRecorder, services.BulkResult) {
+		jsonValue, _ := json.Marshal(TagTasksRequest{TaskIDs: taskIDs})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/tags/%d/%s", tagID, action), bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var result services.BulkResult
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return w, result
+	}
+	tagCount := func(taskID uint) int64 {
+		var count int64
+		database.DB.Model(&models.TaskTag{}).Where("task_id = ? AND tag_id = ?", taskID, q1.ID).Count(&count)
+		return count
+	}
+
+	sharedTagIDs := func() []uint {
+		var tagIDs []uint
+		database.DB.Model(&models.TaskTag{}).Where("task_id = ?", shared.ID).Pluck("tag_id", &tagIDs)
+		return tagIDs
+	}
+
+	t.Run("Tasks the user doesn't own are rejected like single-task tag edits", func(t *testing.T) {
+		for _, action := range []string{"assign", "unassign"} {
+			for _, taskID := range []uint{shared.ID, assignedByMe.ID} {
+				w, _ := bulkTag(action, q1.ID, []uint{untagged.ID, taskID})
+				assert.Equal(t, http.StatusForbidden, w.Code, action)
+				assert.Contains(t, w.Body.String(), "Only the task owner can edit")
+			}
+		}
+
+		// Nothing is changed, not even the owned task in the same request
+		assert.Equal(t, int64(0), tagCount(untagged.ID))
+		assert.Equal(t, []uint{othersTag.ID}, sharedTagIDs())
+		assert.Equal(t, int64(0), tagCount(assignedByMe.ID))
+	})
+
+	t.Run("Assigns the tag to the owned tasks and skips the others", func(t *testing.T) {
+		w, result := bulkTag("assign", q1.ID, []uint{untagged.ID, tagged.ID, private.ID, 9999, untagged.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(1), result.Affected)
+		assert.Equal(t, []services.BulkFailure{
+			{TaskID: tagged.ID, Message: "Task already has the tag"},
+			{TaskID: private.ID, Message: "Task not found"},
+			{TaskID: 9999, Message: "Task not found"},
+		}, result.Failed)
+
+		assert.Equal(t, int64(1), tagCount(untagged.ID))
+		assert.Equal(t, int64(1), tagCount(tagged.ID))
+		assert.Equal(t, int64(0), tagCount(private.ID))
+	})
+
+	t.Run("Tasks tagged concurrently keep a single link", func(t *testing.T) {
+		// The service skips tasks it saw tagged, but another request may tag them in between
+		assert.NoError(t, repositories.NewTaskRepository().AddTagToTasks(context.Background(), q1.ID, []uint{tagged.ID}, user.ID))
+		assert.Equal(t, int64(1), tagCount(tagged.ID))
+	})
+
+	t.Run("Unassigns the tag", func(t *testing.T) {
+		w, result := bulkTag("unassign", q1.ID, []uint{untagged.ID, tagged.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(2), result.Affected)
+		assert.Empty(t, result.Failed)
+		assert.Equal(t, int64(0), tagCount(untagged.ID))
+		assert.Equal(t, int64(0), tagCount(tagged.ID))
+
+		_, result = bulkTag("unassign", q1.ID, []uint{untagged.ID})
+		assert.Equal(t, int64(0), result.Affected)
+		assert.Equal(t, "Task doesn't have the tag", result.Failed[0].Message)
+	})
+
+	t.Run("Another user's tag is not found", func(t *testing.T) {
+		w, _ := bulkTag("assign", othersTag.ID, []uint{untagged.ID})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("At least one task ID is required", func(t *testing.T) {
+		w, _ := bulkTag("assign", q1.ID, []uint{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +14,8 @@ import (
 	"todo-go-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
 // TaskHandler manages task handlers
@@ -28,11 +34,11 @@ func NewTaskHandler(taskService services.TaskService) *TaskHandler {
 type CreateTaskRequest struct {
 	Title       string          `json:"title" binding:"required,min=1,max=200" example:"Clean the house"`
 	Description string          `json:"description" example:"Clean all rooms"`
-	Type        models.TaskType `json:"type" binding:"required,oneof=casa trabalho lazer saude" example:"casa"`
-	Priority    *string         `json:"priority" binding:"omitempty,oneof=baixa media alta urgente" example:"alta"` // Optional: task priority
-	DueDate     *string         `json:"due_date" example:"2024-12-31T23:59:59Z"`                                    // ISO 8601 format
-	UserID      *uint           `json:"user_id" example:"2"`                                                        // Optional: if provided, assign to another user
-	TagIDs      []uint          `json:"tag_ids"`                                                                    // Optional: IDs of tags to associate
+	Type        models.TaskType `json:"type" binding:"required,task_type" example:"casa"`
+	Priority    *string         `json:"priority" binding:"omitempty,priority" example:"alta"` // Optional: task priority
+	DueDate     *string         `json:"due_date" example:"2024-12-31T23:59:59Z"`              // ISO 8601 format
+	UserID      *uint           `json:"user_id" example:"2"`                                  // Optional: if provided, assign to another user
+	TagIDs      []uint          `json:"tag_ids"`                                              // Optional: IDs of the creator's tags to associate (at most TASK_MAX_TAGS)
 }
 
 // ShareTaskRequest represents a request to share a task with users
@@ -44,8 +50,8 @@ type ShareTaskRequest struct {
 type UpdateTaskRequest struct {
 	Title       *string          `json:"title" example:"Updated title"`
 	Description *string          `json:"description" example:"Updated description"`
-	Type        *models.TaskType `json:"type" binding:"omitempty,oneof=casa trabalho lazer saude" example:"trabalho"`
-	Priority    *string          `json:"priority" binding:"omitempty,oneof=baixa media alta urgente" example:"urgente"`
+	Type        *models.TaskType `json:"type" binding:"omitempty,task_type" example:"trabalho"`
+	Priority    *string          `json:"priority" binding:"omitempty,priority" example:"urgente"`
 	DueDate     *string          `json:"due_date" example:"2024-12-31T23:59:59Z"`
 	Completed   *bool            `json:"completed" example:"true"`
 	TagIDs      *[]uint          `json:"tag_ids"` // Optional: nil = no change, [] = remove all, [1,2] = set tags
@@ -53,7 +59,7 @@ type UpdateTaskRequest struct {
 
 // CreateTask creates a new task
 // @Summary      Create a new task
-// @Description  Creates a new task for the authenticated user or assigns it to another user
+// @Description  Creates a new task for the authenticated user or assigns it to another user. tag_ids always refer to the authenticated user's tags; when assigning to another user, the assignee gets tags with the same name and color (reusing their existing tags with that name).
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
@@ -61,6 +67,7 @@ type UpdateTaskRequest struct {
 // @Param        request  body      CreateTaskRequest  true  "Task creation data"
 // @Success      201      {object}  models.Task
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      404      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
@@ -68,19 +75,129 @@ type UpdateTaskRequest struct {
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	var req CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleError(c, errors.NewInvalidInputError(err.Error()))
+		handleValidationError(c, err)
 		return
 	}
 
 	userID := c.GetUint("user_id")
 
+	createReq, err := toServiceCreateTaskRequest(&req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	task, err := h.taskService.Create(c.Request.Context(), userID, createReq)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// BatchCreateTasksErrorResponse reports which items of a batch are invalid
+type BatchCreateTasksErrorResponse struct {
+	Error   string                    `json:"error" example:"Validation failed"`
+	Message string                    `json:"message" example:"No tasks were created because one or more items are invalid"`
+	Items   []services.BatchItemError `json:"items"`
+}
+
+// CreateTasksBatch creates several tasks at once
+// @Summary      Batch-create tasks
+// @Description  Creates up to 100 tasks in a single transaction. Each item is validated like POST /tasks; if any item is invalid nothing is created and the response lists the failing items by index.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      []CreateTaskRequest  true  "Tasks to create"
+// @Success      201      {array}   models.Task
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  BatchCreateTasksErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /tasks/batch [post]
+func (h *TaskHandler) CreateTasksBatch(c *gin.Context) {
+	// Items are validated one by one so errors can be reported per index
+	var reqs []CreateTaskRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&reqs); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+	if len(reqs) == 0 {
+		handleError(c, errors.NewInvalidInputError("At least one task is required"))
+		return
+	}
+	if len(reqs) > services.MaxBatchCreateTasks {
+		handleError(c, errors.NewInvalidInputError(fmt.Sprintf("A batch can contain at most %d tasks", services.MaxBatchCreateTasks)))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	createReqs := make([]*services.CreateTaskRequest, 0, len(reqs))
+	var itemErrors []services.BatchItemError
+	for i := range reqs {
+		if err := binding.Validator.ValidateStruct(&reqs[i]); err != nil {
+			itemErrors = append(itemErrors, services.BatchItemError{Index: i, Message: batchItemValidationMessage(err)})
+			continue
+		}
+		createReq, err := toServiceCreateTaskRequest(&reqs[i])
+		if err != nil {
+			itemErrors = append(itemErrors, services.BatchItemError{Index: i, Message: err.Error()})
+			continue
+		}
+		createReqs = append(createReqs, createReq)
+	}
+	if len(itemErrors) > 0 {
+		respondBatchErrors(c, itemErrors)
+		return
+	}
+
+	tasks, err := h.taskService.CreateMany(c.Request.Context(), userID, createReqs)
+	if err != nil {
+		var batchErr *services.BatchCreateError
+		if stdErrors.As(err, &batchErr) {
+			respondBatchErrors(c, batchErr.Items)
+			return
+		}
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tasks)
+}
+
+// respondBatchErrors returns the per-item error report of a rejected batch
+func respondBatchErrors(c *gin.Context, items []services.BatchItemError) {
+	c.JSON(http.StatusUnprocessableEntity, BatchCreateTasksErrorResponse{
+		Error:   "Validation failed",
+		Message: "No tasks were created because one or more items are invalid",
+		Items:   items,
+	})
+}
+
+// batchItemValidationMessage joins the field messages of a failed item validation
+func batchItemValidationMessage(err error) string {
+	var validationErrors validator.ValidationErrors
+	if !stdErrors.As(err, &validationErrors) {
+		return err.Error()
+	}
+	messages := make([]string, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		messages[i] = validationMessage(fieldErr)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// toServiceCreateTaskRequest parses the due date and priority of a create request
+func toServiceCreateTaskRequest(req *CreateTaskRequest) (*services.CreateTaskRequest, error) {
 	// Parse due date if provided
 	var dueDate *time.Time
 	if req.DueDate != nil && *req.DueDate != "" {
 		parsed, err := time.Parse(time.RFC3339, *req.DueDate)
 		if err != nil {
-			handleError(c, errors.NewInvalidInputError("Invalid date format. Use ISO 8601 (RFC3339)"))
-			return
+			return nil, errors.NewInvalidInputError("Invalid date format. Use ISO 8601 (RFC3339)")
 		}
 		dueDate = &parsed
 	}
@@ -92,7 +209,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		priority = &p
 	}
 
-	createReq := &services.CreateTaskRequest{
+	return &services.CreateTaskRequest{
 		Title:       req.Title,
 		Description: req.Description,
 		Type:        req.Type,
@@ -100,15 +217,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		DueDate:     dueDate,
 		UserID:      req.UserID,
 		TagIDs:      req.TagIDs,
-	}
-
-	task, err := h.taskService.Create(userID, createReq)
-	if err != nil {
-		handleError(c, err)
-		return
-	}
-
-	c.JSON(http.StatusCreated, task)
+	}, nil
 }
 
 // GetTasks lists user tasks
@@ -119,17 +228,25 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        page          query     int     false  "Page number (default: 1)"
-// @Param        limit         query     int     false  "Items per page (default: 10, max: 100)"
-// @Param        type          query     string  false  "Filter by task type (casa, trabalho, lazer, saude)"
+// @Param        limit         query     int     false  "Items per page (default: 10, max: 100). 0 returns every matching task on a single page, up to TASK_LIST_ALL_MAX (default: 1000)"
+// @Param        type          query     string  false  "Filter by task type, comma-separated for multiple (casa, trabalho, lazer, saude)"
+// @Param        priority      query     string  false  "Filter by priority, comma-separated for multiple (baixa, media, alta, urgente)"
 // @Param        completed     query     bool    false  "Filter by completion status"
 // @Param        search        query     string  false  "Search in title and description"
 // @Param        due_date_from query     string  false  "Filter tasks with due date from (ISO 8601 format)"
 // @Param        due_date_to   query     string  false  "Filter tasks with due date to (ISO 8601 format)"
 // @Param        period        query     string  false  "Filter by period (overdue, today, this_week, this_month)"
 // @Param        assigned_by   query     int     false  "Filter by user ID who assigned the task"
-// @Param        sort_by       query     string  false  "Sort field (created_at, due_date, title)"
+// @Param        has_comments  query     bool    false  "Filter tasks with (true) or without (false) comments"
+// @Param        include_archived query  bool    false  "Include archived tasks (default: false)"
+// @Param        sort_by       query     string  false  "Sort field (created_at, due_date, title); tasks without due date always sort last by due_date"
 // @Param        order         query     string  false  "Sort order (asc, desc)"
+// @Param        include_counts query    bool    false  "Include counts per bucket (total, pending, completed, overdue) for the current filters, ignoring completed and period=overdue"
 // @Success      200           {object}  services.PaginatedTasksResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
 // @Failure      400           {object}  ErrorResponse
 // @Failure      401           {object}  ErrorResponse
 // @Failure      500           {object}  ErrorResponse
@@ -139,28 +256,35 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 
 	filters := &services.TaskFilters{}
 
-	// Parse pagination
-	if pageStr := c.Query("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			filters.Page = page
-		}
-	}
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filters.Limit = limit
-		}
+	// Parse pagination; an explicit limit=0 lists every matching task on a single page
+	page, limit, err := parsePagination(c, true)
+	if err != nil {
+		handleError(c, err)
+		return
 	}
+	filters.Page = page
+	filters.Limit = limit
+	filters.All = c.Query("limit") == "0"
 
 	// Parse filters
-	if taskType := c.Query("type"); taskType != "" {
-		taskTypeEnum := models.TaskType(taskType)
-		filters.Type = &taskTypeEnum
+	// Parse type filter (comma-separated, e.g. casa,saude)
+	for _, taskType := range splitQueryList(c.Query("type")) {
+		filters.Types = append(filters.Types, models.TaskType(taskType))
 	}
 
-	if completed := c.Query("completed"); completed != "" {
-		completedBool := completed == "true"
-		filters.Completed = &completedBool
+	completed, err := parseBoolQuery(c, "completed")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	filters.Completed = completed
+
+	hasComments, err := parseBoolQuery(c, "has_comments")
+	if err != nil {
+		handleError(c, err)
+		return
 	}
+	filters.HasComments = hasComments
 
 	if search := c.Query("search"); search != "" {
 		filters.Search = &search
@@ -168,22 +292,21 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 
 	// Parse date filters and period filters
 	now := time.Now()
+	// The periods end a second before the next one starts: a task due at midnight is a date-only
+	// task of that new day (as in the notifications), so it is not listed in the period before it
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	todayEnd := todayStart.Add(24 * time.Hour)
+	todayEnd := todayStart.AddDate(0, 0, 1).Add(-1 * time.Second)
 	weekStart := todayStart.AddDate(0, 0, -int(now.Weekday()))
-	weekEnd := weekStart.AddDate(0, 0, 7)
+	weekEnd := weekStart.AddDate(0, 0, 7).Add(-1 * time.Second)
 	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	monthEnd := monthStart.AddDate(0, 1, 0)
+	monthEnd := monthStart.AddDate(0, 1, 0).Add(-1 * time.Second)
 
 	// Handle period filters (overdue, today, this_week, this_month)
 	if period := c.Query("period"); period != "" {
 		switch period {
 		case "overdue":
 			// Tasks with due_date in the past and not completed
-			past := now
-			filters.DueDateTo = &past
-			notCompleted := false
-			filters.Completed = &notCompleted
+			filters.Overdue = true
 		case "today":
 			filters.DueDateFrom = &todayStart
 			filters.DueDateTo = &todayEnd
@@ -209,10 +332,9 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		}
 	}
 
-	// Parse priority filter
-	if priorityStr := c.Query("priority"); priorityStr != "" {
-		priority := models.Priority(priorityStr)
-		filters.Priority = &priority
+	// Parse priority filter (comma-separated, e.g. alta,urgente)
+	for _, priority := range splitQueryList(c.Query("priority")) {
+		filters.Priorities = append(filters.Priorities, models.Priority(priority))
 	}
 
 	// Parse assigned_by filter
@@ -253,6 +375,9 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		}
 	}
 
+	// Archived tasks are hidden unless explicitly requested
+	filters.IncludeArchived = c.Query("include_archived") == "true"
+
 	// Parse sorting
 	if sortBy := c.Query("sort_by"); sortBy != "" {
 		filters.SortBy = sortBy
@@ -261,7 +386,142 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		filters.Order = order
 	}
 
-	result, err := h.taskService.GetByUserID(userID, filters)
+	filters.IncludeCounts = c.Query("include_counts") == "true"
+
+	result, err := h.taskService.GetByUserID(c.Request.Context(), userID, filters)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setPaginationHeaders(c, result.Page, result.Limit, result.Total, result.TotalPages)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTagTasks lists the tasks with a tag
+// @Summary      List tasks by tag
+// @Description  Retrieves paginated tasks accessible to the authenticated user that have the tag. The tag must belong to the authenticated user.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      int     true   "Tag ID"
+// @Param        page          query     int     false  "Page number (default: 1)"
+// @Param        limit         query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        completed     query     bool    false  "Filter by completion status"
+// @Param        include_archived query  bool    false  "Include archived tasks (default: false)"
+// @Param        sort_by       query     string  false  "Sort field (created_at, due_date, title); tasks without due date always sort last by due_date"
+// @Param        order         query     string  false  "Sort order (asc, desc)"
+// @Success      200           {object}  services.PaginatedTasksResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
+// @Failure      400           {object}  ErrorResponse
+// @Failure      401           {object}  ErrorResponse
+// @Failure      404           {object}  ErrorResponse
+// @Failure      500           {object}  ErrorResponse
+// @Router       /tags/{id}/tasks [get]
+func (h *TaskHandler) GetTagTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid tag ID"))
+		return
+	}
+
+	page, limit, err := parsePagination(c, false)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	filters := &services.TaskFilters{
+		Page:            page,
+		Limit:           limit,
+		IncludeArchived: c.Query("include_archived") == "true",
+		SortBy:          c.Query("sort_by"),
+		Order:           c.Query("order"),
+	}
+	completed, err := parseBoolQuery(c, "completed")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	filters.Completed = completed
+
+	result, err := h.taskService.GetByTag(c.Request.Context(), userID, uint(tagID), filters)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setPaginationHeaders(c, result.Page, result.Limit, result.Total, result.TotalPages)
+	c.JSON(http.StatusOK, result)
+}
+
+// TagTasksRequest lists the tasks to add a tag to or remove it from
+type TagTasksRequest struct {
+	TaskIDs []uint `json:"task_ids" binding:"required" example:"1,2,3"`
+}
+
+// AssignTag adds a tag to several tasks
+// @Summary      Add a tag to several tasks
+// @Description  Adds the tag, which must belong to the authenticated user, to up to 100 tasks at once. Only tasks the user owns are changed; tasks that already have the tag, that aren't accessible or that already have TASK_MAX_TAGS tags are listed in failed with the reason; affected is the number of tasks changed. If any task is only shared with or assigned by the user, the request is rejected with 403 and nothing is changed, as only the owner edits a task's tags.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int              true  "Tag ID"
+// @Param        request  body      TagTasksRequest  true  "Task IDs"
+// @Success      200      {object}  services.BulkResult
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /tags/{id}/assign [post]
+func (h *TaskHandler) AssignTag(c *gin.Context) {
+	h.bulkTag(c, h.taskService.AssignTag)
+}
+
+// UnassignTag removes a tag from several tasks
+// @Summary      Remove a tag from several tasks
+// @Description  Removes the tag, which must belong to the authenticated user, from up to 100 tasks at once. Only tasks the user owns are changed; tasks without the tag or that aren't accessible are listed in failed with the reason; affected is the number of tasks changed. If any task is only shared with or assigned by the user, the request is rejected with 403 and nothing is changed.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int              true  "Tag ID"
+// @Param        request  body      TagTasksRequest  true  "Task IDs"
+// @Success      200      {object}  services.BulkResult
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /tags/{id}/unassign [post]
+func (h *TaskHandler) UnassignTag(c *gin.Context) {
+	h.bulkTag(c, h.taskService.UnassignTag)
+}
+
+// bulkTag parses a bulk tag request and applies it with apply
+func (h *TaskHandler) bulkTag(c *gin.Context, apply func(ctx context.Context, userID, tagID uint, taskIDs []uint) (*services.BulkResult, error)) {
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid tag ID"))
+		return
+	}
+
+	var req TagTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	result, err := apply(c.Request.Context(), c.GetUint("user_id"), uint(tagID), req.TaskIDs)
 	if err != nil {
 		handleError(c, err)
 		return
@@ -272,24 +532,34 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 
 // GetAssignedTasks lists tasks assigned by the authenticated user
 // @Summary      List tasks assigned by user
-// @Description  Retrieves paginated tasks that were created/assigned by the authenticated user to other users. This allows users to follow tasks they created for others.
+// @Description  Retrieves paginated tasks that were created/assigned by the authenticated user to other users. This allows users to follow tasks they created for others. With group_by=assignee the response is a services.AssigneeGroupsResponse instead: all matching tasks grouped per assignee with total, pending, completed and overdue counts.
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        page          query     int     false  "Page number (default: 1)"
 // @Param        limit         query     int     false  "Items per page (default: 10, max: 100)"
-// @Param        type          query     string  false  "Filter by task type (casa, trabalho, lazer, saude)"
+// @Param        type          query     string  false  "Filter by task type, comma-separated for multiple (casa, trabalho, lazer, saude)"
+// @Param        priority      query     string  false  "Filter by priority, comma-separated for multiple (baixa, media, alta, urgente)"
 // @Param        completed     query     bool    false  "Filter by completion status"
 // @Param        search        query     string  false  "Search in title and description"
 // @Param        due_date_from query     string  false  "Filter tasks with due date from (ISO 8601 format)"
 // @Param        due_date_to   query     string  false  "Filter tasks with due date to (ISO 8601 format)"
 // @Param        period        query     string  false  "Filter by period (overdue, today, this_week, this_month)"
-// @Param        sort_by       query     string  false  "Sort field (created_at, due_date, title)"
+// @Param        include_archived query  bool    false  "Include archived tasks (default: false)"
+// @Param        assignee_id   query     int     false  "Only tasks assigned to this user"
+// @Param        group_by      query     string  false  "assignee: return every matching task grouped per assignee, with counts (services.AssigneeGroupsResponse), instead of a page"
+// @Param        include       query     string  false  "Comma-separated relations to load (user, assigned_by_user, updated_by_user, shared_with, tags). Default: all; empty: none, for a lean list"
+// @Param        sort_by       query     string  false  "Sort field (created_at, due_date, title); tasks without due date always sort last by due_date. Default: due_date ascending"
 // @Param        order         query     string  false  "Sort order (asc, desc)"
 // @Success      200           {object}  services.PaginatedTasksResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
 // @Failure      400           {object}  ErrorResponse
 // @Failure      401           {object}  ErrorResponse
+// @Failure      404           {object}  ErrorResponse
 // @Failure      500           {object}  ErrorResponse
 // @Router       /tasks/assigned [get]
 func (h *TaskHandler) GetAssignedTasks(c *gin.Context) {
@@ -298,28 +568,26 @@ func (h *TaskHandler) GetAssignedTasks(c *gin.Context) {
 	filters := &services.TaskFilters{}
 
 	// Parse pagination
-	if pageStr := c.Query("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			filters.Page = page
-		}
-	}
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filters.Limit = limit
-		}
+	page, limit, err := parsePagination(c, false)
+	if err != nil {
+		handleError(c, err)
+		return
 	}
+	filters.Page = page
+	filters.Limit = limit
 
 	// Parse filters
-	if typeStr := c.Query("type"); typeStr != "" {
-		taskType := models.TaskType(typeStr)
-		filters.Type = &taskType
+	// Parse type filter (comma-separated, e.g. casa,saude)
+	for _, taskType := range splitQueryList(c.Query("type")) {
+		filters.Types = append(filters.Types, models.TaskType(taskType))
 	}
 
-	if completedStr := c.Query("completed"); completedStr != "" {
-		if completed, err := strconv.ParseBool(completedStr); err == nil {
-			filters.Completed = &completed
-		}
+	completed, err := parseBoolQuery(c, "completed")
+	if err != nil {
+		handleError(c, err)
+		return
 	}
+	filters.Completed = completed
 
 	if search := c.Query("search"); search != "" {
 		filters.Search = &search
@@ -331,10 +599,7 @@ func (h *TaskHandler) GetAssignedTasks(c *gin.Context) {
 
 		switch period {
 		case "overdue":
-			dueDateTo := now
-			filters.DueDateTo = &dueDateTo
-			notCompleted := false
-			filters.Completed = &notCompleted
+			filters.Overdue = true
 		case "today":
 			startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 			endOfDay := startOfDay.Add(24 * time.Hour).Add(-1 * time.Second)
@@ -370,10 +635,9 @@ func (h *TaskHandler) GetAssignedTasks(c *gin.Context) {
 		}
 	}
 
-	// Parse priority filter
-	if priorityStr := c.Query("priority"); priorityStr != "" {
-		priority := models.Priority(priorityStr)
-		filters.Priority = &priority
+	// Parse priority filter (comma-separated, e.g. alta,urgente)
+	for _, priority := range splitQueryList(c.Query("priority")) {
+		filters.Priorities = append(filters.Priorities, models.Priority(priority))
 	}
 
 	// Parse tag_ids filter (comma-separated)
@@ -406,6 +670,14 @@ func (h *TaskHandler) GetAssignedTasks(c *gin.Context) {
 		}
 	}
 
+	// Parse include (comma-separated relations to preload); an empty value skips all of them
+	if include, ok := c.GetQuery("include"); ok {
+		filters.Include = append([]string{}, splitQueryList(include)...)
+	}
+
+	// Archived tasks are hidden unless explicitly requested
+	filters.IncludeArchived = c.Query("include_archived") == "true"
+
 	// Parse sorting
 	if sortBy := c.Query("sort_by"); sortBy != "" {
 		filters.SortBy = sortBy
@@ -414,18 +686,207 @@ func (h *TaskHandler) GetAssignedTasks(c *gin.Context) {
 		filters.Order = order
 	}
 
-	result, err := h.taskService.GetAssignedByUser(userID, filters)
+	// Parse assignee filter (the user the tasks were assigned to)
+	if assigneeIDStr := c.Query("assignee_id"); assigneeIDStr != "" {
+		assigneeID, err := strconv.ParseUint(assigneeIDStr, 10, 32)
+		if err != nil {
+			handleError(c, errors.NewInvalidInputError("Invalid assignee ID"))
+			return
+		}
+		id := uint(assigneeID)
+		filters.AssigneeID = &id
+	}
+
+	// group_by=assignee returns every matching task bucketed per assignee instead of a page
+	switch c.Query("group_by") {
+	case "":
+	case "assignee":
+		groups, err := h.taskService.GetAssignedByUserByAssignee(c.Request.Context(), userID, filters)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, groups)
+		return
+	default:
+		handleError(c, errors.NewInvalidInputError("Invalid group_by. Must be: assignee"))
+		return
+	}
+
+	result, err := h.taskService.GetAssignedByUser(c.Request.Context(), userID, filters)
 	if err != nil {
 		handleError(c, err)
 		return
 	}
 
+	setPaginationHeaders(c, result.Page, result.Limit, result.Total, result.TotalPages)
 	c.JSON(http.StatusOK, result)
 }
 
+// AssignedToMeCountResponse represents the number of pending tasks others assigned to the user
+type AssignedToMeCountResponse struct {
+	Count int64 `json:"count" example:"3"`
+}
+
+// GetAssignedToMeTasks lists tasks others assigned to the authenticated user
+// @Summary      List tasks assigned to me
+// @Description  Retrieves paginated tasks owned by the authenticated user that were created/assigned by another user. Each task includes assigned_by_user with the assigner's details.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int     false  "Page number (default: 1)"
+// @Param        limit      query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        completed  query     bool    false  "Filter by completion status"
+// @Param        sort_by    query     string  false  "Sort field (created_at, due_date, title, priority)"
+// @Param        order      query     string  false  "Sort order (asc, desc)"
+// @Success      200        {object}  services.PaginatedTasksResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
+// @Failure      400        {object}  ErrorResponse
+// @Failure      401        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /tasks/assigned-to-me [get]
+func (h *TaskHandler) GetAssignedToMeTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	page, limit, err := parsePagination(c, false)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	filters := &services.TaskFilters{
+		Page:   page,
+		Limit:  limit,
+		SortBy: c.Query("sort_by"),
+		Order:  c.Query("order"),
+	}
+	completed, err := parseBoolQuery(c, "completed")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	filters.Completed = completed
+
+	result, err := h.taskService.GetAssignedToUser(c.Request.Context(), userID, filters)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setPaginationHeaders(c, result.Page, result.Limit, result.Total, result.TotalPages)
+	c.JSON(http.StatusOK, result)
+}
+
+// CountAssignedToMeTasks counts pending tasks others assigned to the authenticated user
+// @Summary      Count pending tasks assigned to me
+// @Description  Returns how many pending, non archived tasks owned by the authenticated user were assigned by another user
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  AssignedToMeCountResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/assigned-to-me/count [get]
+func (h *TaskHandler) CountAssignedToMeTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	count, err := h.taskService.CountPendingAssignedToUser(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AssignedToMeCountResponse{Count: count})
+}
+
+// GetTaskStats returns the counts of the tasks created and completed in a date range
+// @Summary      Get task stats
+// @Description  Counts the tasks accessible to the authenticated user (owned or shared with them) in a date range given by from and to, both optional and inclusive: created counts the tasks created in the range, completed the tasks completed in the range (by completed_at, whenever they were created), and pending and overdue (pending with a past due date) how many of the created tasks are still open. Overall and per type. Without from and to, all tasks are counted. Archived tasks are excluded unless include_archived=true.
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from              query     string  false  "Start of the range (ISO 8601, e.g. 2024-12-01T00:00:00Z)"
+// @Param        to                query     string  false  "End of the range (ISO 8601)"
+// @Param        include_archived  query     bool    false  "Also count archived tasks (default: false)"
+// @Success      200               {object}  services.TaskStats
+// @Failure      400               {object}  ErrorResponse
+// @Failure      401               {object}  ErrorResponse
+// @Failure      500               {object}  ErrorResponse
+// @Router       /tasks/stats [get]
+func (h *TaskHandler) GetTaskStats(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	stats, err := h.taskService.GetStats(c.Request.Context(), userID, from, to, c.Query("include_archived") == "true")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// defaultUpcomingHours is the window used by GetUpcomingTasks when hours is not provided
+const defaultUpcomingHours = 24
+
+// UpcomingTasksResponse represents the tasks due within the next hours
+type UpcomingTasksResponse struct {
+	Hours int           `json:"hours" example:"48"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// GetUpcomingTasks lists tasks due within the next hours
+// @Summary      List upcoming tasks
+// @Description  Retrieves pending, non archived tasks accessible to the authenticated user whose due date is between now and now + hours, sorted by due date ascending
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        hours  query     int  false  "Window in hours (default: 24, max: 720)"
+// @Success      200    {object}  UpcomingTasksResponse
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /tasks/upcoming [get]
+func (h *TaskHandler) GetUpcomingTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	hours := defaultUpcomingHours
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		parsed, err := strconv.Atoi(hoursStr)
+		if err != nil {
+			handleError(c, errors.NewInvalidInputError("hours must be an integer"))
+			return
+		}
+		hours = parsed
+	}
+
+	tasks, err := h.taskService.GetUpcoming(c.Request.Context(), userID, hours)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, UpcomingTasksResponse{Hours: hours, Tasks: tasks})
+}
+
 // GetTask retrieves a specific task
 // @Summary      Get a task by ID
-// @Description  Retrieves a specific task by its ID
+// @Description  Retrieves a specific task by its ID. The response includes permissions (can_edit, can_complete, can_delete, can_comment, is_owner, is_shared) computed for the authenticated user.
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
@@ -445,7 +906,7 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		return
 	}
 
-	task, err := h.taskService.GetByID(userID, uint(taskID))
+	task, err := h.taskService.GetByID(c.Request.Context(), userID, uint(taskID))
 	if err != nil {
 		handleError(c, err)
 		return
@@ -454,9 +915,37 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 	c.JSON(http.StatusOK, task)
 }
 
+// HeadTask checks that a task exists and is accessible without loading it
+// @Summary      Check a task
+// @Description  Returns 200 if the task exists and the authenticated user can access it (owner, creator or shared user), 403 if it exists but isn't accessible and 404 if it doesn't exist. The response has no body.
+// @Tags         tasks
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Task ID"
+// @Success      200
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Router       /tasks/{id} [head]
+func (h *TaskHandler) HeadTask(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.taskService.CheckAccess(c.Request.Context(), userID, uint(taskID)); err != nil {
+		c.Status(errorStatus(err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
 // UpdateTask updates a task
 // @Summary      Update a task
-// @Description  Updates an existing task
+// @Description  Updates an existing task. Any user with access (owner, creator or shared user) can change the completion status; only the owner can edit title, description, type, priority, due date and tags.
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
@@ -465,6 +954,7 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 // @Param        request  body      UpdateTaskRequest  true  "Task update data"
 // @Success      200      {object}  models.Task
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      403      {object}  ErrorResponse
 // @Failure      404      {object}  ErrorResponse
@@ -480,7 +970,7 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 
 	var req UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleError(c, errors.NewInvalidInputError(err.Error()))
+		handleValidationError(c, err)
 		return
 	}
 
@@ -516,7 +1006,7 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		TagIDs:      req.TagIDs,
 	}
 
-	task, err := h.taskService.Update(userID, uint(taskID), updateReq)
+	task, err := h.taskService.Update(c.Request.Context(), userID, uint(taskID), updateReq)
 	if err != nil {
 		handleError(c, err)
 		return
@@ -548,7 +1038,7 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.Delete(userID, uint(taskID)); err != nil {
+	if err := h.taskService.Delete(c.Request.Context(), userID, uint(taskID)); err != nil {
 		handleError(c, err)
 		return
 	}
@@ -556,9 +1046,149 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	handleSuccess(c, http.StatusOK, "Task deleted successfully", nil)
 }
 
+// DeleteCompletedTasks clears the user's completed tasks
+// @Summary      Clear completed tasks
+// @Description  Clears all completed tasks owned by the authenticated user in one call; tasks shared with the user are not affected. The tasks are soft-deleted, or archived when TASK_CLEAR_COMPLETED_ARCHIVE is enabled. Returns the number of tasks cleared in affected.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  services.ClearCompletedResult
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/completed [delete]
+func (h *TaskHandler) DeleteCompletedTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	result, err := h.taskService.DeleteCompleted(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportTasks exports the user's tasks
+// @Summary      Export tasks
+// @Description  Exports all tasks owned by the authenticated user (archived included) with their tags, in a format accepted by POST /tasks/import. Only format=json is supported.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        format  query     string  false  "Export format (json)"
+// @Success      200     {object}  services.TaskExport
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /tasks/export [get]
+func (h *TaskHandler) ExportTasks(c *gin.Context) {
+	if format := c.DefaultQuery("format", "json"); format != "json" {
+		handleError(c, errors.NewInvalidInputError("Unsupported export format. Must be: json"))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	export, err := h.taskService.Export(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="tasks.json"`)
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportTasks imports tasks from an export
+// @Summary      Import tasks
+// @Description  Recreates the tasks of a JSON export (GET /tasks/export) for the authenticated user. Missing tags are created by name; types and priorities are matched case-insensitively. Invalid items are skipped and reported by index instead of aborting the import.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      services.TaskExport  true  "Exported tasks"
+// @Success      200      {object}  services.ImportResult
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /tasks/import [post]
+func (h *TaskHandler) ImportTasks(c *gin.Context) {
+	var export services.TaskExport
+	if err := c.ShouldBindJSON(&export); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	result, err := h.taskService.Import(c.Request.Context(), userID, &export)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ArchiveTask archives a task
+// @Summary      Archive a task
+// @Description  Archives a task so it is kept but hidden from the default task lists (use include_archived=true to list it). Archived tasks don't trigger due date notifications. Only the task owner can archive.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Task ID"
+// @Success      200  {object}  models.Task
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/{id}/archive [put]
+func (h *TaskHandler) ArchiveTask(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// UnarchiveTask restores an archived task
+// @Summary      Unarchive a task
+// @Description  Restores an archived task to the default task lists. Only the task owner can unarchive.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Task ID"
+// @Success      200  {object}  models.Task
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/{id}/unarchive [put]
+func (h *TaskHandler) UnarchiveTask(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *TaskHandler) setArchived(c *gin.Context, archived bool) {
+	userID := c.GetUint("user_id")
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid task ID"))
+		return
+	}
+
+	task, err := h.taskService.SetArchived(c.Request.Context(), userID, uint(taskID), archived)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
 // ShareTask shares a task with other users (owner only). No limit on how many users.
 // @Summary      Share a task with users
-// @Description  Adds the given users to the task's shared list so they can view and update the task. Only the task owner can share. When a user creates a task for another, the task is already shared between the two.
+// @Description  Adds the given users to the task's shared list so they can view the task and mark it as completed. Only the task owner can share. When a user creates a task for another, the task is already shared between the two.
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
@@ -567,6 +1197,7 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 // @Param        request  body      ShareTaskRequest  true  "User IDs to share with"
 // @Success      200      {object}  SuccessResponse
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      403      {object}  ErrorResponse
 // @Failure      404      {object}  ErrorResponse
@@ -582,11 +1213,11 @@ func (h *TaskHandler) ShareTask(c *gin.Context) {
 
 	var req ShareTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleError(c, errors.NewInvalidInputError(err.Error()))
+		handleValidationError(c, err)
 		return
 	}
 
-	if err := h.taskService.ShareTask(userID, uint(taskID), req.UserIDs); err != nil {
+	if err := h.taskService.ShareTask(c.Request.Context(), userID, uint(taskID), req.UserIDs); err != nil {
 		handleError(c, err)
 		return
 	}
@@ -623,10 +1254,21 @@ func (h *TaskHandler) UnshareTask(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.UnshareTask(userID, uint(taskID), uint(sharedUserID)); err != nil {
+	if err := h.taskService.UnshareTask(c.Request.Context(), userID, uint(taskID), uint(sharedUserID)); err != nil {
 		handleError(c, err)
 		return
 	}
 
 	handleSuccess(c, http.StatusOK, "User removed from shared list", nil)
 }
+
+// splitQueryList splits a comma-separated query value, ignoring blank items
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -2,17 +2,25 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
+	"todo-go-backend/internal/repositories"
+	"todo-go-backend/internal/services"
 	"todo-go-backend/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func createTestUser(t *testing.T) (models.User, string) {
@@ -85,6 +93,409 @@ func TestCreateTask(t *testing.T) {
 		assert.Equal(t, otherUser.ID, task.UserID)
 		assert.Equal(t, user.ID, *task.AssignedBy)
 	})
+
+	t.Run("Create task with your own user ID is not a self-assignment", func(t *testing.T) {
+		reqBody := CreateTaskRequest{
+			Title:  "Task for myself",
+			Type:   models.TaskTypeCasa,
+			UserID: &user.ID,
+		}
+		jsonValue, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var task models.Task
+		json.Unmarshal(w.Body.Bytes(), &task)
+		assert.Equal(t, user.ID, task.UserID)
+		assert.Nil(t, task.AssignedBy)
+
+		req, _ = http.NewRequest("GET", "/api/v1/tasks/assigned", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		for _, assigned := range response.Tasks {
+			assert.NotEqual(t, user.ID, assigned.UserID)
+		}
+	})
+
+	t.Run("Create tagged task for another user copies tags", func(t *testing.T) {
+		assignee := models.User{
+			Username: "assignee",
+			Email:    "assignee@example.com",
+			Password: "hashed",
+		}
+		database.DB.Create(&assignee)
+
+		tag := models.Tag{Name: "urgente", Color: "#FF0000", UserID: user.ID}
+		database.DB.Create(&tag)
+
+		reqBody := CreateTaskRequest{
+			Title:  "Tagged task for other user",
+			Type:   models.TaskTypeTrabalho,
+			UserID: &assignee.ID,
+			TagIDs: []uint{tag.ID},
+		}
+		jsonValue, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var task models.Task
+		json.Unmarshal(w.Body.Bytes(), &task)
+		if assert.Len(t, task.Tags, 1) {
+			assert.Equal(t, "urgente", task.Tags[0].Name)
+			assert.Equal(t, "#FF0000", task.Tags[0].Color)
+			assert.Equal(t, assignee.ID, task.Tags[0].UserID)
+		}
+	})
+
+	t.Run("A failed create for another user leaves no copied tag", func(t *testing.T) {
+		assignee := models.User{Username: "failed-assignee", Email: "failed-assignee@example.com", Password: "hashed"}
+		database.DB.Create(&assignee)
+		tag := models.Tag{Name: "Casa", Color: "#00FF00", UserID: user.ID}
+		database.DB.Create(&tag)
+		failTaskInserts(t)
+
+		jsonValue, _ := json.Marshal(CreateTaskRequest{Title: "Not saved", Type: models.TaskTypeCasa, UserID: &assignee.ID, TagIDs: []uint{tag.ID}})
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var count int64
+		database.DB.Model(&models.Tag{}).Where("user_id = ?", assignee.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Invalid fields return field-level errors", func(t *testing.T) {
+		jsonValue := []byte(`{"type": "escola", "priority": "alta"}`)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response ValidationErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "title is required", response.Fields["title"])
+		assert.Equal(t, services.InvalidTaskTypeMessage, response.Fields["type"])
+		assert.NotContains(t, response.Fields, "priority")
+	})
+
+	t.Run("Whitespace-only title is rejected", func(t *testing.T) {
+		jsonValue := []byte(`{"title": "   ", "type": "casa"}`)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), services.EmptyTitleMessage)
+	})
+
+	t.Run("Invalid priority lists the allowed values", func(t *testing.T) {
+		jsonValue := []byte(`{"title": "Task", "type": "casa", "priority": "maxima"}`)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response ValidationErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "Invalid priority. Must be one of: baixa, media, alta, urgente", response.Fields["priority"])
+	})
+
+	t.Run("Malformed JSON returns bad request", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(`{"title":`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// recordingNotifier records the tasks it's asked to notify about
+type recordingNotifier struct {
+	assigned []models.Task
+	comments []commentNotification
+}
+
+// commentNotification is a comment notification recorded by recordingNotifier
+type commentNotification struct {
+	comment    models.Comment
+	recipients []models.User
+}
+
+func (n *recordingNotifier) NotifyAssigned(task *models.Task) {
+	n.assigned = append(n.assigned, *task)
+}
+
+func (n *recordingNotifier) NotifyCommented(task *models.Task, comment *models.Comment, recipients []models.User) {
+	n.comments = append(n.comments, commentNotification{comment: *comment, recipients: recipients})
+}
+
+func TestCreateTaskNotifiesAssignee(t *testing.T) {
+	setupTestDB()
+	user, _ := createTestUser(t)
+	other := models.User{Username: "assignee", Email: "assignee@example.com", Password: "x"}
+	database.DB.Create(&other)
+
+	notifier := &recordingNotifier{}
+	taskService := services.NewTaskService(repositories.NewTaskRepository(), repositories.NewUserRepository(), repositories.NewTagRepository(), realtime.NewHub(), notifier, services.TaskServiceOptions{MaxTags: 3, ClearCompletedArchive: false, ListAllMax: 5, HideInaccessible: false})
+
+	_, err := taskService.Create(context.Background(), user.ID, &services.CreateTaskRequest{Title: "Mine", Type: models.TaskTypeCasa})
+	assert.NoError(t, err)
+	_, err = taskService.Create(context.Background(), user.ID, &services.CreateTaskRequest{Title: "Also mine", Type: models.TaskTypeCasa, UserID: &user.ID})
+	assert.NoError(t, err)
+	assert.Empty(t, notifier.assigned)
+
+	task, err := taskService.Create(context.Background(), user.ID, &services.CreateTaskRequest{Title: "For you", Type: models.TaskTypeCasa, UserID: &other.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, notifier.assigned, 1) {
+		assert.Equal(t, task.ID, notifier.assigned[0].ID)
+		assert.Equal(t, other.ID, notifier.assigned[0].UserID)
+		if assert.NotNil(t, notifier.assigned[0].AssignedByUser) {
+			assert.Equal(t, user.Username, notifier.assigned[0].AssignedByUser.Username)
+		}
+	}
+
+	// Editing the task afterwards doesn't notify again
+	title := "Renamed"
+	_, err = taskService.Update(context.Background(), other.ID, task.ID, &services.UpdateTaskRequest{Title: &title})
+	assert.NoError(t, err)
+	assert.Len(t, notifier.assigned, 1)
+
+	_, err = taskService.CreateMany(context.Background(), user.ID, []*services.CreateTaskRequest{
+		{Title: "Batch for you", Type: models.TaskTypeCasa, UserID: &other.ID},
+		{Title: "Batch mine", Type: models.TaskTypeCasa},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, notifier.assigned, 2)
+}
+
+func TestTaskTimestampsInUTC(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	_, token := createTestUser(t)
+
+	dueDate := "2030-01-15T09:00:00-03:00"
+	jsonValue, _ := json.Marshal(CreateTaskRequest{Title: "Meeting", Type: models.TaskTypeTrabalho, DueDate: &dueDate})
+	req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "2030-01-15T12:00:00Z", created["due_date"])
+	assert.True(t, strings.HasSuffix(created["created_at"].(string), "Z"), "created_at should be in UTC: %v", created["created_at"])
+
+	countTasks := func(query string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return len(response.Tasks)
+	}
+
+	// The same instant written with different offsets filters the same way
+	assert.Equal(t, 1, countTasks("due_date_from="+url.QueryEscape("2030-01-15T08:30:00-03:00")))
+	assert.Equal(t, 0, countTasks("due_date_from="+url.QueryEscape("2030-01-15T12:30:00Z")))
+	assert.Equal(t, 0, countTasks("due_date_to="+url.QueryEscape("2030-01-15T11:59:00+00:00")))
+	assert.Equal(t, 1, countTasks("due_date_to="+url.QueryEscape("2030-01-15T10:00:00-02:00")))
+}
+
+// failTaskInserts makes every insert into tasks fail until the test ends, to check what a failed
+// create leaves behind
+func failTaskInserts(t *testing.T) {
+	callback := database.DB.Callback().Create()
+	callback.Before("gorm:create").Register("test:fail_task_inserts", func(db *gorm.DB) {
+		if db.Statement.Table == "tasks" {
+			db.AddError(fmt.Errorf("task insert failed"))
+		}
+	})
+	t.Cleanup(func() { callback.Remove("test:fail_task_inserts") })
+}
+
+// countTaskLoads counts the queries that load task rows, not counts, for the rest of the test
+func countTaskLoads(t *testing.T) *int {
+	loads := 0
+	callback := database.DB.Callback().Query()
+	callback.Before("gorm:query").Register("test:count_task_loads", func(db *gorm.DB) {
+		if _, isCount := db.Statement.Dest.(*int64); db.Statement.Table == "tasks" && !isCount {
+			loads++
+		}
+	})
+	t.Cleanup(func() { callback.Remove("test:count_task_loads") })
+	return &loads
+}
+
+func TestCreateTasksBatch(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	countTasks := func() int64 {
+		var count int64
+		database.DB.Model(&models.Task{}).Where("user_id = ?", user.ID).Count(&count)
+		return count
+	}
+
+	t.Run("Create all tasks", func(t *testing.T) {
+		jsonValue := []byte(`[{"title": "First", "type": "casa"}, {"title": "Second", "type": "saude", "priority": "alta"}]`)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks/batch", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var tasks []models.Task
+		json.Unmarshal(w.Body.Bytes(), &tasks)
+		if assert.Len(t, tasks, 2) {
+			assert.Equal(t, "First", tasks[0].Title)
+			assert.Equal(t, models.PriorityAlta, tasks[1].Priority)
+		}
+	})
+
+	t.Run("Invalid item rejects the whole batch", func(t *testing.T) {
+		before := countTasks()
+		jsonValue := []byte(`[{"title": "Valid", "type": "casa"}, {"title": "No type"}, {"title": "Bad date", "type": "casa", "due_date": "tomorrow"}]`)
+
+		req, _ := http.NewRequest("POST", "/api/v1/tasks/batch", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response BatchCreateTasksErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if assert.Len(t, response.Items, 2) {
+			assert.Equal(t, 1, response.Items[0].Index)
+			assert.Equal(t, "type is required", response.Items[0].Message)
+			assert.Equal(t, 2, response.Items[1].Index)
+		}
+		assert.Equal(t, before, countTasks())
+	})
+
+	t.Run("Tags copied for a failed batch are rolled back", func(t *testing.T) {
+		assignee := models.User{Username: "assignee", Email: "assignee@example.com", Password: "hashed"}
+		database.DB.Create(&assignee)
+		tag := models.Tag{Name: "Mercado", Color: "#00FF00", UserID: user.ID}
+		database.DB.Create(&tag)
+		failTaskInserts(t)
+
+		jsonValue := []byte(fmt.Sprintf(`[{"title": "First", "type": "casa", "user_id": %d, "tag_ids": [%d]}]`, assignee.ID, tag.ID))
+		req, _ := http.NewRequest("POST", "/api/v1/tasks/batch", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var count int64
+		database.DB.Model(&models.Tag{}).Where("user_id = ?", assignee.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestExportImportTasks(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	tag := models.Tag{Name: "Mercado", Color: "#00FF00", UserID: user.ID}
+	database.DB.Create(&tag)
+	task := models.Task{
+		Title:    "Exported Task",
+		Type:     models.TaskTypeCasa,
+		Priority: models.PriorityAlta,
+		UserID:   user.ID,
+		Tags:     []models.Tag{tag},
+	}
+	database.DB.Create(&task)
+
+	req, _ := http.NewRequest("GET", "/api/v1/tasks/export?format=json", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var export services.TaskExport
+	json.Unmarshal(w.Body.Bytes(), &export)
+	if assert.Len(t, export.Tasks, 1) {
+		assert.Equal(t, "Exported Task", export.Tasks[0].Title)
+		assert.Equal(t, "Mercado", export.Tasks[0].Tags[0].Name)
+	}
+
+	// Import into another account, with an invalid item that is skipped
+	otherUser := models.User{Username: "importer", Email: "importer@example.com", Password: "hashed"}
+	database.DB.Create(&otherUser)
+	otherToken, _ := utils.GenerateToken(otherUser.ID, otherUser.Username, "test-secret")
+	export.Tasks = append(export.Tasks, services.ExportedTask{Title: "Invalid", Type: "escola"})
+	jsonValue, _ := json.Marshal(export)
+
+	req, _ = http.NewRequest("POST", "/api/v1/tasks/import", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result services.ImportResult
+	json.Unmarshal(w.Body.Bytes(), &result)
+	assert.Equal(t, 1, result.Imported)
+	if assert.Len(t, result.Skipped, 1) {
+		assert.Equal(t, 1, result.Skipped[0].Index)
+	}
+
+	var imported models.Task
+	database.DB.Preload("Tags").Where("user_id = ?", otherUser.ID).First(&imported)
+	assert.Equal(t, "Exported Task", imported.Title)
+	assert.Equal(t, models.PriorityAlta, imported.Priority)
+	if assert.Len(t, imported.Tags, 1) {
+		assert.Equal(t, "Mercado", imported.Tags[0].Name)
+		assert.Equal(t, otherUser.ID, imported.Tags[0].UserID)
+	}
 }
 
 func TestGetTasks(t *testing.T) {
@@ -142,8 +553,8 @@ func TestGetTasks(t *testing.T) {
 		}
 	})
 
-	t.Run("Search tasks", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/api/v1/tasks?search=Task", nil)
+	t.Run("Filter by multiple types", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?type=casa,trabalho", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -152,11 +563,30 @@ func TestGetTasks(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		var response map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NotNil(t, response["tasks"])
+		assert.Equal(t, float64(2), response["total"])
 	})
 
-	t.Run("Pagination", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/api/v1/tasks?page=1&limit=1", nil)
+	t.Run("Unknown type in filter is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?type=casa,escola", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Filter by multiple priorities", func(t *testing.T) {
+		urgentTask := models.Task{
+			Title:    "Urgent Task",
+			Type:     models.TaskTypeCasa,
+			Priority: models.PriorityUrgente,
+			UserID:   user.ID,
+		}
+		database.DB.Create(&urgentTask)
+		defer database.DB.Unscoped().Delete(&urgentTask)
+
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?priority=alta,urgente", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -165,50 +595,1204 @@ func TestGetTasks(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		var response map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &response)
-		assert.Equal(t, float64(1), response["page"])
-		assert.Equal(t, float64(1), response["limit"])
-		tasks := response["tasks"].([]interface{})
-		assert.LessOrEqual(t, len(tasks), 1)
+		assert.Equal(t, float64(1), response["total"])
 	})
-}
 
-func TestUpdateTask(t *testing.T) {
-	setupTestDB()
-	router := setupTestRouter("test-secret")
-	user, token := createTestUser(t)
+	t.Run("Include counts per bucket", func(t *testing.T) {
+		yesterday := time.Now().Add(-24 * time.Hour)
+		overdueTask := models.Task{
+			Title:   "Overdue Task",
+			Type:    models.TaskTypeLazer,
+			UserID:  user.ID,
+			DueDate: &yesterday,
+		}
+		database.DB.Create(&overdueTask)
+		defer database.DB.Unscoped().Delete(&overdueTask)
 
-	task := models.Task{
-		Title:  "Original Title",
-		Type:   models.TaskTypeCasa,
-		UserID: user.ID,
-	}
-	database.DB.Create(&task)
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?completed=true&include_counts=true", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
 
-	t.Run("Update task successfully", func(t *testing.T) {
-		newTitle := "Updated Title"
-		completed := true
-		reqBody := UpdateTaskRequest{
-			Title:     &newTitle,
-			Completed: &completed,
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, int64(1), response.Total)
+		if assert.NotNil(t, response.Counts) {
+			assert.Equal(t, int64(3), response.Counts.Total)
+			assert.Equal(t, int64(2), response.Counts.Pending)
+			assert.Equal(t, int64(1), response.Counts.Completed)
+			assert.Equal(t, int64(1), response.Counts.Overdue)
 		}
-		jsonValue, _ := json.Marshal(reqBody)
+	})
 
-		req, _ := http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID), bytes.NewBuffer(jsonValue))
-		req.Header.Set("Content-Type", "application/json")
+	t.Run("Search tasks", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?search=Task", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var updatedTask models.Task
-		json.Unmarshal(w.Body.Bytes(), &updatedTask)
-		assert.Equal(t, "Updated Title", updatedTask.Title)
-		assert.True(t, updatedTask.Completed)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NotNil(t, response["tasks"])
 	})
-}
 
-func TestDeleteTask(t *testing.T) {
+	t.Run("Filter by comments", func(t *testing.T) {
+		database.DB.Create(&models.Comment{Content: "First", TaskID: task1.ID, UserID: user.ID})
+		database.DB.Create(&models.Comment{Content: "Second", TaskID: task1.ID, UserID: user.ID})
+		deleted := models.Comment{Content: "Removed", TaskID: task2.ID, UserID: user.ID}
+		database.DB.Create(&deleted)
+		database.DB.Delete(&deleted)
+
+		for query, title := range map[string]string{"true": "Task 1", "1": "Task 1", "false": "Task 2", "0": "Task 2"} {
+			req, _ := http.NewRequest("GET", "/api/v1/tasks?has_comments="+query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, query)
+			var response services.PaginatedTasksResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if assert.Len(t, response.Tasks, 1, query) {
+				assert.Equal(t, title, response.Tasks[0].Title, query)
+			}
+			assert.Equal(t, int64(1), response.Total, query)
+		}
+
+		for _, query := range []string{"yes", "maybe"} {
+			req, _ := http.NewRequest("GET", "/api/v1/tasks?has_comments="+query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?page=1&limit=1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, float64(1), response["page"])
+		assert.Equal(t, float64(1), response["limit"])
+		tasks := response["tasks"].([]interface{})
+		assert.LessOrEqual(t, len(tasks), 1)
+	})
+
+	t.Run("Invalid pagination parameters are rejected", func(t *testing.T) {
+		for _, query := range []string{"?page=abc", "?page=0", "?limit=-5", "?limit=ten"} {
+			req, _ := http.NewRequest("GET", "/api/v1/tasks"+query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Pagination headers", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?page=2&limit=1&type=casa,trabalho", nil)
+		req.Host = "api.example.com"
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+		assert.Equal(t, "2", w.Header().Get("X-Page"))
+		assert.Equal(t, "1", w.Header().Get("X-Per-Page"))
+		link := w.Header().Get("Link")
+		assert.Contains(t, link, `<http://api.example.com/api/v1/tasks?limit=1&page=1&type=casa%2Ctrabalho>; rel="prev"`)
+		assert.Contains(t, link, `rel="last"`)
+		assert.NotContains(t, link, `rel="next"`)
+	})
+}
+
+func TestGetAssignedTasksInclude(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	assignee := models.User{Username: "assignee", Email: "assignee@example.com", Password: "hashed"}
+	database.DB.Create(&assignee)
+	tag := models.Tag{Name: "equipe", UserID: assignee.ID}
+	database.DB.Create(&tag)
+	task := models.Task{
+		Title:      "Assigned",
+		Type:       models.TaskTypeTrabalho,
+		UserID:     assignee.ID,
+		AssignedBy: &user.ID,
+		Tags:       []models.Tag{tag},
+	}
+	database.DB.Create(&task)
+
+	getTasks := func(query string) []models.Task {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/assigned"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Tasks
+	}
+
+	tasks := getTasks("")
+	if assert.Len(t, tasks, 1) {
+		assert.Len(t, tasks[0].Tags, 1)
+		assert.Equal(t, "assignee", tasks[0].User.Username)
+	}
+
+	tasks = getTasks("?include=tags")
+	if assert.Len(t, tasks, 1) {
+		assert.Len(t, tasks[0].Tags, 1)
+		assert.Empty(t, tasks[0].User.Username)
+	}
+
+	tasks = getTasks("?include=")
+	if assert.Len(t, tasks, 1) {
+		assert.Empty(t, tasks[0].Tags)
+		assert.Nil(t, tasks[0].AssignedByUser)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/v1/tasks/assigned?include=comments", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetTasksByTagsCountsEachTaskOnce(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	home := models.Tag{Name: "casa", UserID: user.ID}
+	weekend := models.Tag{Name: "fim de semana", UserID: user.ID}
+	database.DB.Create(&home)
+	database.DB.Create(&weekend)
+	both := models.Task{Title: "Both tags", Type: models.TaskTypeCasa, UserID: user.ID, Tags: []models.Tag{home, weekend}}
+	database.DB.Create(&both)
+	database.DB.Create(&models.Task{Title: "One tag", Type: models.TaskTypeCasa, UserID: user.ID, Tags: []models.Tag{home}})
+	// A stray share row for the owner must not duplicate the task
+	database.DB.Create(&models.TaskSharedWith{TaskID: both.ID, UserID: user.ID})
+
+	getTasks := func(query string) services.PaginatedTasksResponse {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	response := getTasks(fmt.Sprintf("?tag_ids=%d,%d", home.ID, weekend.ID))
+	assert.Equal(t, int64(1), response.Total)
+	if assert.Len(t, response.Tasks, 1) {
+		assert.Equal(t, "Both tags", response.Tasks[0].Title)
+	}
+
+	response = getTasks(fmt.Sprintf("?tag_ids=%d&limit=1", home.ID))
+	assert.Equal(t, int64(2), response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	assert.Len(t, response.Tasks, 1)
+}
+
+func TestGetTasksLimitZero(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret") // Returns at most 5 tasks with limit=0
+	user, token := createTestUser(t)
+
+	createTasks := func(count int) {
+		for i := 0; i < count; i++ {
+			database.DB.Create(&models.Task{Title: fmt.Sprintf("Task %d", i), Type: models.TaskTypeCasa, UserID: user.ID})
+		}
+	}
+	getTasks := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	createTasks(3)
+	t.Run("Returns every matching task on a single page", func(t *testing.T) {
+		w := getTasks("?limit=0")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response.Tasks, 3)
+		assert.Equal(t, int64(3), response.Total)
+		assert.Equal(t, 1, response.TotalPages)
+	})
+
+	t.Run("Without limit the default page size is kept", func(t *testing.T) {
+		createTasks(9)
+		w := getTasks("")
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response.Tasks, 10)
+	})
+
+	t.Run("Above the cap pagination is required", func(t *testing.T) {
+		loads := countTaskLoads(t)
+		w := getTasks("?limit=0")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, *loads, "tasks are loaded before the cap is checked")
+	})
+
+	t.Run("Only a plain 0 lists every task", func(t *testing.T) {
+		for _, limit := range []string{"00", "-0", "+0"} {
+			w := getTasks("?limit=" + limit)
+			assert.Equal(t, http.StatusBadRequest, w.Code, limit)
+		}
+	})
+
+	t.Run("Other lists reject limit=0", func(t *testing.T) {
+		w := getTasks("/assigned?limit=0")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCompletedFilter(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	tag := models.Tag{Name: "Casa", Color: "#808080", UserID: user.ID}
+	database.DB.Create(&tag)
+	database.DB.Create(&models.Task{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true})
+	database.DB.Create(&models.Task{Title: "Pending", Type: models.TaskTypeCasa, UserID: user.ID})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1"+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Accepts any boolean form", func(t *testing.T) {
+		for query, title := range map[string]string{"true": "Done", "1": "Done", "false": "Pending", "0": "Pending"} {
+			w := get("/tasks?completed=" + query)
+			assert.Equal(t, http.StatusOK, w.Code, query)
+			var response services.PaginatedTasksResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if assert.Len(t, response.Tasks, 1, query) {
+				assert.Equal(t, title, response.Tasks[0].Title, query)
+			}
+		}
+	})
+
+	t.Run("Rejects invalid values on every list", func(t *testing.T) {
+		paths := []string{"/tasks", fmt.Sprintf("/tags/%d/tasks", tag.ID), "/tasks/assigned", "/tasks/assigned-to-me"}
+		for _, path := range paths {
+			for _, query := range []string{"yes", "maybe", "True1"} {
+				assert.Equal(t, http.StatusBadRequest, get(path+"?completed="+query).Code, path+"?completed="+query)
+			}
+		}
+	})
+}
+
+func TestGetTasksSortByDueDate(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	soon := time.Now().Add(24 * time.Hour)
+	later := time.Now().Add(72 * time.Hour)
+	for _, task := range []models.Task{
+		{Title: "Later", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &later},
+		{Title: "No due date", Type: models.TaskTypeCasa, UserID: user.ID},
+		{Title: "Soon", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &soon},
+	} {
+		database.DB.Create(&task)
+	}
+
+	getTitles := func(query string) []string {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		titles := []string{}
+		for _, task := range response.Tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	t.Run("Ascending puts tasks without due date last", func(t *testing.T) {
+		assert.Equal(t, []string{"Soon", "Later", "No due date"}, getTitles("?sort_by=due_date&order=asc"))
+	})
+
+	t.Run("Descending puts tasks without due date last", func(t *testing.T) {
+		assert.Equal(t, []string{"Later", "Soon", "No due date"}, getTitles("?sort_by=due_date&order=desc"))
+	})
+}
+
+func TestGetAssignedTasksSort(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	assignee := models.User{Username: "assignee", Email: "assignee@example.com", Password: "hashed"}
+	database.DB.Create(&assignee)
+	soon := time.Now().Add(24 * time.Hour)
+	later := time.Now().Add(72 * time.Hour)
+	for _, task := range []models.Task{
+		{Title: "No due date", Type: models.TaskTypeTrabalho, UserID: assignee.ID, AssignedBy: &user.ID},
+		{Title: "Later", Type: models.TaskTypeTrabalho, UserID: assignee.ID, AssignedBy: &user.ID, DueDate: &later},
+		{Title: "Soon", Type: models.TaskTypeTrabalho, UserID: assignee.ID, AssignedBy: &user.ID, DueDate: &soon},
+	} {
+		database.DB.Create(&task)
+	}
+
+	getTitles := func(query string) []string {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/assigned"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		titles := []string{}
+		for _, task := range response.Tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	t.Run("Defaults to soonest due date, undated last", func(t *testing.T) {
+		assert.Equal(t, []string{"Soon", "Later", "No due date"}, getTitles(""))
+	})
+
+	t.Run("Explicit sort is kept", func(t *testing.T) {
+		assert.Equal(t, []string{"No due date", "Later", "Soon"}, getTitles("?sort_by=created_at&order=asc"))
+	})
+}
+
+func TestGetAssignedTasksByAssignee(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	maria := models.User{Username: "maria", Email: "maria@example.com", Password: "hashed"}
+	database.DB.Create(&maria)
+	joao := models.User{Username: "joao", Email: "joao@example.com", Password: "hashed"}
+	database.DB.Create(&joao)
+	for _, task := range []models.Task{
+		{Title: "For Maria", Type: models.TaskTypeTrabalho, UserID: maria.ID, AssignedBy: &user.ID},
+		{Title: "For Joao", Type: models.TaskTypeTrabalho, UserID: joao.ID, AssignedBy: &user.ID},
+	} {
+		database.DB.Create(&task)
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/assigned"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Only the assignee's tasks are listed", func(t *testing.T) {
+		w := get(fmt.Sprintf("?assignee_id=%d", maria.ID))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, int64(1), response.Total)
+		if assert.Len(t, response.Tasks, 1) {
+			assert.Equal(t, "For Maria", response.Tasks[0].Title)
+		}
+	})
+
+	t.Run("Unknown assignee returns not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("?assignee_id=9999").Code)
+	})
+
+	t.Run("Invalid assignee ID returns bad request", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?assignee_id=maria").Code)
+	})
+}
+
+func TestGetAssignedTasksGroupedByAssignee(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	maria := models.User{Username: "maria", Email: "maria@example.com", Password: "hashed"}
+	database.DB.Create(&maria)
+	joao := models.User{Username: "joao", Email: "joao@example.com", Password: "hashed"}
+	database.DB.Create(&joao)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	for _, task := range []models.Task{
+		{Title: "Maria overdue", Type: models.TaskTypeTrabalho, UserID: maria.ID, AssignedBy: &user.ID, DueDate: &yesterday},
+		{Title: "Maria done", Type: models.TaskTypeTrabalho, UserID: maria.ID, AssignedBy: &user.ID, Completed: true},
+		{Title: "Joao pending", Type: models.TaskTypeCasa, UserID: joao.ID, AssignedBy: &user.ID},
+		{Title: "Own task", Type: models.TaskTypeCasa, UserID: user.ID},
+	} {
+		database.DB.Create(&task)
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/assigned"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Tasks are bucketed per assignee with counts", func(t *testing.T) {
+		w := get("?group_by=assignee")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.AssigneeGroupsResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, int64(3), response.Total)
+		if assert.Len(t, response.Groups, 2) {
+			assert.Equal(t, "joao", response.Groups[0].AssigneeUsername)
+			assert.Equal(t, services.TaskCounts{Total: 1, Pending: 1}, response.Groups[0].Counts)
+			assert.Len(t, response.Groups[0].Tasks, 1)
+
+			assert.Equal(t, maria.ID, response.Groups[1].AssigneeID)
+			assert.Equal(t, services.TaskCounts{Total: 2, Pending: 1, Completed: 1, Overdue: 1}, response.Groups[1].Counts)
+			assert.Len(t, response.Groups[1].Tasks, 2)
+		}
+	})
+
+	t.Run("Filters apply to the groups", func(t *testing.T) {
+		var response services.AssigneeGroupsResponse
+		json.Unmarshal(get("?group_by=assignee&type=casa").Body.Bytes(), &response)
+		if assert.Len(t, response.Groups, 1) {
+			assert.Equal(t, joao.ID, response.Groups[0].AssigneeID)
+		}
+	})
+
+	t.Run("Unknown group_by is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?group_by=type").Code)
+	})
+
+	t.Run("Above the cap the filters must be narrowed", func(t *testing.T) {
+		for i := 0; i < 3; i++ { // The test router returns at most 5 tasks at once
+			database.DB.Create(&models.Task{Title: fmt.Sprintf("Joao %d", i), Type: models.TaskTypeCasa, UserID: joao.ID, AssignedBy: &user.ID})
+		}
+		loads := countTaskLoads(t)
+		assert.Equal(t, http.StatusBadRequest, get("?group_by=assignee").Code)
+		assert.Zero(t, *loads, "tasks are loaded before the cap is checked")
+	})
+}
+
+func TestAssignedToMe(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	assigner := models.User{Username: "assigner", Email: "assigner@example.com", Password: "hashed"}
+	database.DB.Create(&assigner)
+	for _, task := range []models.Task{
+		{Title: "Pending from assigner", Type: models.TaskTypeTrabalho, UserID: user.ID, AssignedBy: &assigner.ID},
+		{Title: "Done from assigner", Type: models.TaskTypeTrabalho, UserID: user.ID, AssignedBy: &assigner.ID, Completed: true},
+		{Title: "Created by me", Type: models.TaskTypeCasa, UserID: user.ID, AssignedBy: &user.ID},
+		{Title: "Legacy without assigner", Type: models.TaskTypeCasa, UserID: user.ID},
+	} {
+		task := task
+		database.DB.Create(&task)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/v1/tasks/assigned-to-me/count", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var countResponse AssignedToMeCountResponse
+	json.Unmarshal(w.Body.Bytes(), &countResponse)
+	assert.Equal(t, int64(1), countResponse.Count)
+
+	req, _ = http.NewRequest("GET", "/api/v1/tasks/assigned-to-me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response services.PaginatedTasksResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, int64(2), response.Total)
+	for _, task := range response.Tasks {
+		if assert.NotNil(t, task.AssignedByUser) {
+			assert.Equal(t, "assigner", task.AssignedByUser.Username)
+		}
+	}
+}
+
+func TestGetUpcomingTasks(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	inTwoHours := time.Now().Add(2 * time.Hour)
+	inOneHour := time.Now().Add(time.Hour)
+	inThreeDays := time.Now().Add(72 * time.Hour)
+	anHourAgo := time.Now().Add(-time.Hour)
+	for _, task := range []models.Task{
+		{Title: "In two hours", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &inTwoHours},
+		{Title: "In one hour", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &inOneHour},
+		{Title: "In three days", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &inThreeDays},
+		{Title: "Overdue", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &anHourAgo},
+		{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &inOneHour, Completed: true},
+	} {
+		task := task
+		database.DB.Create(&task)
+	}
+
+	t.Run("Returns pending tasks in the window sorted by due date", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/upcoming?hours=48", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response UpcomingTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, 48, response.Hours)
+		if assert.Len(t, response.Tasks, 2) {
+			assert.Equal(t, "In one hour", response.Tasks[0].Title)
+			assert.Equal(t, "In two hours", response.Tasks[1].Title)
+		}
+	})
+
+	t.Run("Invalid hours", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/upcoming?hours=0", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetTaskStats(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	december := time.Date(2024, 12, 10, 12, 0, 0, 0, time.UTC)
+	completedInDecember := time.Date(2024, 12, 20, 12, 0, 0, 0, time.UTC)
+	november := time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC)
+	past := time.Now().Add(-time.Hour)
+	for _, task := range []models.Task{
+		{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true, CompletedAt: &completedInDecember, CreatedAt: december},
+		{Title: "Late", Type: models.TaskTypeTrabalho, UserID: user.ID, DueDate: &past, CreatedAt: december},
+		{Title: "Pending", Type: models.TaskTypeTrabalho, UserID: user.ID, CreatedAt: december},
+		{Title: "Archived", Type: models.TaskTypeTrabalho, UserID: user.ID, Completed: true, CompletedAt: &completedInDecember, Archived: true, CreatedAt: december},
+		{Title: "Last month", Type: models.TaskTypeCasa, UserID: user.ID, CreatedAt: november},
+		{Title: "Finished this month", Type: models.TaskTypeSaude, UserID: user.ID, Completed: true, CompletedAt: &completedInDecember, CreatedAt: november},
+		{Title: "Someone else's", Type: models.TaskTypeCasa, UserID: other.ID, CreatedAt: december},
+	} {
+		task := task
+		database.DB.Create(&task)
+	}
+
+	getStats := func(query string) (int, services.TaskStats) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/stats"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var stats services.TaskStats
+		json.Unmarshal(w.Body.Bytes(), &stats)
+		return w.Code, stats
+	}
+
+	t.Run("Counts the tasks created and completed in the range, overall and per type", func(t *testing.T) {
+		code, stats := getStats("?from=2024-12-01T00:00:00Z&to=2024-12-31T23:59:59Z")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, services.TaskStatsCounts{Created: 3, Completed: 2, Pending: 2, Overdue: 1}, stats.TaskStatsCounts)
+		assert.Equal(t, services.TaskStatsCounts{Created: 1, Completed: 1}, stats.ByType[models.TaskTypeCasa])
+		assert.Equal(t, services.TaskStatsCounts{Created: 2, Pending: 2, Overdue: 1}, stats.ByType[models.TaskTypeTrabalho])
+		assert.Equal(t, services.TaskStatsCounts{Completed: 1}, stats.ByType[models.TaskTypeSaude])
+	})
+
+	t.Run("Tasks completed outside the range only count as created", func(t *testing.T) {
+		_, stats := getStats("?from=2024-11-01T00:00:00Z&to=2024-11-30T23:59:59Z")
+		assert.Equal(t, services.TaskStatsCounts{Created: 2, Pending: 1}, stats.TaskStatsCounts)
+	})
+
+	t.Run("Without a range every task is counted", func(t *testing.T) {
+		_, stats := getStats("")
+		assert.Equal(t, int64(5), stats.Created)
+		assert.Equal(t, int64(2), stats.Completed)
+		assert.Nil(t, stats.From)
+
+		_, stats = getStats("?include_archived=true")
+		assert.Equal(t, int64(6), stats.Created)
+		assert.Equal(t, int64(3), stats.Completed)
+	})
+
+	t.Run("Completing a task records when it was completed", func(t *testing.T) {
+		var pending models.Task
+		database.DB.Where("title = ?", "Pending").First(&pending)
+		update := func(completed bool) {
+			jsonValue, _ := json.Marshal(map[string]bool{"completed": completed})
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/tasks/%d", pending.ID), bytes.NewBuffer(jsonValue))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			pending = models.Task{}
+			database.DB.Where("title = ?", "Pending").First(&pending)
+		}
+
+		update(true)
+		if assert.NotNil(t, pending.CompletedAt) {
+			assert.WithinDuration(t, time.Now(), *pending.CompletedAt, time.Minute)
+		}
+		from := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		_, stats := getStats("?from=" + from)
+		assert.Equal(t, services.TaskStatsCounts{Completed: 1}, stats.TaskStatsCounts)
+
+		update(false)
+		assert.Nil(t, pending.CompletedAt)
+	})
+
+	t.Run("Invalid ranges", func(t *testing.T) {
+		code, _ := getStats("?from=yesterday")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = getStats("?from=2024-12-31T00:00:00Z&to=2024-12-01T00:00:00Z")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestGetTaskPermissions(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "hashed"}
+	database.DB.Create(&owner)
+	ownTask := models.Task{Title: "Mine", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&ownTask)
+	sharedTask := models.Task{Title: "Shared", Type: models.TaskTypeCasa, UserID: owner.ID}
+	database.DB.Create(&sharedTask)
+	database.DB.Create(&models.TaskSharedWith{TaskID: sharedTask.ID, UserID: user.ID})
+
+	getTask := func(id uint) models.Task {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/tasks/%d", id), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var task models.Task
+		json.Unmarshal(w.Body.Bytes(), &task)
+		return task
+	}
+
+	t.Run("Owner can do everything", func(t *testing.T) {
+		task := getTask(ownTask.ID)
+		assert.Equal(t, &models.TaskPermissions{
+			CanEdit: true, CanComplete: true, CanDelete: true, CanComment: true, IsOwner: true,
+		}, task.Permissions)
+	})
+
+	t.Run("Shared user can only complete and comment", func(t *testing.T) {
+		task := getTask(sharedTask.ID)
+		assert.Equal(t, &models.TaskPermissions{
+			CanComplete: true, CanComment: true, IsShared: true,
+		}, task.Permissions)
+	})
+
+	t.Run("Comments closed on completed and archived task", func(t *testing.T) {
+		// The test router locks comments on closed tasks, as the comment service does
+		closedTask := models.Task{Title: "Closed", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true, Archived: true}
+		database.DB.Create(&closedTask)
+
+		task := getTask(closedTask.ID)
+		assert.Equal(t, &models.TaskPermissions{
+			CanEdit: true, CanComplete: true, CanDelete: true, IsOwner: true,
+		}, task.Permissions)
+
+		body, _ := json.Marshal(CreateCommentRequest{Content: "Late", TaskID: closedTask.ID})
+		req, _ := http.NewRequest("POST", "/api/v1/comments", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Completed task that isn't archived takes comments", func(t *testing.T) {
+		completedTask := models.Task{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true}
+		database.DB.Create(&completedTask)
+
+		task := getTask(completedTask.ID)
+		assert.True(t, task.Permissions.CanComment)
+	})
+}
+
+func TestHeadTask(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+	ownTask := models.Task{Title: "Mine", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&ownTask)
+	sharedTask := models.Task{Title: "Shared", Type: models.TaskTypeCasa, UserID: other.ID}
+	database.DB.Create(&sharedTask)
+	database.DB.Create(&models.TaskSharedWith{TaskID: sharedTask.ID, UserID: user.ID})
+	privateTask := models.Task{Title: "Private", Type: models.TaskTypeCasa, UserID: other.ID}
+	database.DB.Create(&privateTask)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"Own task", fmt.Sprintf("/api/v1/tasks/%d", ownTask.ID), http.StatusOK},
+		{"Shared task", fmt.Sprintf("/api/v1/tasks/%d", sharedTask.ID), http.StatusOK},
+		{"Inaccessible task", fmt.Sprintf("/api/v1/tasks/%d", privateTask.ID), http.StatusForbidden},
+		{"Missing task", "/api/v1/tasks/99999", http.StatusNotFound},
+		{"Invalid ID", "/api/v1/tasks/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("HEAD", tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Empty(t, w.Body.String())
+		})
+	}
+}
+
+func TestInaccessibleTaskPolicy(t *testing.T) {
+	setupTestDB()
+	owner, _ := createTestUser(t)
+	stranger := models.User{Username: "stranger", Email: "stranger@example.com", Password: "hashed"}
+	database.DB.Create(&stranger)
+	shared := models.User{Username: "shared", Email: "shared@example.com", Password: "hashed"}
+	database.DB.Create(&shared)
+
+	task := models.Task{Title: "Private", Type: models.TaskTypeCasa, UserID: owner.ID}
+	database.DB.Create(&task)
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: shared.ID})
+	comment := models.Comment{Content: "Owner note", TaskID: task.ID, UserID: owner.ID}
+	database.DB.Create(&comment)
+
+	title := "Changed"
+	for _, tt := range []struct {
+		hide   bool
+		status int
+	}{
+		{false, http.StatusForbidden},
+		{true, http.StatusNotFound},
+	} {
+		t.Run(fmt.Sprintf("hideInaccessible=%v", tt.hide), func(t *testing.T) {
+			taskService := services.NewTaskService(repositories.NewTaskRepository(), repositories.NewUserRepository(), repositories.NewTagRepository(), nil, nil, services.TaskServiceOptions{MaxTags: 3, ClearCompletedArchive: false, ListAllMax: 5, HideInaccessible: tt.hide})
+			commentService := services.NewCommentService(repositories.NewCommentRepository(), repositories.NewTaskRepository(), nil, nil, services.CommentServiceOptions{MaxPinned: 2, LockClosed: false, NotifyAll: false, HideInaccessible: tt.hide, MaxLength: 5000})
+
+			_, err := taskService.GetByID(context.Background(), stranger.ID, task.ID)
+			assert.Equal(t, tt.status, errorStatus(err))
+			assert.Equal(t, tt.status, errorStatus(taskService.CheckAccess(context.Background(), stranger.ID, task.ID)))
+			_, err = taskService.Update(context.Background(), stranger.ID, task.ID, &services.UpdateTaskRequest{Title: &title})
+			assert.Equal(t, tt.status, errorStatus(err))
+			assert.Equal(t, tt.status, errorStatus(taskService.Delete(context.Background(), stranger.ID, task.ID)))
+			_, err = taskService.SetArchived(context.Background(), stranger.ID, task.ID, true)
+			assert.Equal(t, tt.status, errorStatus(err))
+			assert.Equal(t, tt.status, errorStatus(taskService.ShareTask(context.Background(), stranger.ID, task.ID, []uint{stranger.ID})))
+
+			_, err = commentService.Create(stranger.ID, &services.CreateCommentRequest{Content: "Hi", TaskID: task.ID})
+			assert.Equal(t, tt.status, errorStatus(err))
+			_, err = commentService.GetByTaskID(stranger.ID, task.ID, false)
+			assert.Equal(t, tt.status, errorStatus(err))
+			_, err = commentService.Update(stranger.ID, comment.ID, &services.UpdateCommentRequest{Content: &title})
+			assert.Equal(t, tt.status, errorStatus(err))
+			assert.Equal(t, tt.status, errorStatus(commentService.Delete(stranger.ID, comment.ID)))
+
+			// Users who can see the task are still told they aren't allowed
+			assert.Equal(t, http.StatusForbidden, errorStatus(taskService.Delete(context.Background(), shared.ID, task.ID)))
+			assert.Equal(t, http.StatusForbidden, errorStatus(commentService.Delete(shared.ID, comment.ID)))
+		})
+	}
+}
+
+func TestTaskQueriesUseRequestContext(t *testing.T) {
+	setupTestDB()
+	user, _ := createTestUser(t)
+	task := models.Task{Title: "Mine", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+
+	taskService := services.NewTaskService(repositories.NewTaskRepository(), repositories.NewUserRepository(), repositories.NewTagRepository(), nil, nil, services.TaskServiceOptions{MaxTags: 3, ClearCompletedArchive: false, ListAllMax: 5, HideInaccessible: false})
+
+	_, err := taskService.GetByID(context.Background(), user.ID, task.ID)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = taskService.GetByUserID(ctx, user.ID, &services.TaskFilters{})
+	assert.Error(t, err, "a cancelled request shouldn't run its queries")
+}
+
+func TestUpdateTask(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	task := models.Task{
+		Title:  "Original Title",
+		Type:   models.TaskTypeCasa,
+		UserID: user.ID,
+	}
+	database.DB.Create(&task)
+
+	t.Run("Update task successfully", func(t *testing.T) {
+		newTitle := "Updated Title"
+		completed := true
+		reqBody := UpdateTaskRequest{
+			Title:     &newTitle,
+			Completed: &completed,
+		}
+		jsonValue, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID), bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var updatedTask models.Task
+		json.Unmarshal(w.Body.Bytes(), &updatedTask)
+		assert.Equal(t, "Updated Title", updatedTask.Title)
+		assert.True(t, updatedTask.Completed)
+		if assert.NotNil(t, updatedTask.UpdatedBy) {
+			assert.Equal(t, user.ID, *updatedTask.UpdatedBy)
+		}
+	})
+
+	t.Run("Title is trimmed and can't be blanked", func(t *testing.T) {
+		update := func(title string) *httptest.ResponseRecorder {
+			jsonValue, _ := json.Marshal(UpdateTaskRequest{Title: &title})
+			req, _ := http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID), bytes.NewBuffer(jsonValue))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusOK, update("  Trimmed title ").Code)
+		assert.Equal(t, http.StatusBadRequest, update(" \t ").Code)
+
+		var reloaded models.Task
+		database.DB.First(&reloaded, task.ID)
+		assert.Equal(t, "Trimmed title", reloaded.Title)
+	})
+
+	t.Run("Invalid type gets the same message as the service", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID), bytes.NewBufferString(`{"type": "escola"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response ValidationErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, services.InvalidTaskTypeMessage, response.Fields["type"])
+
+		taskService := services.NewTaskService(repositories.NewTaskRepository(), repositories.NewUserRepository(), repositories.NewTagRepository(), nil, nil, services.TaskServiceOptions{MaxTags: 3, ClearCompletedArchive: false, ListAllMax: 5, HideInaccessible: false})
+		invalidType := models.TaskType("escola")
+		_, err := taskService.Update(context.Background(), user.ID, task.ID, &services.UpdateTaskRequest{Type: &invalidType})
+		assert.EqualError(t, err, services.InvalidTaskTypeMessage)
+	})
+
+	t.Run("Missing tags are listed in the error", func(t *testing.T) {
+		tag := models.Tag{Name: "casa", UserID: user.ID}
+		database.DB.Create(&tag)
+
+		tagIDs := []uint{tag.ID, 9998, 9999}
+		jsonValue, _ := json.Marshal(UpdateTaskRequest{TagIDs: &tagIDs})
+
+		req, _ := http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID), bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "Tags not found or don't belong to the user: 9998, 9999", response.Message)
+	})
+
+	t.Run("Response reflects the final tag set", func(t *testing.T) {
+		tag := models.Tag{Name: "final", UserID: user.ID}
+		database.DB.Create(&tag)
+		url := "/api/v1/tasks/" + fmt.Sprintf("%d", task.ID)
+
+		update := func(tagIDs []uint) *httptest.ResponseRecorder {
+			jsonValue, _ := json.Marshal(UpdateTaskRequest{TagIDs: &tagIDs})
+			req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(jsonValue))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		// Repeated IDs are applied once
+		w := update([]uint{tag.ID, tag.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var updatedTask models.Task
+		json.Unmarshal(w.Body.Bytes(), &updatedTask)
+		if assert.Len(t, updatedTask.Tags, 1) {
+			assert.Equal(t, tag.ID, updatedTask.Tags[0].ID)
+		}
+
+		// Repeated missing IDs are named once
+		w = update([]uint{9997, 9997})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "Tags not found or don't belong to the user: 9997", response.Message)
+
+		// Removing every tag returns an empty list rather than omitting it
+		w = update([]uint{})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		assert.Equal(t, []interface{}{}, body["tags"])
+	})
+
+	t.Run("Non-owner with access can only change completion", func(t *testing.T) {
+		owner := models.User{
+			Username: "owner",
+			Email:    "owner@example.com",
+			Password: "hashed",
+		}
+		database.DB.Create(&owner)
+
+		// Task assigned to another user by the test user, who keeps access to it
+		assignedTask := models.Task{
+			Title:      "Assigned Task",
+			Type:       models.TaskTypeCasa,
+			UserID:     owner.ID,
+			AssignedBy: &user.ID,
+		}
+		database.DB.Create(&assignedTask)
+		url := "/api/v1/tasks/" + fmt.Sprintf("%d", assignedTask.ID)
+
+		newTitle := "Renamed"
+		jsonValue, _ := json.Marshal(UpdateTaskRequest{Title: &newTitle})
+		req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		completed := true
+		jsonValue, _ = json.Marshal(UpdateTaskRequest{Completed: &completed})
+		req, _ = http.NewRequest("PUT", url, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var updatedTask models.Task
+		json.Unmarshal(w.Body.Bytes(), &updatedTask)
+		assert.Equal(t, "Assigned Task", updatedTask.Title)
+		assert.True(t, updatedTask.Completed)
+		if assert.NotNil(t, updatedTask.UpdatedByUser) {
+			assert.Equal(t, user.ID, updatedTask.UpdatedByUser.ID)
+			assert.Equal(t, user.Username, updatedTask.UpdatedByUser.Username)
+		}
+	})
+}
+
+func TestTagOnlyChangesBumpUpdatedAt(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	tag := models.Tag{Name: "Q1", Color: "#3B82F6", UserID: user.ID}
+	database.DB.Create(&tag)
+	task := models.Task{Title: "Retag me", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+
+	lastWeek := time.Now().UTC().AddDate(0, 0, -7)
+	resetUpdatedAt := func() {
+		database.DB.Model(&models.Task{}).Where("id = ?", task.ID).UpdateColumns(map[string]interface{}{"updated_at": lastWeek, "updated_by": nil})
+	}
+	reload := func() models.Task {
+		var reloaded models.Task
+		database.DB.First(&reloaded, task.ID)
+		return reloaded
+	}
+	send := func(method, path string, body interface{}) {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	t.Run("Updating only the tags", func(t *testing.T) {
+		resetUpdatedAt()
+		send("PUT", fmt.Sprintf("/api/v1/tasks/%d", task.ID), UpdateTaskRequest{TagIDs: &[]uint{tag.ID}})
+
+		reloaded := reload()
+		assert.True(t, reloaded.UpdatedAt.After(lastWeek.Add(time.Hour)), "updated_at should advance, got %v", reloaded.UpdatedAt)
+	})
+
+	t.Run("Removing a tag from several tasks", func(t *testing.T) {
+		resetUpdatedAt()
+		send("POST", fmt.Sprintf("/api/v1/tags/%d/unassign", tag.ID), TagTasksRequest{TaskIDs: []uint{task.ID}})
+
+		reloaded := reload()
+		assert.True(t, reloaded.UpdatedAt.After(lastWeek.Add(time.Hour)), "updated_at should advance, got %v", reloaded.UpdatedAt)
+		if assert.NotNil(t, reloaded.UpdatedBy) {
+			assert.Equal(t, user.ID, *reloaded.UpdatedBy)
+		}
+	})
+
+	t.Run("Adding a tag to several tasks", func(t *testing.T) {
+		resetUpdatedAt()
+		send("POST", fmt.Sprintf("/api/v1/tags/%d/assign", tag.ID), TagTasksRequest{TaskIDs: []uint{task.ID}})
+
+		reloaded := reload()
+		assert.True(t, reloaded.UpdatedAt.After(lastWeek.Add(time.Hour)), "updated_at should advance, got %v", reloaded.UpdatedAt)
+	})
+}
+
+func TestUpdateTaskTagsOnlyWritesChanges(t *testing.T) {
+	db := setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	kept := models.Tag{Name: "Kept", Color: "#3B82F6", UserID: user.ID}
+	dropped := models.Tag{Name: "Dropped", Color: "#EF4444", UserID: user.ID}
+	added := models.Tag{Name: "Added", Color: "#10B981", UserID: user.ID}
+	database.DB.Create(&kept)
+	database.DB.Create(&dropped)
+	database.DB.Create(&added)
+	task := models.Task{Title: "Retag me", Type: models.TaskTypeCasa, UserID: user.ID, Tags: []models.Tag{kept, dropped}}
+	database.DB.Create(&task)
+
+	// Count the rows the update writes to task_tags and tags, including rows sent in an upsert
+	var inserted, deleted, tagWrites int
+	db.Callback().Create().After("gorm:create").Register("test:count_task_tag_inserts", func(tx *gorm.DB) {
+		rows := 1
+		if tx.Statement.ReflectValue.Kind() == reflect.Slice {
+			rows = tx.Statement.ReflectValue.Len()
+		}
+		switch tx.Statement.Table {
+		case "task_tags":
+			inserted += rows
+		case "tags":
+			tagWrites += rows
+		}
+	})
+	db.Callback().Delete().After("gorm:delete").Register("test:count_task_tag_deletes", func(tx *gorm.DB) {
+		if tx.Statement.Table == "task_tags" {
+			deleted += int(tx.RowsAffected)
+		}
+	})
+
+	jsonValue, _ := json.Marshal(UpdateTaskRequest{TagIDs: &[]uint{kept.ID, added.ID}})
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/tasks/%d", task.ID), bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, 1, inserted, "only the added tag should be inserted")
+	assert.Equal(t, 1, deleted, "only the removed tag should be deleted")
+	assert.Zero(t, tagWrites, "the tags themselves should not be written")
+
+	var tagIDs []uint
+	database.DB.Model(&models.TaskTag{}).Where("task_id = ?", task.ID).Pluck("tag_id", &tagIDs)
+	assert.ElementsMatch(t, []uint{kept.ID, added.ID}, tagIDs)
+}
+
+func TestArchiveTask(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	task := models.Task{
+		Title:     "Task to archive",
+		Type:      models.TaskTypeCasa,
+		UserID:    user.ID,
+		Completed: true,
+	}
+	database.DB.Create(&task)
+
+	listTotal := func(query string) float64 {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["total"].(float64)
+	}
+
+	req, _ := http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID)+"/archive", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(0), listTotal(""))
+	assert.Equal(t, float64(1), listTotal("?include_archived=true"))
+
+	req, _ = http.NewRequest("PUT", "/api/v1/tasks/"+fmt.Sprintf("%d", task.ID)+"/unarchive", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(1), listTotal(""))
+}
+
+func TestDeleteTask(t *testing.T) {
 	setupTestDB()
 	router := setupTestRouter("test-secret")
 	user, token := createTestUser(t)
@@ -234,3 +1818,266 @@ func TestDeleteTask(t *testing.T) {
 	assert.Error(t, result.Error)
 }
 
+
+func TestDeleteTaskRemovesRelatedRecords(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	collaborator := models.User{Username: "collaborator", Email: "collaborator@example.com", Password: "hashed"}
+	database.DB.Create(&collaborator)
+	tag := models.Tag{Name: "Work", Color: "#3B82F6", UserID: user.ID}
+	database.DB.Create(&tag)
+
+	task := models.Task{Title: "Related task", Type: models.TaskTypeTrabalho, UserID: user.ID, Tags: []models.Tag{tag}}
+	database.DB.Create(&task)
+	otherTask := models.Task{Title: "Other task", Type: models.TaskTypeTrabalho, UserID: user.ID, Tags: []models.Tag{tag}}
+	database.DB.Create(&otherTask)
+	database.DB.Create(&models.TaskSharedWith{TaskID: task.ID, UserID: collaborator.ID})
+	database.DB.Create(&models.TaskSharedWith{TaskID: otherTask.ID, UserID: collaborator.ID})
+	database.DB.Create(&models.Comment{Content: "First", TaskID: task.ID, UserID: user.ID})
+	database.DB.Create(&models.Comment{Content: "Second", TaskID: task.ID, UserID: collaborator.ID})
+	database.DB.Create(&models.Notification{UserID: user.ID, TaskID: task.ID, Type: models.NotificationTypeDueToday, Channel: models.NotificationChannelEmail, SentAt: time.Now()})
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/tasks/%d", task.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	countRows := func(table string, taskID uint) int64 {
+		var count int64
+		database.DB.Table(table).Where("task_id = ?", taskID).Count(&count)
+		return count
+	}
+	assert.Equal(t, int64(0), countRows("task_shared_with", task.ID))
+	assert.Equal(t, int64(0), countRows("task_tags", task.ID))
+
+	var comments, notifications int64
+	database.DB.Model(&models.Comment{}).Where("task_id = ?", task.ID).Count(&comments)
+	database.DB.Model(&models.Notification{}).Where("task_id = ?", task.ID).Count(&notifications)
+	assert.Equal(t, int64(0), comments)
+	assert.Equal(t, int64(0), notifications)
+
+	// The rows are soft-deleted, not erased
+	database.DB.Unscoped().Model(&models.Comment{}).Where("task_id = ?", task.ID).Count(&comments)
+	assert.Equal(t, int64(2), comments)
+
+	// Other tasks keep their shares and tags
+	assert.Equal(t, int64(1), countRows("task_shared_with", otherTask.ID))
+	assert.Equal(t, int64(1), countRows("task_tags", otherTask.ID))
+}
+
+func TestTaskTagLimit(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret") // Allows 3 tags per task
+	user, token := createTestUser(t)
+
+	tagIDs := make([]uint, 4)
+	for i := range tagIDs {
+		tag := models.Tag{Name: fmt.Sprintf("tag-%d", i), Color: "#3B82F6", UserID: user.ID}
+		database.DB.Create(&tag)
+		tagIDs[i] = tag.ID
+	}
+
+	send := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Creating a task with too many tags fails", func(t *testing.T) {
+		w := send("POST", "/api/v1/tasks", CreateTaskRequest{Title: "Tagged", Type: models.TaskTypeCasa, TagIDs: tagIDs})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 3 tags")
+	})
+
+	t.Run("Repeated tag IDs count once", func(t *testing.T) {
+		ids := []uint{tagIDs[0], tagIDs[1], tagIDs[2], tagIDs[0]}
+		w := send("POST", "/api/v1/tasks", CreateTaskRequest{Title: "Tagged", Type: models.TaskTypeCasa, TagIDs: ids})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Updating a task with too many tags fails", func(t *testing.T) {
+		task := models.Task{Title: "Task", Type: models.TaskTypeCasa, UserID: user.ID}
+		database.DB.Create(&task)
+
+		w := send("PUT", fmt.Sprintf("/api/v1/tasks/%d", task.ID), UpdateTaskRequest{TagIDs: &tagIDs})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var tagCount int64
+		database.DB.Table("task_tags").Where("task_id = ?", task.ID).Count(&tagCount)
+		assert.Equal(t, int64(0), tagCount)
+	})
+}
+
+func TestShareTaskNeverSharesWithOwner(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	assignee := models.User{Username: "assignee", Email: "assignee@example.com", Password: "hashed"}
+	database.DB.Create(&assignee)
+	assigneeToken, _ := utils.GenerateToken(assignee.ID, assignee.Username, "test-secret")
+
+	// The test user assigns a task to the assignee, who shares it back with both of them
+	jsonValue, _ := json.Marshal(CreateTaskRequest{Title: "Assigned", Type: models.TaskTypeTrabalho, UserID: &assignee.ID})
+	req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var task models.Task
+	json.Unmarshal(w.Body.Bytes(), &task)
+
+	jsonValue, _ = json.Marshal(ShareTaskRequest{UserIDs: []uint{user.ID, assignee.ID}})
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/tasks/%d/share", task.ID), bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+assigneeToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Sharing with the owner is a no-op even when called directly
+	assert.NoError(t, repositories.NewTaskRepository().AddSharedWith(context.Background(), task.ID, assignee.ID))
+
+	var shares []models.TaskSharedWith
+	database.DB.Where("task_id = ?", task.ID).Find(&shares)
+	if assert.Len(t, shares, 1) {
+		assert.Equal(t, user.ID, shares[0].UserID)
+	}
+
+	for _, listToken := range []string{token, assigneeToken} {
+		req, _ = http.NewRequest("GET", "/api/v1/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+listToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response.Tasks, 1)
+	}
+}
+
+func TestDeleteCompletedTasks(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+	done := models.Task{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true}
+	database.DB.Create(&done)
+	database.DB.Create(&models.Comment{Content: "Finished", TaskID: done.ID, UserID: user.ID})
+	archivedDone := models.Task{Title: "Archived done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true, Archived: true}
+	database.DB.Create(&archivedDone)
+	pending := models.Task{Title: "Pending", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&pending)
+	sharedDone := models.Task{Title: "Shared done", Type: models.TaskTypeCasa, UserID: other.ID, Completed: true}
+	database.DB.Create(&sharedDone)
+	database.DB.Create(&models.TaskSharedWith{TaskID: sharedDone.ID, UserID: user.ID})
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/tasks/completed", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result services.ClearCompletedResult
+	json.Unmarshal(w.Body.Bytes(), &result)
+	assert.Equal(t, int64(2), result.Affected)
+	assert.Empty(t, result.Failed)
+	assert.False(t, result.Archived)
+
+	var remaining []models.Task
+	database.DB.Order("id ASC").Find(&remaining)
+	if assert.Len(t, remaining, 2) {
+		assert.Equal(t, pending.ID, remaining[0].ID)
+		assert.Equal(t, sharedDone.ID, remaining[1].ID)
+	}
+
+	// Deleted tasks are soft-deleted with their comments
+	var deleted, comments int64
+	database.DB.Unscoped().Model(&models.Task{}).Where("deleted_at IS NOT NULL").Count(&deleted)
+	database.DB.Model(&models.Comment{}).Where("task_id = ?", done.ID).Count(&comments)
+	assert.Equal(t, int64(2), deleted)
+	assert.Equal(t, int64(0), comments)
+
+	t.Run("Archive mode archives instead of deleting", func(t *testing.T) {
+		completed := models.Task{Title: "Completed", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true}
+		database.DB.Create(&completed)
+
+		taskService := services.NewTaskService(repositories.NewTaskRepository(), repositories.NewUserRepository(), repositories.NewTagRepository(), nil, nil, services.TaskServiceOptions{MaxTags: 3, ClearCompletedArchive: true, ListAllMax: 5, HideInaccessible: false})
+		result, err := taskService.DeleteCompleted(context.Background(), user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), result.Affected)
+		assert.True(t, result.Archived)
+
+		var archived models.Task
+		assert.NoError(t, database.DB.First(&archived, completed.ID).Error)
+		assert.True(t, archived.Archived)
+	})
+}
+
+func TestDateOnlyDueDates(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	// A due date at midnight (server time) is a date-only task, due during that whole day
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	yesterdayStart := todayStart.AddDate(0, 0, -1)
+	tomorrowStart := todayStart.AddDate(0, 0, 1)
+	aMinuteAgo := now.Add(-time.Minute)
+	for _, task := range []models.Task{
+		{Title: "Due today", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &todayStart},
+		{Title: "Due yesterday", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &yesterdayStart},
+		{Title: "Due tomorrow", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &tomorrowStart},
+		{Title: "Due a minute ago", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &aMinuteAgo},
+	} {
+		database.DB.Create(&task)
+	}
+
+	titles := func(query string) []string {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		titles := []string{}
+		for _, task := range response.Tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	t.Run("A date-only task isn't overdue until its day ends", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Due yesterday", "Due a minute ago"}, titles("?period=overdue"))
+	})
+
+	t.Run("Overdue counts follow the same rule", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/tasks?include_counts=true", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response services.PaginatedTasksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if assert.NotNil(t, response.Counts) {
+			assert.Equal(t, int64(2), response.Counts.Overdue)
+		}
+	})
+
+	t.Run("A task due at midnight belongs to that day's period", func(t *testing.T) {
+		today := titles("?period=today")
+		assert.Contains(t, today, "Due today")
+		assert.NotContains(t, today, "Due tomorrow")
+		assert.NotContains(t, today, "Due yesterday")
+	})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"todo-go-backend/internal/errors"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/notifications"
+	"todo-go-backend/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// telegramLinkCodeTTL is how long a generated link code stays valid
+const telegramLinkCodeTTL = 15 * time.Minute
+
+// telegramLinkCodeAlphabet avoids ambiguous characters (0/O, 1/I)
+const telegramLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// TelegramHandler manages Telegram bot integration handlers
+type TelegramHandler struct {
+	telegramService *notifications.TelegramService
+	linkRepo        repositories.TelegramLinkRepository
+	userRepo        repositories.UserRepository
+	webhookSecret   string
+}
+
+// NewTelegramHandler creates a new instance of TelegramHandler
+func NewTelegramHandler(telegramService *notifications.TelegramService, linkRepo repositories.TelegramLinkRepository, userRepo repositories.UserRepository, webhookSecret string) *TelegramHandler {
+	return &TelegramHandler{
+		telegramService: telegramService,
+		linkRepo:        linkRepo,
+		userRepo:        userRepo,
+		webhookSecret:   webhookSecret,
+	}
+}
+
+// TelegramLinkCodeResponse represents a generated Telegram link code
+type TelegramLinkCodeResponse struct {
+	Code      string    `json:"code" example:"K7P2XQ9M"`
+	Command   string    `json:"command" example:"/start K7P2XQ9M"` // Message the user must send to the bot
+	ExpiresAt time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+}
+
+// CreateLinkCode generates a code the user sends to the bot to link their Telegram chat
+// @Summary      Generate Telegram link code
+// @Description  Generates a short-lived code. Sending "/start <code>" to the bot links the Telegram chat to the authenticated user, without copying the chat ID manually. Generating a new code invalidates previous ones.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      201      {object}  TelegramLinkCodeResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /users/telegram-link-code [post]
+func (h *TelegramHandler) CreateLinkCode(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	code, err := generateTelegramLinkCode()
+	if err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	// Only the most recent code of a user is valid
+	if err := h.linkRepo.DeleteByUserID(userID); err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	linkCode := &models.TelegramLinkCode{
+		Code:      code,
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(telegramLinkCodeTTL),
+	}
+	if err := h.linkRepo.Create(linkCode); err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, TelegramLinkCodeResponse{
+		Code:      linkCode.Code,
+		Command:   "/start " + linkCode.Code,
+		ExpiresAt: linkCode.ExpiresAt,
+	})
+}
+
+// Webhook receives updates from the Telegram Bot API
+// @Summary      Telegram webhook
+// @Description  Receives Telegram update payloads (register it with the Bot API setWebhook method). A "/start <code>" message links the sender's chat to the account that generated the code. When TELEGRAM_WEBHOOK_SECRET is set, the X-Telegram-Bot-Api-Secret-Token header must match it.
+// @Tags         telegram
+// @Accept       json
+// @Produce      json
+// @Param        update  body      object  true  "Telegram update"
+// @Success      200     {object}  map[string]bool
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Router       /telegram/webhook [post]
+func (h *TelegramHandler) Webhook(c *gin.Context) {
+	if h.webhookSecret != "" {
+		provided := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(h.webhookSecret)) != 1 {
+			handleError(c, errors.NewUnauthorizedError())
+			return
+		}
+	}
+
+	update, err := h.telegramService.ParseUpdate(c.Request.Body)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid Telegram update payload"))
+		return
+	}
+
+	// Telegram retries deliveries that don't get a 200, so every handled update is acknowledged
+	command, argument, chatID, ok := h.telegramService.ParseCommand(update)
+	if !ok || command != "/start" {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+		return
+	}
+
+	// Messages sent inside a forum topic link (and reply to) that topic
+	var threadID *int
+	if update.Message.IsTopicMessage && update.Message.MessageThreadID != 0 {
+		id := update.Message.MessageThreadID
+		threadID = &id
+	}
+
+	h.reply(chatID, threadID, h.linkChat(argument, chatID, threadID))
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// linkChat links the chat to the user owning the code and returns the reply for the bot
+func (h *TelegramHandler) linkChat(code string, chatID string, threadID *int) string {
+	if code == "" {
+		return "👋 Olá! Para receber notificações, gere um código de vinculação no app e envie <b>/start CÓDIGO</b> aqui."
+	}
+
+	linkCode, err := h.linkRepo.FindValidByCode(strings.ToUpper(code), time.Now())
+	if err != nil {
+		return "❌ Código inválido ou expirado. Gere um novo código no app e tente novamente."
+	}
+
+	user, err := h.userRepo.FindByID(linkCode.UserID)
+	if err != nil {
+		return "❌ Usuário não encontrado. Gere um novo código no app e tente novamente."
+	}
+
+	user.TelegramChatID = &chatID
+	user.TelegramThreadID = threadID
+	if err := h.userRepo.Update(user); err != nil {
+		log.Printf("Failed to link telegram chat %s to user %d: %v", chatID, user.ID, err)
+		return "❌ Não foi possível vincular sua conta. Tente novamente mais tarde."
+	}
+
+	if err := h.linkRepo.DeleteByUserID(user.ID); err != nil {
+		log.Printf("Failed to delete telegram link codes of user %d: %v", user.ID, err)
+	}
+
+	log.Printf("Telegram chat %s linked to user %d", chatID, user.ID)
+	return "✅ Conta <b>" + html.EscapeString(user.Username) + "</b> vinculada! Você receberá as notificações das suas tarefas aqui."
+}
+
+// reply sends a message back to the chat, logging failures since the webhook must still succeed
+func (h *TelegramHandler) reply(chatID string, threadID *int, message string) {
+	if err := h.telegramService.SendMessage(chatID, threadID, message); err != nil {
+		log.Printf("Failed to reply to telegram chat %s: %v", chatID, err)
+	}
+}
+
+// generateTelegramLinkCode generates a random 8 character code
+func generateTelegramLinkCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = telegramLinkCodeAlphabet[int(b)%len(telegramLinkCodeAlphabet)]
+	}
+	return string(buf), nil
+}
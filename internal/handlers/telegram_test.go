@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegramLinkCode(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	createCode := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/users/telegram-link-code", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	webhook := func(secret, text string, chatID int64) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"update_id":1,"message":{"message_id":1,"chat":{"id":%d,"type":"private"},"text":%q}}`, chatID, text)
+		req, _ := http.NewRequest("POST", "/api/v1/telegram/webhook", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if secret != "" {
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	linkedChatID := func() *string {
+		var reloaded models.User
+		database.DB.First(&reloaded, user.ID)
+		return reloaded.TelegramChatID
+	}
+
+	t.Run("Generating a code requires authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, createCode("").Code)
+	})
+
+	t.Run("A new code replaces the previous one", func(t *testing.T) {
+		w := createCode(token)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var first TelegramLinkCodeResponse
+		json.Unmarshal(w.Body.Bytes(), &first)
+		assert.Len(t, first.Code, 8)
+		assert.Equal(t, "/start "+first.Code, first.Command)
+		assert.WithinDuration(t, time.Now().Add(telegramLinkCodeTTL), first.ExpiresAt, time.Minute)
+
+		var second TelegramLinkCodeResponse
+		json.Unmarshal(createCode(token).Body.Bytes(), &second)
+		var count int64
+		database.DB.Model(&models.TelegramLinkCode{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		assert.Equal(t, http.StatusOK, webhook(testTelegramWebhookSecret, "/start "+first.Code, 111).Code)
+		assert.Nil(t, linkedChatID())
+	})
+
+	t.Run("A wrong or missing secret is rejected", func(t *testing.T) {
+		var code TelegramLinkCodeResponse
+		json.Unmarshal(createCode(token).Body.Bytes(), &code)
+
+		assert.Equal(t, http.StatusUnauthorized, webhook("wrong-secret", "/start "+code.Code, 222).Code)
+		assert.Equal(t, http.StatusUnauthorized, webhook("", "/start "+code.Code, 222).Code)
+		assert.Nil(t, linkedChatID())
+	})
+
+	t.Run("An expired code doesn't link the chat", func(t *testing.T) {
+		var code TelegramLinkCodeResponse
+		json.Unmarshal(createCode(token).Body.Bytes(), &code)
+		database.DB.Model(&models.TelegramLinkCode{}).Where("code = ?", code.Code).Update("expires_at", time.Now().UTC().Add(-time.Minute))
+
+		assert.Equal(t, http.StatusOK, webhook(testTelegramWebhookSecret, "/start "+code.Code, 333).Code)
+		assert.Nil(t, linkedChatID())
+	})
+
+	t.Run("The webhook redeems a valid code once", func(t *testing.T) {
+		var code TelegramLinkCodeResponse
+		json.Unmarshal(createCode(token).Body.Bytes(), &code)
+
+		// Codes are matched case-insensitively, as users may type them in lowercase
+		assert.Equal(t, http.StatusOK, webhook(testTelegramWebhookSecret, "/start@todo_bot "+strings.ToLower(code.Code), 444).Code)
+		if chatID := linkedChatID(); assert.NotNil(t, chatID) {
+			assert.Equal(t, "444", *chatID)
+		}
+
+		var count int64
+		database.DB.Model(&models.TelegramLinkCode{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		// The used code can't link another chat
+		webhook(testTelegramWebhookSecret, "/start "+code.Code, 555)
+		assert.Equal(t, "444", *linkedChatID())
+	})
+
+	t.Run("Updates without a command are acknowledged", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, webhook(testTelegramWebhookSecret, "hello", 666).Code)
+	})
+
+	t.Run("An invalid payload is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/v1/telegram/webhook", strings.NewReader("not json"))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", testTelegramWebhookSecret)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"time"
+	"todo-go-backend/internal/auth"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/middleware"
 	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/notifications"
+	"todo-go-backend/internal/realtime"
 	"todo-go-backend/internal/repositories"
 	"todo-go-backend/internal/services"
 
@@ -34,7 +37,7 @@ func setupTestDB() *gorm.DB {
 		// Usar MySQL (como na pipeline CI)
 		// Adicionar parâmetros para melhorar robustez da conexão
 		dsn := fmt.Sprintf(
-			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&timeout=10s&readTimeout=10s&writeTimeout=10s",
+			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&timeout=10s&readTimeout=10s&writeTimeout=10s",
 			dbUser,
 			dbPassword,
 			dbHost,
@@ -45,7 +48,7 @@ func setupTestDB() *gorm.DB {
 		// Tentar conectar com retry
 		var lastErr error
 		for i := 0; i < 5; i++ {
-			db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+			db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{NowFunc: database.NowUTC})
 			if err == nil {
 				break
 			}
@@ -71,7 +74,7 @@ func setupTestDB() *gorm.DB {
 		// Remover o arquivo após os testes (será recriado pelo SQLite)
 		os.Remove(tmpFile.Name())
 
-		db, err = gorm.Open(sqlite.Open(tmpFile.Name()), &gorm.Config{})
+		db, err = gorm.Open(sqlite.Open(tmpFile.Name()+"?_loc=UTC"), &gorm.Config{NowFunc: database.NowUTC})
 		if err != nil {
 			panic("Failed to connect to SQLite test database. SQLite requires CGO to be enabled. " +
 				"Either enable CGO (set CGO_ENABLED=1) or configure MySQL environment variables " +
@@ -80,7 +83,7 @@ func setupTestDB() *gorm.DB {
 		}
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Task{}, &models.Tag{}, &models.Comment{}, &models.Notification{})
+	err = db.AutoMigrate(&models.User{}, &models.Task{}, &models.Tag{}, &models.Comment{}, &models.Notification{}, &models.TelegramLinkCode{})
 	if err != nil {
 		panic("Failed to migrate test database: " + err.Error())
 	}
@@ -91,6 +94,7 @@ func setupTestDB() *gorm.DB {
 	if dbHost != "" {
 		// MySQL - desabilitar foreign keys temporariamente
 		db.Exec("SET FOREIGN_KEY_CHECKS = 0")
+		db.Exec("TRUNCATE TABLE telegram_link_codes")
 		db.Exec("TRUNCATE TABLE notifications")
 		db.Exec("TRUNCATE TABLE comments")
 		db.Exec("TRUNCATE TABLE task_tags")
@@ -100,6 +104,7 @@ func setupTestDB() *gorm.DB {
 		db.Exec("SET FOREIGN_KEY_CHECKS = 1")
 	} else {
 		// SQLite - usar DELETE (TRUNCATE não funciona em SQLite)
+		db.Exec("DELETE FROM telegram_link_codes")
 		db.Exec("DELETE FROM notifications")
 		db.Exec("DELETE FROM comments")
 		db.Exec("DELETE FROM task_tags")
@@ -112,6 +117,9 @@ func setupTestDB() *gorm.DB {
 	return db
 }
 
+// testTelegramWebhookSecret é o segredo do webhook do Telegram no router de teste
+const testTelegramWebhookSecret = "test-webhook-secret"
+
 // setupTestRouter cria um router de teste com handlers configurados
 func setupTestRouter(jwtSecret string) *gin.Engine {
 	gin.SetMode(gin.TestMode)
@@ -122,30 +130,87 @@ func setupTestRouter(jwtSecret string) *gin.Engine {
 	taskRepo := repositories.NewTaskRepository()
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, jwtSecret)
+	hub := realtime.NewHub()
+	jwtKeys := auth.NewKeySet("default", jwtSecret, nil)
+	authService := services.NewAuthService(userRepo, jwtKeys)
 	tagRepo := repositories.NewTagRepository()
-	taskService := services.NewTaskService(taskRepo, userRepo, tagRepo)
+	taskService := services.NewTaskService(taskRepo, userRepo, tagRepo, hub, nil, services.TaskServiceOptions{MaxTags: 3, ClearCompletedArchive: false, ListAllMax: 5, HideInaccessible: false, CommentLockClosed: true})
+	tagService := services.NewTagService(tagRepo, nil)
+	commentRepo := repositories.NewCommentRepository()
+	commentService := services.NewCommentService(commentRepo, taskRepo, hub, nil, services.CommentServiceOptions{MaxPinned: 2, LockClosed: true, NotifyAll: false, HideInaccessible: false, MaxLength: 5000})
+	dataExportService := services.NewDataExportService(userRepo, taskRepo, tagRepo, commentRepo)
 
 	// Initialize handlers
 	authHandler := NewAuthHandler(authService)
 	taskHandler := NewTaskHandler(taskService)
+	tagHandler := NewTagHandler(tagService)
+	commentHandler := NewCommentHandler(commentService)
+	realtimeHandler := NewRealtimeHandler(hub)
+	userHandler := NewUserHandler(nil, nil, userRepo, repositories.NewNotificationRepository(), taskRepo, dataExportService)
+	// Without a bot token the bot replies fail and are only logged
+	telegramHandler := NewTelegramHandler(notifications.NewTelegramService("", ""), repositories.NewTelegramLinkRepository(), userRepo, testTelegramWebhookSecret)
 
 	// Public routes
 	api := router.Group("/api/v1")
 	{
 		api.POST("/auth/register", authHandler.Register)
 		api.POST("/auth/login", authHandler.Login)
+		api.GET("/ws", middleware.QueryTokenAuthMiddleware(jwtKeys, true), realtimeHandler.WebSocket)
+		api.GET("/events", middleware.QueryTokenAuthMiddleware(jwtKeys, true), realtimeHandler.Events)
+		api.POST("/telegram/webhook", telegramHandler.Webhook)
 	}
 
 	// Protected routes
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(jwtSecret))
+	protected.Use(middleware.AuthMiddleware(jwtKeys, true))
 	{
+		protected.GET("/auth/me", authHandler.Me)
+
 		protected.GET("/tasks", taskHandler.GetTasks)
+		protected.GET("/tasks/assigned", taskHandler.GetAssignedTasks)
+		protected.GET("/tasks/assigned-to-me", taskHandler.GetAssignedToMeTasks)
+		protected.GET("/tasks/assigned-to-me/count", taskHandler.CountAssignedToMeTasks)
 		protected.GET("/tasks/:id", taskHandler.GetTask)
+		protected.HEAD("/tasks/:id", taskHandler.HeadTask)
 		protected.POST("/tasks", taskHandler.CreateTask)
+		protected.POST("/tasks/batch", taskHandler.CreateTasksBatch)
+		protected.GET("/tasks/upcoming", taskHandler.GetUpcomingTasks)
+		protected.GET("/tasks/stats", taskHandler.GetTaskStats)
+		protected.GET("/tasks/export", taskHandler.ExportTasks)
+		protected.POST("/tasks/import", taskHandler.ImportTasks)
 		protected.PUT("/tasks/:id", taskHandler.UpdateTask)
+		protected.DELETE("/tasks/completed", taskHandler.DeleteCompletedTasks)
 		protected.DELETE("/tasks/:id", taskHandler.DeleteTask)
+		protected.PUT("/tasks/:id/archive", taskHandler.ArchiveTask)
+		protected.PUT("/tasks/:id/unarchive", taskHandler.UnarchiveTask)
+		protected.POST("/tasks/:id/share", taskHandler.ShareTask)
+		protected.GET("/tasks/:id/comments", commentHandler.GetComments)
+		protected.GET("/tags", tagHandler.GetTags)
+		protected.POST("/tags", tagHandler.CreateTag)
+		protected.POST("/tags/ensure", tagHandler.EnsureTag)
+		protected.GET("/tags/:id/tasks", taskHandler.GetTagTasks)
+		protected.POST("/tags/:id/assign", taskHandler.AssignTag)
+		protected.POST("/tags/:id/unassign", taskHandler.UnassignTag)
+		protected.GET("/tags/:id", tagHandler.GetTag)
+		protected.PUT("/tags/:id", tagHandler.UpdateTag)
+		protected.DELETE("/tags/:id", tagHandler.DeleteTag)
+		protected.POST("/comments", commentHandler.CreateComment)
+		protected.GET("/comments/:id", commentHandler.GetComment)
+		protected.PUT("/comments/:id", commentHandler.UpdateComment)
+		protected.DELETE("/comments/:id", commentHandler.DeleteComment)
+		protected.PUT("/comments/:id/pin", commentHandler.PinComment)
+		protected.PUT("/comments/:id/unpin", commentHandler.UnpinComment)
+		protected.GET("/users", userHandler.GetUsers)
+		protected.GET("/users/assignable", userHandler.GetAssignableUsers)
+		protected.PUT("/users/notification-channels", userHandler.UpdatePreferredChannels)
+		protected.PUT("/users/notification-types", userHandler.UpdateNotificationTypes)
+		protected.PUT("/users/language", userHandler.UpdateLanguage)
+		protected.POST("/users/telegram-link-code", telegramHandler.CreateLinkCode)
+		protected.GET("/users/me/export", userHandler.ExportData)
+		protected.GET("/notifications", userHandler.GetNotifications)
+		protected.POST("/notifications/test", userHandler.TestNotifications)
+		protected.GET("/notifications/:id", userHandler.GetNotification)
+		protected.POST("/notifications/:id/resend", userHandler.ResendNotification)
 	}
 
 	return router
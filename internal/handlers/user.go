@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/errors"
 	"todo-go-backend/internal/models"
 	"todo-go-backend/internal/notifications"
 	"todo-go-backend/internal/repositories"
+	"todo-go-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,20 +20,29 @@ import (
 // UserHandler manages user handlers
 type UserHandler struct {
 	notificationService *notifications.NotificationService
+	scheduler          *notifications.Scheduler
 	userRepo           repositories.UserRepository
+	notificationRepo   repositories.NotificationRepository
+	taskRepo           repositories.TaskRepository
+	dataExportService  services.DataExportService
 }
 
 // NewUserHandler creates a new instance of UserHandler
-func NewUserHandler(notificationService *notifications.NotificationService, userRepo repositories.UserRepository) *UserHandler {
+func NewUserHandler(notificationService *notifications.NotificationService, scheduler *notifications.Scheduler, userRepo repositories.UserRepository, notificationRepo repositories.NotificationRepository, taskRepo repositories.TaskRepository, dataExportService services.DataExportService) *UserHandler {
 	return &UserHandler{
 		notificationService: notificationService,
+		scheduler:          scheduler,
 		userRepo:           userRepo,
+		notificationRepo:   notificationRepo,
+		taskRepo:           taskRepo,
+		dataExportService:  dataExportService,
 	}
 }
 
 // UpdateTelegramChatIDRequest represents a request to update Telegram chat ID
 type UpdateTelegramChatIDRequest struct {
-	TelegramChatID *string `json:"telegram_chat_id" example:"123456789"` // Telegram chat ID (must be numeric string, null to remove). User must send a message to the bot first.
+	TelegramChatID   *string `json:"telegram_chat_id" example:"123456789"`                                // Telegram chat ID (must be numeric string, null to remove). User must send a message to the bot first.
+	TelegramThreadID *int    `json:"telegram_message_thread_id" binding:"omitempty,min=1" example:"42"` // Optional: topic of a supergroup to post in (null = general thread)
 }
 
 // UpdateNotificationsEnabledRequest represents a request to update notifications enabled
@@ -36,9 +50,34 @@ type UpdateNotificationsEnabledRequest struct {
 	NotificationsEnabled *bool `json:"notifications_enabled" example:"true"`
 }
 
+// UpdatePreferredChannelsRequest represents a request to update the order of notification channels
+type UpdatePreferredChannelsRequest struct {
+	PreferredChannels string `json:"preferred_channels" binding:"required" example:"telegram,email"` // "both", or channels in order of preference: "telegram,email", "email,telegram", "telegram" or "email"
+}
+
+// UpdateLanguageRequest represents a request to change the language of the user's notifications
+type UpdateLanguageRequest struct {
+	Language string `json:"language" binding:"required,language" example:"en"` // One of models.Languages: pt (default), en or es
+}
+
+// UpdateNotificationTypesRequest represents a request to turn due date reminders on or off by type.
+// Omitted fields are left unchanged.
+type UpdateNotificationTypesRequest struct {
+	NotifyDueSoon  *bool `json:"notify_due_soon" example:"false"`
+	NotifyDueToday *bool `json:"notify_due_today" example:"true"`
+	NotifyOverdue  *bool `json:"notify_overdue" example:"true"`
+}
+
+// NotificationTypesResponse reports which due date reminders the user receives
+type NotificationTypesResponse struct {
+	NotifyDueSoon  bool `json:"notify_due_soon" example:"false"`
+	NotifyDueToday bool `json:"notify_due_today" example:"true"`
+	NotifyOverdue  bool `json:"notify_overdue" example:"true"`
+}
+
 // UpdateTelegramChatID updates user's Telegram chat ID
 // @Summary      Update Telegram chat ID
-// @Description  Updates the Telegram chat ID for the authenticated user to receive notifications
+// @Description  Updates the Telegram chat ID for the authenticated user to receive notifications. For supergroups with topics, telegram_message_thread_id routes notifications to a specific topic.
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -46,6 +85,7 @@ type UpdateNotificationsEnabledRequest struct {
 // @Param        request  body      UpdateTelegramChatIDRequest  true  "Telegram chat ID"
 // @Success      200      {object}  SuccessResponse
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /users/telegram-chat-id [put]
@@ -86,6 +126,10 @@ func (h *UserHandler) UpdateTelegramChatID(c *gin.Context) {
 	}
 
 	user.TelegramChatID = req.TelegramChatID
+	user.TelegramThreadID = req.TelegramThreadID
+	if req.TelegramChatID == nil {
+		user.TelegramThreadID = nil
+	}
 	if err := database.DB.Save(&user).Error; err != nil {
 		handleError(c, errors.NewInternalServerError(err))
 		return
@@ -111,6 +155,7 @@ func (h *UserHandler) UpdateTelegramChatID(c *gin.Context) {
 // @Param        request  body      UpdateNotificationsEnabledRequest  true  "Notifications enabled"
 // @Success      200      {object}  SuccessResponse
 // @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /users/notifications-enabled [put]
@@ -148,18 +193,299 @@ func (h *UserHandler) UpdateNotificationsEnabled(c *gin.Context) {
 	handleSuccess(c, http.StatusOK, message, nil)
 }
 
+// UpdateNotificationTypes turns due date reminders on or off by type
+// @Summary      Update notification types
+// @Description  Turns the due soon, due today and overdue reminders on or off individually. Omitted fields are left unchanged. The reminders are only sent while notifications_enabled is true.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      UpdateNotificationTypesRequest  true  "Notification types"
+// @Success      200      {object}  SuccessResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /users/notification-types [put]
+func (h *UserHandler) UpdateNotificationTypes(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req UpdateNotificationTypesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	if req.NotifyDueSoon == nil && req.NotifyDueToday == nil && req.NotifyOverdue == nil {
+		handleError(c, errors.NewInvalidInputError("At least one of notify_due_soon, notify_due_today or notify_overdue is required"))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		handleError(c, errors.NewUserNotFoundError())
+		return
+	}
+
+	if req.NotifyDueSoon != nil {
+		user.NotifyDueSoon = *req.NotifyDueSoon
+	}
+	if req.NotifyDueToday != nil {
+		user.NotifyDueToday = *req.NotifyDueToday
+	}
+	if req.NotifyOverdue != nil {
+		user.NotifyOverdue = *req.NotifyOverdue
+	}
+	if err := database.DB.Save(&user).Error; err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	handleSuccess(c, http.StatusOK, "Notification types updated", NotificationTypesResponse{
+		NotifyDueSoon:  user.NotifyDueSoon,
+		NotifyDueToday: user.NotifyDueToday,
+		NotifyOverdue:  user.NotifyOverdue,
+	})
+}
+
+// UpdateLanguage sets the language of the user's notifications
+// @Summary      Update notification language
+// @Description  Sets the language the email and Telegram notifications are written in: pt (Portuguese, the default), en (English) or es (Spanish).
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      UpdateLanguageRequest  true  "Language"
+// @Success      200      {object}  SuccessResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /users/language [put]
+func (h *UserHandler) UpdateLanguage(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req UpdateLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		handleError(c, errors.NewUserNotFoundError())
+		return
+	}
+
+	user.Language = models.Language(req.Language)
+	if err := database.DB.Save(&user).Error; err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	handleSuccess(c, http.StatusOK, "Language updated", gin.H{"language": user.Language})
+}
+
+// UpdatePreferredChannels updates the order in which notification channels are used
+// @Summary      Update preferred notification channels
+// @Description  Sets how notifications are delivered. "both" sends every notification to email and Telegram. A comma-separated list sends it to the first channel, falling back to the next one only if it fails (e.g. "telegram,email"); a single channel uses only that one.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      UpdatePreferredChannelsRequest  true  "Preferred channels"
+// @Success      200      {object}  SuccessResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /users/notification-channels [put]
+func (h *UserHandler) UpdatePreferredChannels(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req UpdatePreferredChannelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleValidationError(c, err)
+		return
+	}
+
+	channels, fallback, err := notifications.ParsePreferredChannels(req.PreferredChannels)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid preferred_channels: use \"both\" or a comma-separated list of email and telegram"))
+		return
+	}
+	preferred := models.PreferredChannelsBoth
+	if fallback {
+		names := make([]string, len(channels))
+		for i, channel := range channels {
+			names[i] = string(channel)
+		}
+		preferred = strings.Join(names, ",")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		handleError(c, errors.NewUserNotFoundError())
+		return
+	}
+
+	user.PreferredChannels = preferred
+	if err := database.DB.Save(&user).Error; err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	handleSuccess(c, http.StatusOK, "Preferred channels updated", gin.H{"preferred_channels": preferred})
+}
+
+// GetNotification returns one notification sent to the user
+// @Summary      Get a notification
+// @Description  Returns a notification sent to the authenticated user with its task, e.g. to open it from a link in the message. The task is null when it was deleted or the user no longer has access to it. Notifications sent to other users are reported as not found.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Notification ID"
+// @Success      200  {object}  NotificationDetail
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /notifications/{id} [get]
+func (h *UserHandler) GetNotification(c *gin.Context) {
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid notification ID"))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	notification, err := h.notificationRepo.FindByIDAndUserID(uint(notificationID), userID)
+	if err != nil {
+		handleError(c, errors.NewNotificationNotFoundError())
+		return
+	}
+
+	detail := NotificationDetail{
+		ID:        notification.ID,
+		TaskID:    notification.TaskID,
+		CommentID: notification.CommentID,
+		Type:      notification.Type,
+		Channel:   notification.Channel,
+		SentAt:    notification.SentAt,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+	}
+	// The task may have been unshared from the user since the notification was sent
+	if notification.Task.ID != 0 {
+		canAccess, err := h.taskRepo.UserCanAccessTask(c.Request.Context(), notification.Task.ID, userID)
+		if err != nil {
+			handleError(c, errors.NewInternalServerError(err))
+			return
+		}
+		if canAccess {
+			detail.Task = &notification.Task
+		}
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// ResendNotification sends a notification to the user again
+// @Summary      Resend a notification
+// @Description  Sends a notification again to the authenticated user, with the same task, type and channel, e.g. after deleting the email. The resent notification is recorded as a new one; if recording fails after the message was sent, it is still returned, with id 0. Only notifications of existing, not completed tasks the user still has access to can be resent.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Notification ID"
+// @Success      200  {object}  NotificationHistoryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      502  {object}  ErrorResponse
+// @Router       /notifications/{id}/resend [post]
+func (h *UserHandler) ResendNotification(c *gin.Context) {
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		handleError(c, errors.NewInvalidInputError("Invalid notification ID"))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	notification, err := h.notificationRepo.FindByIDAndUserID(uint(notificationID), userID)
+	if err != nil {
+		handleError(c, errors.NewNotificationNotFoundError())
+		return
+	}
+	if notification.Task.ID == 0 {
+		handleError(c, errors.NewTaskNotFoundError())
+		return
+	}
+	// As in GetNotification, a task the user lost access to is not shown, nor sent again
+	canAccess, err := h.taskRepo.UserCanAccessTask(c.Request.Context(), notification.Task.ID, userID)
+	if err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+	if !canAccess {
+		handleError(c, errors.NewTaskNotFoundError())
+		return
+	}
+	if notification.Task.Completed {
+		handleError(c, errors.NewInvalidInputError("Cannot resend a notification for a completed task"))
+		return
+	}
+
+	resent, err := h.notificationService.Resend(notification)
+	if err != nil {
+		handleError(c, errors.NewAppError(err, "Failed to resend notification: "+err.Error(), http.StatusBadGateway))
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationHistoryItem{
+		ID:        resent.ID,
+		TaskID:    resent.TaskID,
+		TaskTitle: notification.Task.Title,
+		CommentID: resent.CommentID,
+		Type:      resent.Type,
+		Channel:   resent.Channel,
+		SentAt:    resent.SentAt,
+		Subject:   resent.Subject,
+		Body:      resent.Body,
+	})
+}
+
+// NotificationPreviewResponse lists the reminders a notification check would send to the user
+type NotificationPreviewResponse struct {
+	Message       string                              `json:"message" example:"Dry run completed, nothing was sent"`
+	Notifications []notifications.PlannedNotification `json:"notifications"`
+}
+
 // TestNotifications manually triggers notification check (for testing)
 // @Summary      Test notifications
-// @Description  Manually triggers a notification check. Useful for testing without waiting for the scheduler. Check server logs for detailed information.
+// @Description  Manually triggers a notification check. Useful for testing without waiting for the scheduler. Check server logs for detailed information. With dry_run=true nothing is sent or recorded: the response lists the reminders the check would send to the authenticated user and through which channels.
 // @Tags         notifications
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200      {object}  SuccessResponse
+// @Param        dry_run  query     bool  false  "Only preview the reminders that would be sent to the user"
+// @Success      200      {object}  SuccessResponse              "Notification check completed (without dry_run)"
+// @Success      200      {object}  NotificationPreviewResponse  "Reminders that would be sent (with dry_run=true)"
+// @Failure      400      {object}  ErrorResponse
 // @Failure      401      {object}  ErrorResponse
 // @Failure      500      {object}  ErrorResponse
 // @Router       /notifications/test [post]
 func (h *UserHandler) TestNotifications(c *gin.Context) {
+	dryRun, err := parseBoolQuery(c, "dry_run")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	if dryRun != nil && *dryRun {
+		h.previewNotifications(c)
+		return
+	}
+
 	if err := h.notificationService.CheckAndSendNotifications(); err != nil {
 		handleError(c, errors.NewInternalServerError(err))
 		return
@@ -168,9 +494,131 @@ func (h *UserHandler) TestNotifications(c *gin.Context) {
 	handleSuccess(c, http.StatusOK, "Notification check completed. Check server logs for details and verify your email/Telegram.", nil)
 }
 
+// previewNotifications returns the reminders a notification check would send to the current
+// user. Other users' reminders are left out so their tasks aren't exposed.
+func (h *UserHandler) previewNotifications(c *gin.Context) {
+	planned, err := h.notificationService.PreviewNotifications()
+	if err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	own := make([]notifications.PlannedNotification, 0, len(planned))
+	for _, notification := range planned {
+		if notification.UserID == userID {
+			own = append(own, notification)
+		}
+	}
+
+	c.JSON(http.StatusOK, NotificationPreviewResponse{
+		Message:       "Dry run completed, nothing was sent",
+		Notifications: own,
+	})
+}
+
+// TestChannelsResponse represents the per-channel result of a test message
+type TestChannelsResponse struct {
+	Message  string                        `json:"message" example:"Test message sent"`
+	Channels []notifications.ChannelResult `json:"channels"`
+}
+
+// TestChannels sends a test message to the current user's channels
+// @Summary      Test notification channels
+// @Description  Immediately sends a fixed test message to the authenticated user's email and Telegram, bypassing the due date logic and the dedupe table. Returns which channels succeeded or failed and why.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200      {object}  TestChannelsResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Router       /notifications/test-channel [post]
+func (h *UserHandler) TestChannels(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		handleError(c, errors.NewUserNotFoundError())
+		return
+	}
+
+	results := h.notificationService.SendTestMessage(user)
+
+	message := "Test message sent"
+	for _, result := range results {
+		if !result.Success {
+			message = "Test message sent with failures"
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, TestChannelsResponse{
+		Message:  message,
+		Channels: results,
+	})
+}
+
+// TestChannelResponse represents the result of a test message sent to one channel
+type TestChannelResponse struct {
+	Message string `json:"message" example:"Test message sent"`
+	notifications.ChannelResult
+}
+
+// TestEmail sends a test message to the current user's email only
+// @Summary      Test the email channel
+// @Description  Immediately sends a fixed test message to the authenticated user's email, independently of Telegram. When it fails, error holds the reason (e.g. "email service not configured").
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200      {object}  TestChannelResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Router       /notifications/test-email [post]
+func (h *UserHandler) TestEmail(c *gin.Context) {
+	h.testChannel(c, models.NotificationChannelEmail)
+}
+
+// TestTelegram sends a test message to the current user's Telegram only
+// @Summary      Test the Telegram channel
+// @Description  Immediately sends a fixed test message to the authenticated user's Telegram, independently of email. When it fails, error holds the reason (e.g. "chat not found").
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200      {object}  TestChannelResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Router       /notifications/test-telegram [post]
+func (h *UserHandler) TestTelegram(c *gin.Context) {
+	h.testChannel(c, models.NotificationChannelTelegram)
+}
+
+// testChannel sends a test message to one channel of the current user
+func (h *UserHandler) testChannel(c *gin.Context, channel models.NotificationChannel) {
+	user, err := h.userRepo.FindByID(c.GetUint("user_id"))
+	if err != nil {
+		handleError(c, errors.NewUserNotFoundError())
+		return
+	}
+
+	result := h.notificationService.SendTestMessageTo(user, channel)
+
+	message := "Test message sent"
+	if !result.Success {
+		message = "Test message failed"
+	}
+
+	c.JSON(http.StatusOK, TestChannelResponse{
+		Message:       message,
+		ChannelResult: result,
+	})
+}
+
 // GetNotificationDebugInfo returns debug information about notification configuration
 // @Summary      Get notification debug info
-// @Description  Returns debug information about the current user's notification settings and recent tasks
+// @Description  Returns debug information about the current user's notification settings and recent tasks, and whether the server sends notifications at all: notifications_enabled is false when due date reminders are turned off (NOTIFICATIONS_ENABLED=false or paused by an admin), and email_configured/telegram_configured tell whether the channels have their server settings.
 // @Tags         notifications
 // @Accept       json
 // @Produce      json
@@ -202,13 +650,21 @@ func (h *UserHandler) GetNotificationDebugInfo(c *gin.Context) {
 		Limit(10).
 		Find(&notifications)
 
+	channels := h.notificationService.ChannelsConfigured()
+
 	debugInfo := map[string]interface{}{
+		"server": map[string]interface{}{
+			"notifications_enabled": h.scheduler.Status().Running,
+			"email_configured":      channels[models.NotificationChannelEmail],
+			"telegram_configured":   channels[models.NotificationChannelTelegram],
+		},
 		"user": map[string]interface{}{
-			"id":                    user.ID,
-			"username":              user.Username,
-			"email":                 user.Email,
-			"notifications_enabled": user.NotificationsEnabled,
-			"telegram_chat_id":      user.TelegramChatID,
+			"id":                         user.ID,
+			"username":                   user.Username,
+			"email":                      user.Email,
+			"notifications_enabled":      user.NotificationsEnabled,
+			"telegram_chat_id":           user.TelegramChatID,
+			"telegram_message_thread_id": user.TelegramThreadID,
 		},
 		"tasks_count": len(tasks),
 		"tasks":       tasks,
@@ -219,6 +675,146 @@ func (h *UserHandler) GetNotificationDebugInfo(c *gin.Context) {
 	handleSuccess(c, http.StatusOK, "Debug information retrieved", debugInfo)
 }
 
+// NotificationHistoryItem is a notification sent to the user
+type NotificationHistoryItem struct {
+	ID        uint                       `json:"id" example:"1"`
+	TaskID    uint                       `json:"task_id" example:"12"`
+	TaskTitle string                     `json:"task_title" example:"Pay bills"`
+	CommentID *uint                      `json:"comment_id,omitempty" example:"3"`
+	Type      models.NotificationType    `json:"type" example:"due_today"`
+	Channel   models.NotificationChannel `json:"channel" example:"telegram"`
+	SentAt    time.Time                  `json:"sent_at" example:"2024-12-01T09:00:00Z"`
+	Subject   string                     `json:"subject,omitempty" example:"Tarefa vence hoje: Pay bills"`
+	Body      string                     `json:"body,omitempty"` // Empty for notifications sent before the content was recorded
+}
+
+// NotificationDetail is a notification sent to the user, with its task
+type NotificationDetail struct {
+	ID        uint                       `json:"id" example:"1"`
+	TaskID    uint                       `json:"task_id" example:"12"`
+	CommentID *uint                      `json:"comment_id,omitempty" example:"3"`
+	Type      models.NotificationType    `json:"type" example:"due_today"`
+	Channel   models.NotificationChannel `json:"channel" example:"telegram"`
+	SentAt    time.Time                  `json:"sent_at" example:"2024-12-01T09:00:00Z"`
+	Subject   string                     `json:"subject,omitempty" example:"Tarefa vence hoje: Pay bills"`
+	Body      string                     `json:"body,omitempty"` // Empty for notifications sent before the content was recorded
+	Task      *models.Task               `json:"task"` // null when the task has since been deleted or the user lost access to it
+}
+
+// PaginatedNotificationsResponse represents a paginated response for the notification history
+type PaginatedNotificationsResponse struct {
+	Notifications []NotificationHistoryItem `json:"notifications"`
+	Total         int64                     `json:"total"`
+	Page          int                       `json:"page"`
+	Limit         int                       `json:"limit"`
+	TotalPages    int                       `json:"total_pages"`
+}
+
+// GetNotifications lists the notifications sent to the user
+// @Summary      List my notifications
+// @Description  Returns the notifications sent to the authenticated user, newest first, with the title of the related task. Can be filtered by type, channel and the date they were sent.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page     query     int     false  "Page number (default: 1)"
+// @Param        limit    query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        type     query     string  false  "Filter by type"  Enums(due_soon, due_today, overdue, assigned, comment)
+// @Param        channel  query     string  false  "Filter by channel"  Enums(email, telegram)
+// @Param        from     query     string  false  "Sent at or after (ISO 8601 format)"
+// @Param        to       query     string  false  "Sent at or before (ISO 8601 format)"
+// @Success      200      {object}  PaginatedNotificationsResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /notifications [get]
+func (h *UserHandler) GetNotifications(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	page, limit, err := parseListPagination(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	filters := &repositories.NotificationFilters{
+		Type:    models.NotificationType(c.Query("type")),
+		Channel: models.NotificationChannel(c.Query("channel")),
+	}
+	switch filters.Type {
+	case "", models.NotificationTypeDueSoon, models.NotificationTypeDueToday, models.NotificationTypeOverdue, models.NotificationTypeAssigned, models.NotificationTypeComment:
+	default:
+		handleError(c, errors.NewInvalidInputError("Invalid type. Must be one of: due_soon, due_today, overdue, assigned, comment"))
+		return
+	}
+	switch filters.Channel {
+	case "", models.NotificationChannelEmail, models.NotificationChannelTelegram:
+	default:
+		handleError(c, errors.NewInvalidInputError("Invalid channel. Must be one of: email, telegram"))
+		return
+	}
+	if filters.From, err = parseTimeQuery(c, "from"); err != nil {
+		handleError(c, err)
+		return
+	}
+	if filters.To, err = parseTimeQuery(c, "to"); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sent, total, err := h.notificationRepo.FindByUserIDPaginated(userID, filters, page, limit)
+	if err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
+	}
+
+	items := make([]NotificationHistoryItem, len(sent))
+	for i, notification := range sent {
+		items[i] = NotificationHistoryItem{
+			ID:        notification.ID,
+			TaskID:    notification.TaskID,
+			TaskTitle: notification.Task.Title,
+			CommentID: notification.CommentID,
+			Type:      notification.Type,
+			Channel:   notification.Channel,
+			SentAt:    notification.SentAt,
+			Subject:   notification.Subject,
+			Body:      notification.Body,
+		}
+	}
+
+	// Calculate total pages
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	setPaginationHeaders(c, page, limit, total, totalPages)
+	c.JSON(http.StatusOK, PaginatedNotificationsResponse{
+		Notifications: items,
+		Total:         total,
+		Page:          page,
+		Limit:         limit,
+		TotalPages:    totalPages,
+	})
+}
+
+// parseTimeQuery reads an optional ISO 8601 query parameter; nil means it wasn't given
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.NewInvalidInputError("Invalid " + name + " parameter: use ISO 8601 format (e.g. 2024-12-01T00:00:00Z)")
+	}
+	return &parsed, nil
+}
+
 // PaginatedUsersResponse represents a paginated response for users
 type PaginatedUsersResponse struct {
 	Users      []models.User `json:"users"`
@@ -230,45 +826,105 @@ type PaginatedUsersResponse struct {
 
 // GetUsers lists all users in the system with pagination
 // @Summary      List users
-// @Description  Retrieves a paginated list of all users in the system. Returns only public information (id, username, email) for use in task assignment.
+// @Description  Retrieves a paginated list of all users in the system. Returns only public information (id, username, email) for use in task assignment. With q, only users whose username or email contains it are listed, ordered by username.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        page   query     int     false  "Page number (default: 1)"
 // @Param        limit  query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        q      query     string  false  "Search in username and email"
 // @Success      200    {object}  PaginatedUsersResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
 // @Failure      400    {object}  ErrorResponse
 // @Failure      401    {object}  ErrorResponse
 // @Failure      500    {object}  ErrorResponse
 // @Router       /users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	// Parse pagination parameters
-	page := 1
-	limit := 10
+	page, limit, err := parseListPagination(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
 
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	var users []models.User
+	var total int64
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		users, total, err = h.userRepo.SearchPaginated(q, page, limit)
+	} else {
+		users, total, err = h.userRepo.FindAllPaginated(page, limit)
+	}
+	if err != nil {
+		handleError(c, errors.NewInternalServerError(err))
+		return
 	}
 
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-			// Maximum limit is 100
-			if limit > 100 {
-				limit = 100
-			}
-		}
+	respondUsersPage(c, users, total, page, limit)
+}
+
+// GetAssignableUsers lists the users the authenticated user can assign tasks to
+// @Summary      List assignable users
+// @Description  Retrieves a paginated list of the users the authenticated user can assign tasks to, ordered by username, for the assignment picker. The caller is never listed. With collaborators=true, only users who share at least one task with the caller (as owner or shared user) are listed, instead of every user in the system. Returns only public information (id, username, email).
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page           query     int     false  "Page number (default: 1)"
+// @Param        limit          query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        q              query     string  false  "Search in username and email"
+// @Param        collaborators  query     bool    false  "Only users who share a task with the caller (default: false)"
+// @Success      200            {object}  PaginatedUsersResponse
+// @Header       200  {integer}  X-Total-Count  "Total number of items"
+// @Header       200  {integer}  X-Page         "Current page"
+// @Header       200  {integer}  X-Per-Page     "Items per page"
+// @Header       200  {string}   Link           "RFC 5988 links to the first, prev, next and last pages"
+// @Failure      400            {object}  ErrorResponse
+// @Failure      401            {object}  ErrorResponse
+// @Failure      500            {object}  ErrorResponse
+// @Router       /users/assignable [get]
+func (h *UserHandler) GetAssignableUsers(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	page, limit, err := parseListPagination(c)
+	if err != nil {
+		handleError(c, err)
+		return
 	}
+	collaboratorsOnly := c.Query("collaborators") == "true"
 
-	users, total, err := h.userRepo.FindAllPaginated(page, limit)
+	users, total, err := h.userRepo.FindAssignablePaginated(userID, collaboratorsOnly, strings.TrimSpace(c.Query("q")), page, limit)
 	if err != nil {
 		handleError(c, errors.NewInternalServerError(err))
 		return
 	}
 
+	respondUsersPage(c, users, total, page, limit)
+}
+
+// parseListPagination reads the page and limit query parameters of the user and notification lists, applying
+// the defaults (page 1, 10 per page) and the maximum of 100 per page
+func parseListPagination(c *gin.Context) (int, int, error) {
+	page, limit, err := parsePagination(c, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 10
+	}
+	// Maximum limit is 100
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit, nil
+}
+
+// respondUsersPage writes a page of users with the pagination envelope and headers
+func respondUsersPage(c *gin.Context, users []models.User, total int64, page, limit int) {
 	// Calculate total pages
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
 	if totalPages == 0 {
@@ -283,5 +939,35 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		TotalPages: totalPages,
 	}
 
+	setPaginationHeaders(c, page, limit, total, totalPages)
 	c.JSON(http.StatusOK, response)
 }
+
+// ExportData exports everything stored for the authenticated user
+// @Summary      Export my data
+// @Description  Returns a single JSON document (sent as an attachment) with the user's profile (without the password), notification settings, tags and every task they own (archived included) with its tag IDs and comments. Unlike GET /tasks/export, the document keeps IDs and is meant for data portability rather than re-import.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  services.DataExport
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /users/me/export [get]
+func (h *UserHandler) ExportData(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	export, err := h.dataExportService.Export(userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Encode straight to the response instead of building the whole document in memory first
+	c.Header("Content-Disposition", `attachment; filename="user-data.json"`)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := json.NewEncoder(c.Writer).Encode(export); err != nil {
+		log.Printf("Failed to write data export of user %d: %v", userID, err)
+	}
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUserData(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	tag := models.Tag{Name: "Work", Color: "#3B82F6", UserID: user.ID}
+	database.DB.Create(&tag)
+	task := models.Task{Title: "Exported task", Type: models.TaskTypeTrabalho, UserID: user.ID, Tags: []models.Tag{tag}}
+	database.DB.Create(&task)
+	archived := models.Task{Title: "Archived task", Type: models.TaskTypeCasa, UserID: user.ID, Archived: true}
+	database.DB.Create(&archived)
+	database.DB.Create(&models.Task{Title: "Someone else's task", Type: models.TaskTypeCasa, UserID: other.ID})
+	database.DB.Create(&models.Comment{Content: "Looks good", TaskID: task.ID, UserID: other.ID})
+
+	t.Run("Export requires authentication", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users/me/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Export contains the user's profile, tags, tasks and comments", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users/me/export", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+		assert.NotContains(t, strings.ToLower(w.Body.String()), "password")
+
+		var export services.DataExport
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+		assert.Equal(t, services.DataExportVersion, export.Version)
+		assert.Equal(t, user.Username, export.Profile.Username)
+		assert.True(t, export.NotificationSettings.NotificationsEnabled)
+		assert.Equal(t, models.LanguagePortuguese, export.NotificationSettings.Language)
+
+		assert.Len(t, export.Tags, 1)
+		assert.Equal(t, "Work", export.Tags[0].Name)
+
+		if assert.Len(t, export.Tasks, 2) {
+			assert.Equal(t, task.ID, export.Tasks[0].ID)
+			assert.Equal(t, []uint{tag.ID}, export.Tasks[0].TagIDs)
+			if assert.Len(t, export.Tasks[0].Comments, 1) {
+				assert.Equal(t, "other", export.Tasks[0].Comments[0].Author)
+				assert.Equal(t, "Looks good", export.Tasks[0].Comments[0].Content)
+			}
+			assert.True(t, export.Tasks[1].Archived)
+			assert.Empty(t, export.Tasks[1].Comments)
+		}
+	})
+}
+
+func TestSearchUsers(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	_, token := createTestUser(t)
+
+	database.DB.Create(&models.User{Username: "maria", Email: "maria@example.com", Password: "hashed"})
+	database.DB.Create(&models.User{Username: "joao", Email: "joao.mariano@example.com", Password: "hashed"})
+	database.DB.Create(&models.User{Username: "pedro", Email: "pedro@example.com", Password: "hashed"})
+
+	search := func(query string) PaginatedUsersResponse {
+		req, _ := http.NewRequest("GET", "/api/v1/users"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response PaginatedUsersResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	t.Run("Matches username or email", func(t *testing.T) {
+		response := search("?q=maria")
+		assert.Equal(t, int64(2), response.Total)
+		if assert.Len(t, response.Users, 2) {
+			assert.Equal(t, "joao", response.Users[0].Username)
+			assert.Equal(t, "maria", response.Users[1].Username)
+		}
+	})
+
+	t.Run("Only public fields are returned", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?q=pedro", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.NotContains(t, strings.ToLower(w.Body.String()), "password")
+		assert.NotContains(t, w.Body.String(), "hashed")
+	})
+
+	t.Run("Search is paginated", func(t *testing.T) {
+		response := search("?q=example.com&limit=2&page=2")
+		assert.Equal(t, int64(4), response.Total)
+		assert.Equal(t, 2, response.TotalPages)
+		assert.Len(t, response.Users, 2)
+	})
+
+	t.Run("Without q every user is listed", func(t *testing.T) {
+		assert.Equal(t, int64(4), search("").Total)
+	})
+
+	t.Run("Invalid pagination parameters are rejected", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?page=abc", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "Invalid page parameter: must be a positive integer", response.Message)
+	})
+}
+
+func TestGetAssignableUsers(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "hashed"}
+	assignee := models.User{Username: "assignee", Email: "assignee@example.com", Password: "hashed"}
+	coworker := models.User{Username: "coworker", Email: "coworker@example.com", Password: "hashed"}
+	stranger := models.User{Username: "stranger", Email: "stranger@example.com", Password: "hashed"}
+	for _, u := range []*models.User{&owner, &assignee, &coworker, &stranger} {
+		database.DB.Create(u)
+	}
+
+	// owner shares a task with the test user and the coworker; the test user shares one with the assignee
+	ownersTask := models.Task{Title: "Owner's", Type: models.TaskTypeCasa, UserID: owner.ID}
+	database.DB.Create(&ownersTask)
+	database.DB.Create(&models.TaskSharedWith{TaskID: ownersTask.ID, UserID: user.ID})
+	database.DB.Create(&models.TaskSharedWith{TaskID: ownersTask.ID, UserID: coworker.ID})
+	myTask := models.Task{Title: "Mine", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&myTask)
+	database.DB.Create(&models.TaskSharedWith{TaskID: myTask.ID, UserID: assignee.ID})
+
+	getUsernames := func(query string) []string {
+		req, _ := http.NewRequest("GET", "/api/v1/users/assignable"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response PaginatedUsersResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, int64(len(response.Users)), response.Total)
+		usernames := []string{}
+		for _, u := range response.Users {
+			usernames = append(usernames, u.Username)
+		}
+		return usernames
+	}
+
+	t.Run("Excludes the caller", func(t *testing.T) {
+		assert.Equal(t, []string{"assignee", "coworker", "owner", "stranger"}, getUsernames(""))
+	})
+
+	t.Run("Collaborators only, each listed once", func(t *testing.T) {
+		assert.Equal(t, []string{"assignee", "coworker", "owner"}, getUsernames("?collaborators=true"))
+	})
+
+	t.Run("Collaborators can be searched", func(t *testing.T) {
+		assert.Equal(t, []string{"owner"}, getUsernames("?collaborators=true&q=own"))
+	})
+
+	t.Run("Deleted tasks don't make collaborators", func(t *testing.T) {
+		database.DB.Delete(&ownersTask)
+		assert.Equal(t, []string{"assignee"}, getUsernames("?collaborators=true"))
+	})
+}
+
+func TestUpdatePreferredChannels(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/api/v1/users/notification-channels", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	stored := func() string {
+		var reloaded models.User
+		database.DB.First(&reloaded, user.ID)
+		return reloaded.PreferredChannels
+	}
+
+	t.Run("Defaults to both", func(t *testing.T) {
+		assert.Equal(t, models.PreferredChannelsBoth, stored())
+	})
+
+	t.Run("Stores a normalized channel order", func(t *testing.T) {
+		w := update(`{"preferred_channels": " Telegram , email "}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "telegram,email", stored())
+
+		w = update(`{"preferred_channels": "both"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.PreferredChannelsBoth, stored())
+	})
+
+	t.Run("Rejects unknown or repeated channels", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, update(`{"preferred_channels": "sms"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, update(`{"preferred_channels": "email,email"}`).Code)
+		assert.Equal(t, models.PreferredChannelsBoth, stored())
+	})
+}
+
+func TestUpdateNotificationTypes(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/api/v1/users/notification-types", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	reload := func() models.User {
+		var reloaded models.User
+		database.DB.First(&reloaded, user.ID)
+		return reloaded
+	}
+
+	t.Run("Every type is enabled by default", func(t *testing.T) {
+		reloaded := reload()
+		assert.True(t, reloaded.NotifyDueSoon)
+		assert.True(t, reloaded.NotifyDueToday)
+		assert.True(t, reloaded.NotifyOverdue)
+	})
+
+	t.Run("Only the given types change", func(t *testing.T) {
+		w := update(`{"notify_due_soon": false}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		reloaded := reload()
+		assert.False(t, reloaded.NotifyDueSoon)
+		assert.True(t, reloaded.NotifyDueToday)
+		assert.True(t, reloaded.NotifyOverdue)
+	})
+
+	t.Run("An empty update is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, update(`{}`).Code)
+	})
+}
+
+func TestUpdateLanguage(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/api/v1/users/language", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	stored := func() models.Language {
+		var reloaded models.User
+		database.DB.First(&reloaded, user.ID)
+		return reloaded.Language
+	}
+
+	t.Run("Defaults to Portuguese", func(t *testing.T) {
+		assert.Equal(t, models.LanguagePortuguese, stored())
+	})
+
+	t.Run("Stores a supported language", func(t *testing.T) {
+		w := update(`{"language": "en"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.LanguageEnglish, stored())
+	})
+
+	t.Run("Rejects unsupported languages", func(t *testing.T) {
+		w := update(`{"language": "fr"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "must be one of pt, en, es")
+		assert.Equal(t, models.LanguageEnglish, stored())
+	})
+}
+
+func TestGetNotifications(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	task := models.Task{Title: "Pay bills", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+	base := time.Date(2024, 12, 10, 9, 0, 0, 0, time.UTC)
+	database.DB.Create(&models.Notification{UserID: user.ID, TaskID: task.ID, Type: models.NotificationTypeDueSoon, Channel: models.NotificationChannelEmail, SentAt: base})
+	database.DB.Create(&models.Notification{UserID: user.ID, TaskID: task.ID, Type: models.NotificationTypeDueToday, Channel: models.NotificationChannelTelegram, SentAt: base.AddDate(0, 0, 1)})
+	database.DB.Create(&models.Notification{UserID: user.ID, TaskID: task.ID, Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelTelegram, SentAt: base.AddDate(0, 0, 2)})
+	database.DB.Create(&models.Notification{UserID: other.ID, TaskID: task.ID, Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelEmail, SentAt: base})
+
+	list := func(query string) (*httptest.ResponseRecorder, PaginatedNotificationsResponse) {
+		req, _ := http.NewRequest("GET", "/api/v1/notifications"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response PaginatedNotificationsResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("Lists only the user's notifications, newest first, with the task title", func(t *testing.T) {
+		w, response := list("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(3), response.Total)
+		if assert.Len(t, response.Notifications, 3) {
+			assert.Equal(t, models.NotificationTypeOverdue, response.Notifications[0].Type)
+			assert.Equal(t, "Pay bills", response.Notifications[0].TaskTitle)
+			assert.Equal(t, models.NotificationTypeDueSoon, response.Notifications[2].Type)
+		}
+	})
+
+	t.Run("Filters by type, channel and date range", func(t *testing.T) {
+		_, response := list("?channel=telegram")
+		assert.Equal(t, int64(2), response.Total)
+
+		_, response = list("?type=due_soon")
+		assert.Equal(t, int64(1), response.Total)
+
+		_, response = list("?from=2024-12-11T00:00:00Z&to=2024-12-11T23:59:59Z")
+		if assert.Len(t, response.Notifications, 1) {
+			assert.Equal(t, models.NotificationTypeDueToday, response.Notifications[0].Type)
+		}
+	})
+
+	t.Run("Paginates", func(t *testing.T) {
+		_, response := list("?limit=2&page=2")
+		assert.Len(t, response.Notifications, 1)
+		assert.Equal(t, 2, response.TotalPages)
+	})
+
+	t.Run("Rejects invalid filters", func(t *testing.T) {
+		w, _ := list("?type=weekly")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, _ = list("?channel=sms")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, _ = list("?from=yesterday")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetNotification(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	task := models.Task{Title: "Pay bills", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+	own := models.Notification{UserID: user.ID, TaskID: task.ID, Type: models.NotificationTypeDueToday, Channel: models.NotificationChannelTelegram, SentAt: time.Now()}
+	database.DB.Create(&own)
+	foreign := models.Notification{UserID: other.ID, TaskID: task.ID, Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelEmail, SentAt: time.Now()}
+	database.DB.Create(&foreign)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/notifications/"+id, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Returns the user's notification with its task", func(t *testing.T) {
+		w := get(fmt.Sprint(own.ID))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response NotificationDetail
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, own.ID, response.ID)
+		assert.Equal(t, models.NotificationTypeDueToday, response.Type)
+		if assert.NotNil(t, response.Task) {
+			assert.Equal(t, "Pay bills", response.Task.Title)
+		}
+	})
+
+	t.Run("Omits a task the user lost access to", func(t *testing.T) {
+		// The task was shared with the user when the notification was sent, then unshared
+		unshared := models.Task{Title: "Private now", Type: models.TaskTypeCasa, UserID: other.ID}
+		database.DB.Create(&unshared)
+		notification := models.Notification{UserID: user.ID, TaskID: unshared.ID, Type: models.NotificationTypeAssigned, Channel: models.NotificationChannelEmail, SentAt: time.Now()}
+		database.DB.Create(&notification)
+
+		w := get(fmt.Sprint(notification.ID))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Contains(t, response, "task")
+		assert.Nil(t, response["task"])
+		assert.NotContains(t, w.Body.String(), "Private now")
+	})
+
+	t.Run("Hides other users' notifications", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get(fmt.Sprint(foreign.ID)).Code)
+		assert.Equal(t, http.StatusNotFound, get("999999").Code)
+	})
+
+	t.Run("Rejects an invalid ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("abc").Code)
+	})
+}
+
+func TestResendNotification(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	user, token := createTestUser(t)
+	other := models.User{Username: "other", Email: "other@example.com", Password: "hashed"}
+	database.DB.Create(&other)
+
+	task := models.Task{Title: "Pay bills", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&task)
+	completed := models.Task{Title: "Done", Type: models.TaskTypeCasa, UserID: user.ID, Completed: true}
+	database.DB.Create(&completed)
+	deleted := models.Task{Title: "Gone", Type: models.TaskTypeCasa, UserID: user.ID}
+	database.DB.Create(&deleted)
+	database.DB.Delete(&deleted)
+
+	notify := func(userID, taskID uint) models.Notification {
+		notification := models.Notification{UserID: userID, TaskID: taskID, Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelEmail, SentAt: time.Now()}
+		database.DB.Create(&notification)
+		return notification
+	}
+
+	resend := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/notifications/"+id+"/resend", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Rejects other users' notifications", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, resend(fmt.Sprint(notify(other.ID, task.ID).ID)).Code)
+	})
+
+	t.Run("Rejects notifications of completed tasks", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, resend(fmt.Sprint(notify(user.ID, completed.ID).ID)).Code)
+	})
+
+	t.Run("Rejects notifications of deleted tasks", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, resend(fmt.Sprint(notify(user.ID, deleted.ID).ID)).Code)
+	})
+
+	t.Run("Rejects notifications of tasks the user lost access to", func(t *testing.T) {
+		unshared := models.Task{Title: "Private now", Type: models.TaskTypeCasa, UserID: other.ID}
+		database.DB.Create(&unshared)
+		assert.Equal(t, http.StatusNotFound, resend(fmt.Sprint(notify(user.ID, unshared.ID).ID)).Code)
+	})
+
+	t.Run("Rejects an invalid ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, resend("abc").Code)
+	})
+}
+
+func TestTestNotificationsDryRun(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter("test-secret")
+	_, token := createTestUser(t)
+
+	// An invalid value is rejected instead of running a real check
+	for _, dryRun := range []string{"yes", "TRUE1", "on"} {
+		req, _ := http.NewRequest("POST", "/api/v1/notifications/test?dry_run="+dryRun, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, dryRun)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/services"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report validation errors using the JSON field names clients send (e.g. "due_date", not "DueDate")
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+		// Task enums are checked against the models' lists, so the allowed values live in one place
+		v.RegisterValidation("task_type", func(fl validator.FieldLevel) bool {
+			return slices.Contains(models.TaskTypes, models.TaskType(fl.Field().String()))
+		})
+		v.RegisterValidation("priority", func(fl validator.FieldLevel) bool {
+			return slices.Contains(models.Priorities, models.Priority(fl.Field().String()))
+		})
+		v.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+			return slices.Contains(models.Languages, models.Language(fl.Field().String()))
+		})
+	}
+}
+
+// validationMessage builds a human-readable message for a failed validation rule
+func validationMessage(fieldErr validator.FieldError) string {
+	field := fieldErr.Field()
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "excludes":
+		return fmt.Sprintf("%s must not contain %q", field, param)
+	case "task_type":
+		return services.InvalidTaskTypeMessage
+	case "priority":
+		return services.InvalidPriorityMessage
+	case "language":
+		languages := make([]string, len(models.Languages))
+		for i, language := range models.Languages {
+			languages[i] = string(language)
+		}
+		return fmt.Sprintf("%s must be one of %s", field, strings.Join(languages, ", "))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", field, strings.Join(strings.Fields(param), ", "))
+	case "min":
+		switch fieldErr.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("%s must be at least %s characters long", field, param)
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("%s must contain at least %s items", field, param)
+		default:
+			return fmt.Sprintf("%s must be at least %s", field, param)
+		}
+	case "max":
+		switch fieldErr.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("%s must be at most %s characters long", field, param)
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("%s must contain at most %s items", field, param)
+		default:
+			return fmt.Sprintf("%s must be at most %s", field, param)
+		}
+	default:
+		return fmt.Sprintf("%s is invalid (%s)", field, fieldErr.Tag())
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware only lets through users listed in ADMIN_USERNAMES.
+// It must run after AuthMiddleware, which sets the username in the context.
+func AdminMiddleware(adminUsernames string) gin.HandlerFunc {
+	admins := make(map[string]bool)
+	for _, username := range parseStringList(adminUsernames) {
+		admins[username] = true
+	}
+
+	return func(c *gin.Context) {
+		if !admins[c.GetString("username")] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
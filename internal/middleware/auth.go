@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"todo-go-backend/internal/auth"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
 
@@ -16,7 +17,10 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware authenticates with the JWT from the Authorization header. With verifyUser set, the
+// user in the token must still exist, so tokens of deleted users stop working before they expire;
+// without it the token alone is trusted and no query is made per request.
+func AuthMiddleware(keys *auth.KeySet, verifyUser bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -33,33 +37,51 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-
-		// Parse and validate token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		authenticate(c, parts[1], keys, verifyUser)
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+// QueryTokenAuthMiddleware authenticates with the JWT from the "token" query parameter, falling back
+// to the Authorization header. Only meant for streaming endpoints, since browsers can't set headers
+// on WebSocket and EventSource connections.
+func QueryTokenAuthMiddleware(keys *auth.KeySet, verifyUser bool) gin.HandlerFunc {
+	header := AuthMiddleware(keys, verifyUser)
+	return func(c *gin.Context) {
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			header(c)
 			return
 		}
 
-		// Verify user still exists
-		var user models.User
-		if err := database.DB.First(&user, claims.UserID).Error; err != nil {
+		authenticate(c, tokenString, keys, verifyUser)
+	}
+}
+
+// authenticate validates the token and sets the user info in the context, aborting with 401 on failure
+func authenticate(c *gin.Context, tokenString string, keys *auth.KeySet, verifyUser bool) {
+	// Parse and validate token
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc)
+
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return
+	}
+
+	// Verify user still exists (soft-deleted users are excluded)
+	if verifyUser {
+		var count int64
+		if err := database.DB.Model(&models.User{}).Where("id = ?", claims.UserID).Count(&count).Error; err != nil || count == 0 {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 			c.Abort()
 			return
 		}
+	}
 
-		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+	// Set user info in context
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
 
-		c.Next()
-	}
+	c.Next()
 }
-
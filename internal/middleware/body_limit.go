@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodySizeLimitMiddleware caps the request body at maxBytes.
+// Requests declaring a larger Content-Length are rejected with 413 right away; bodies without a
+// (truthful) Content-Length are cut off by http.MaxBytesReader while the handler reads them.
+func BodySizeLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":   "Request body too large",
+				"message": "The request body exceeds the maximum allowed size",
+			})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodySizeLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(maxBytes int64) *gin.Engine {
+		router := gin.New()
+		router.Use(BodySizeLimitMiddleware(maxBytes))
+		router.POST("/echo", func(c *gin.Context) {
+			body, err := io.ReadAll(c.Request.Body)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.Status(http.StatusRequestEntityTooLarge)
+				return
+			}
+			c.String(http.StatusOK, string(body))
+		})
+		return router
+	}
+
+	post := func(router *gin.Engine, body string, chunked bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/echo", strings.NewReader(body))
+		if chunked {
+			// Unknown length, as with Transfer-Encoding: chunked
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	router := newRouter(10)
+
+	t.Run("A body within the limit reaches the handler", func(t *testing.T) {
+		w := post(router, "0123456789", false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0123456789", w.Body.String())
+	})
+
+	t.Run("A declared length over the limit is rejected before the handler", func(t *testing.T) {
+		w := post(router, "0123456789A", false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "Request body too large")
+	})
+
+	t.Run("A body of unknown length is cut off at the limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, post(router, "0123456789", true).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, post(router, "0123456789A", true).Code)
+	})
+
+	t.Run("A limit of 0 disables the check", func(t *testing.T) {
+		w := post(newRouter(0), strings.Repeat("x", 1<<16), true)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/http"
 	"strconv"
 	"strings"
 	"todo-go-backend/internal/config"
@@ -8,9 +9,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware creates a CORS middleware based on the provided configuration
+// CORSMiddleware creates a CORS middleware based on the provided configuration. routes lists the
+// router's routes (usually engine.Routes) so strict preflights to unknown routes aren't answered.
 // TEMPORARY: Currently configured to allow all origins for testing
-func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
+func CORSMiddleware(cfg *config.Config, routes func() gin.RoutesInfo) gin.HandlerFunc {
 	// Parse allowed origins
 	allowedOrigins := parseStringList(cfg.CORSAllowedOrigins)
 	if len(allowedOrigins) == 0 {
@@ -131,6 +133,10 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 		// Handle preflight requests
 		if c.Request.Method == "OPTIONS" {
+			if cfg.CORSStrictPreflight {
+				handleStrictPreflight(c, originAllowed, allowedMethods, allowedHeaders, routes)
+				return
+			}
 			c.AbortWithStatus(204)
 			return
 		}
@@ -139,6 +145,82 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// handleStrictPreflight answers preflight requests only when the origin, method and headers are allowed,
+// echoing back just the requested method and headers. Other preflights get 403. Plain OPTIONS
+// requests (without Access-Control-Request-Method) and allowed preflights for a method and path that
+// no route handles are passed on to the router instead of answered, so unknown routes get its 404.
+func handleStrictPreflight(c *gin.Context, originAllowed bool, allowedMethods, allowedHeaders []string, routes func() gin.RoutesInfo) {
+	requestedMethod := c.GetHeader("Access-Control-Request-Method")
+	if requestedMethod == "" {
+		c.Next()
+		return
+	}
+
+	if !originAllowed || !containsFold(allowedMethods, requestedMethod) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "CORS preflight not allowed"})
+		return
+	}
+
+	requestedHeaders := parseStringList(c.GetHeader("Access-Control-Request-Headers"))
+	for _, header := range requestedHeaders {
+		if !containsFold(allowedHeaders, header) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "CORS preflight not allowed"})
+			return
+		}
+	}
+
+	if !hasRoute(routes(), requestedMethod, c.Request.URL.Path) {
+		c.Next()
+		return
+	}
+
+	c.Header("Access-Control-Allow-Methods", requestedMethod)
+	if len(requestedHeaders) > 0 {
+		c.Header("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+	} else {
+		c.Writer.Header().Del("Access-Control-Allow-Headers")
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// hasRoute reports whether one of routes handles method on path, matching :param segments and
+// *catchAll suffixes like the router does
+func hasRoute(routes gin.RoutesInfo, method, path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range routes {
+		if strings.EqualFold(route.Method, method) && routeMatches(strings.Split(strings.Trim(route.Path, "/"), "/"), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeMatches reports whether the segments of a route pattern match the segments of a path
+func routeMatches(pattern, segments []string) bool {
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(segments) || (segments[i] == "" && part != "") {
+			return false
+		}
+		if !strings.HasPrefix(part, ":") && part != segments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segments)
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
 // parseStringList parses a comma-separated string into a slice of trimmed strings
 func parseStringList(s string) []string {
 	if s == "" {
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-go-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSStrictPreflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(&config.Config{
+		CORSAllowedOrigins:  "https://app.example.com",
+		CORSAllowedMethods:  "GET,POST,PUT",
+		CORSAllowedHeaders:  "Content-Type,Authorization",
+		CORSStrictPreflight: true,
+	}, router.Routes))
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/tasks", handler)
+	router.PUT("/tasks", handler)
+	router.GET("/tasks/:id", handler)
+	router.GET("/files/*path", handler)
+
+	preflightTo := func(path, origin, method, headers string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", origin)
+		if method != "" {
+			req.Header.Set("Access-Control-Request-Method", method)
+		}
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	preflight := func(origin, method, headers string) *httptest.ResponseRecorder {
+		return preflightTo("/tasks", origin, method, headers)
+	}
+
+	t.Run("Allowed preflight echoes only the requested method and headers", func(t *testing.T) {
+		w := preflight("https://app.example.com", "PUT", "authorization")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "PUT", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("Disallowed origin is rejected", func(t *testing.T) {
+		w := preflight("https://evil.example.com", "GET", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Disallowed method or header is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, preflight("https://app.example.com", "DELETE", "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight("https://app.example.com", "GET", "X-Custom").Code)
+	})
+
+	t.Run("OPTIONS without a preflight method reaches the router", func(t *testing.T) {
+		w := preflight("https://app.example.com", "", "")
+		assert.NotEqual(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Preflights are answered for parameterized routes", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, preflightTo("/tasks/42", "https://app.example.com", "GET", "").Code)
+		assert.Equal(t, http.StatusNoContent, preflightTo("/files/a/b.txt", "https://app.example.com", "GET", "").Code)
+	})
+
+	t.Run("Preflights for unknown routes get the router's 404", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, preflightTo("/unknown", "https://app.example.com", "GET", "").Code)
+		assert.Equal(t, http.StatusNotFound, preflightTo("/tasks/42/extra", "https://app.example.com", "GET", "").Code)
+		// The path exists, but not for the requested method
+		assert.Equal(t, http.StatusNotFound, preflightTo("/tasks/42", "https://app.example.com", "PUT", "").Code)
+	})
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &logs
+	defer func() { gin.DefaultWriter = defaultWriter }()
+
+	router := gin.New()
+	router.Use(LoggerMiddleware())
+	var received string
+	handler := func(c *gin.Context) {
+		received = c.Query("token")
+		c.Status(http.StatusOK)
+	}
+	router.GET("/ws", handler)
+	router.GET("/events", handler)
+
+	get := func(target string) string {
+		logs.Reset()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+		return logs.String()
+	}
+
+	t.Run("Tokens of the streaming endpoints are redacted", func(t *testing.T) {
+		for _, path := range []string{"/ws", "/events"} {
+			line := get(path + "?token=eyJhbGciOiJIUzI1NiJ9.secret&last_event_id=4")
+			assert.NotContains(t, line, "secret")
+			assert.Contains(t, line, path+"?last_event_id=4&token=REDACTED")
+			assert.Equal(t, "eyJhbGciOiJIUzI1NiJ9.secret", received)
+		}
+	})
+
+	t.Run("Other query strings are logged as sent", func(t *testing.T) {
+		assert.Contains(t, get("/events?b=2&a=1"), "/events?b=2&a=1")
+	})
+
+	t.Run("Unparsable query strings are redacted whole", func(t *testing.T) {
+		line := get("/ws?token=secret&bad=%zz")
+		assert.NotContains(t, line, "secret")
+		assert.Contains(t, line, "/ws?[REDACTED]")
+	})
+}
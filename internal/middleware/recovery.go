@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware recovers from panics in handlers, logs them with the request ID and stack
+// trace, and returns a JSON 500 body in the same shape as the API's ErrorResponse.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Printf("[PANIC] request_id=%s %s %s: %v\n%s",
+					c.GetString("request_id"), c.Request.Method, c.Request.URL.Path, recovered, debug.Stack())
+
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error":   "Internal server error",
+					"message": "An unexpected error occurred",
+				})
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -6,16 +6,19 @@ import (
 	"gorm.io/gorm"
 )
 
-// Comment represents a comment on a task
+// Comment represents a comment on a task. The (task_id, pinned DESC, created_at) index matches the
+// order of a task's comment list, so the list is read in index order instead of being sorted.
 type Comment struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Content   string         `json:"content" gorm:"type:text;not null"` // Comment text
-	TaskID    uint           `json:"task_id" gorm:"not null;index"`     // ID of the task this comment belongs to
+	TaskID    uint           `json:"task_id" gorm:"not null;index;index:idx_comments_task_pinned_created,priority:1"` // ID of the task this comment belongs to
 	UserID    uint           `json:"user_id" gorm:"not null;index"`      // ID of the user who created the comment
+	Pinned    bool           `json:"pinned" gorm:"default:false;index:idx_comments_task_pinned_created,priority:2,sort:desc"` // Pinned comments are listed first; only the task owner can pin
 	Task      Task           `json:"task,omitempty" gorm:"foreignKey:TaskID"`
 	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index:idx_comments_task_pinned_created,priority:3"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Deleted   bool           `json:"deleted" gorm:"-"` // True for deleted comments listed as "[deleted]" placeholders
 }
 
@@ -10,12 +10,16 @@ import (
 type NotificationType string
 
 const (
-	// NotificationTypeDueSoon represents notification for tasks due soon (1 day before)
+	// NotificationTypeDueSoon represents notification for tasks due soon (within the configured number of days)
 	NotificationTypeDueSoon NotificationType = "due_soon"
 	// NotificationTypeDueToday represents notification for tasks due today
 	NotificationTypeDueToday NotificationType = "due_today"
 	// NotificationTypeOverdue represents notification for overdue tasks
 	NotificationTypeOverdue NotificationType = "overdue"
+	// NotificationTypeAssigned represents notification sent to a user when someone else creates a task for them
+	NotificationTypeAssigned NotificationType = "assigned"
+	// NotificationTypeComment represents notification sent to the collaborators of a task when someone comments on it
+	NotificationTypeComment NotificationType = "comment"
 )
 
 // NotificationChannel represents the channel used to send notification
@@ -28,14 +32,21 @@ const (
 	NotificationChannelTelegram NotificationChannel = "telegram"
 )
 
-// Notification represents a sent notification
+// PreferredChannelsBoth is the User.PreferredChannels value that sends notifications through every channel
+const PreferredChannelsBoth = "both"
+
+// Notification represents a sent notification. The (user_id, task_id, type, channel, sent_at)
+// index serves the dedupe checks run before every notification is sent.
 type Notification struct {
 	ID        uint                `json:"id" gorm:"primaryKey"`
-	UserID    uint                 `json:"user_id" gorm:"not null;index"`
-	TaskID    uint                 `json:"task_id" gorm:"not null;index"`
-	Type      NotificationType     `json:"type" gorm:"type:varchar(20);not null"`
-	Channel   NotificationChannel  `json:"channel" gorm:"type:varchar(20);not null"`
-	SentAt    time.Time            `json:"sent_at"`
+	UserID    uint                 `json:"user_id" gorm:"not null;index;index:idx_notifications_dedupe,priority:1"`
+	TaskID    uint                 `json:"task_id" gorm:"not null;index;index:idx_notifications_dedupe,priority:2"`
+	CommentID *uint                `json:"comment_id,omitempty" gorm:"index"` // Comment that triggered the notification (comment notifications only)
+	Type      NotificationType     `json:"type" gorm:"type:varchar(20);not null;index:idx_notifications_dedupe,priority:3"`
+	Channel   NotificationChannel  `json:"channel" gorm:"type:varchar(20);not null;index:idx_notifications_dedupe,priority:4"`
+	SentAt    time.Time            `json:"sent_at" gorm:"index:idx_notifications_dedupe,priority:5"`
+	Subject   string               `json:"subject,omitempty" gorm:"type:text"` // Subject of the message sent (email only)
+	Body      string               `json:"body,omitempty" gorm:"type:text"`    // Text of the message sent; empty for notifications sent before it was recorded
 	User      User                 `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Task      Task                 `json:"task,omitempty" gorm:"foreignKey:TaskID"`
 	CreatedAt time.Time            `json:"created_at"`
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Setting represents a runtime setting persisted as a key/value pair.
+// Used for operator overrides that must survive restarts (e.g. scheduler state).
+type Setting struct {
+	Key       string    `json:"key" gorm:"type:varchar(100);primaryKey"`
+	Value     string    `json:"value" gorm:"type:varchar(255);not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,6 +21,9 @@ const (
 	TaskTypeSaude TaskType = "saude"
 )
 
+// TaskTypes lists the valid task types
+var TaskTypes = []TaskType{TaskTypeCasa, TaskTypeTrabalho, TaskTypeLazer, TaskTypeSaude}
+
 // Priority represents the priority level of a task
 type Priority string
 
@@ -34,34 +38,58 @@ const (
 	PriorityUrgente Priority = "urgente"
 )
 
+// Priorities lists the valid priorities, from lowest to highest
+var Priorities = []Priority{PriorityBaixa, PriorityMedia, PriorityAlta, PriorityUrgente}
+
 // Task represents a task in the system
 // A task belongs to a user and can be assigned by another user.
 // Tasks can be shared with other users (many-to-many); when a user creates a task for another, both have access.
+// The (user_id, completed, due_date) and (assigned_by, completed, due_date) indexes narrow the task lists
+// by owner or assigner and completion. The due date sort still takes a sort step: the lists order by
+// "due_date IS NULL, due_date" to put undated tasks last, which the plain due_date column doesn't cover.
 type Task struct {
-	ID               uint           `json:"id" gorm:"primaryKey"`
-	Title            string         `json:"title" gorm:"type:varchar(200);not null"`
-	Description      string         `json:"description" gorm:"type:text"`
-	Type             TaskType       `json:"type" gorm:"type:varchar(20);not null"`
-	Priority         Priority       `json:"priority" gorm:"type:varchar(20);default:'media'"` // Task priority
-	DueDate          *time.Time     `json:"due_date"`                                         // Deadline for task completion
-	Completed        bool           `json:"completed" gorm:"default:false"`
-	UserID           uint           `json:"user_id" gorm:"not null;index"` // ID of the user responsible for the task (owner)
-	AssignedBy       *uint          `json:"assigned_by"`                   // ID of the user who created/assigned the task (nil if created by the user themselves)
-	User             User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	AssignedByUser   *User          `json:"assigned_by_user,omitempty" gorm:"foreignKey:AssignedBy"`
-	SharedWithUsers  []User         `json:"shared_with,omitempty" gorm:"many2many:task_shared_with;"` // Users with whom the task is shared (no limit)
-	Tags             []Tag          `json:"tags,omitempty" gorm:"many2many:task_tags;"`             // Tags associated with the task
-	Comments         []Comment      `json:"comments,omitempty" gorm:"foreignKey:TaskID"`           // Comments on the task
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint             `json:"id" gorm:"primaryKey"`
+	Title           string           `json:"title" gorm:"type:varchar(200);not null"`
+	Description     string           `json:"description" gorm:"type:text"`
+	Type            TaskType         `json:"type" gorm:"type:varchar(20);not null"`
+	Priority        Priority         `json:"priority" gorm:"type:varchar(20);default:'media'"`                                                                // Task priority
+	DueDate         *time.Time       `json:"due_date" gorm:"index:idx_tasks_user_completed_due,priority:3;index:idx_tasks_assigned_completed_due,priority:3"` // Deadline for task completion
+	Completed       bool             `json:"completed" gorm:"default:false;index:idx_tasks_user_completed_due,priority:2;index:idx_tasks_assigned_completed_due,priority:2"`
+	CompletedAt     *time.Time       `json:"completed_at"`                                                                // When the task was last marked as completed (nil while pending)
+	Archived        bool             `json:"archived" gorm:"default:false;index"`                                         // Archived tasks are kept but hidden from the default task list
+	UserID          uint             `json:"user_id" gorm:"not null;index;index:idx_tasks_user_completed_due,priority:1"` // ID of the user responsible for the task (owner)
+	AssignedBy      *uint            `json:"assigned_by" gorm:"index:idx_tasks_assigned_completed_due,priority:1"`        // ID of the user who created/assigned the task (nil if created by the user themselves)
+	UpdatedBy       *uint            `json:"updated_by"`                                                                  // ID of the user who last updated the task (nil if never updated)
+	User            User             `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	AssignedByUser  *User            `json:"assigned_by_user,omitempty" gorm:"foreignKey:AssignedBy"`
+	UpdatedByUser   *User            `json:"updated_by_user,omitempty" gorm:"foreignKey:UpdatedBy;constraint:-"` // No FK: adding one would make SQLite rebuild the tasks table
+	SharedWithUsers []User           `json:"shared_with,omitempty" gorm:"many2many:task_shared_with;"`           // Users with whom the task is shared (no limit)
+	Tags            []Tag            `json:"tags" gorm:"many2many:task_tags;"`                                   // Tags associated with the task; [] when loaded and empty, null when not loaded
+	Comments        []Comment        `json:"comments,omitempty" gorm:"foreignKey:TaskID"`                        // Comments on the task
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `json:"-" gorm:"index"`
+	Permissions     *TaskPermissions `json:"permissions,omitempty" gorm:"-"` // What the requesting user can do with the task (single task responses only)
+}
+
+// TaskPermissions describes what the requesting user can do with a task they have access to
+type TaskPermissions struct {
+	CanEdit     bool `json:"can_edit"`     // Edit title, description, type, priority, due date and tags (owner only)
+	CanComplete bool `json:"can_complete"` // Change the completion status (anyone with access)
+	CanDelete   bool `json:"can_delete"`   // Delete the task (owner only)
+	CanComment  bool `json:"can_comment"`  // Add comments (anyone with access, unless comments are closed on the task)
+	IsOwner     bool `json:"is_owner"`     // The user owns the task
+	IsShared    bool `json:"is_shared"`    // The task is shared with the user
 }
 
 // TaskSharedWith is the join table for sharing tasks with users (task_id, user_id).
 // Used for FirstOrCreate/Delete; the same table is used by Task.SharedWithUsers many2many.
+// Rows are removed by the database when the task or the user is deleted.
 type TaskSharedWith struct {
-	TaskID uint `gorm:"primaryKey"`
-	UserID uint `gorm:"primaryKey"`
+	TaskID uint `gorm:"primaryKey;uniqueIndex:idx_task_shared_with_task_user,priority:1"`
+	UserID uint `gorm:"primaryKey;uniqueIndex:idx_task_shared_with_task_user,priority:2;index"`
+	Task   Task `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
 // TableName returns the table name for TaskSharedWith
@@ -69,15 +97,45 @@ func (TaskSharedWith) TableName() string {
 	return "task_shared_with"
 }
 
-// Tag represents a custom tag that can be associated with tasks
+// TaskTag is the join table of Task.Tags and Tag.Tasks (task_id, tag_id).
+// Rows are removed by the database when the task or the tag is deleted.
+type TaskTag struct {
+	TaskID uint `gorm:"primaryKey;uniqueIndex:idx_task_tags_task_tag,priority:1"`
+	TagID  uint `gorm:"primaryKey;uniqueIndex:idx_task_tags_task_tag,priority:2;index"`
+	Task   Task `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
+	Tag    Tag  `gorm:"foreignKey:TagID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for TaskTag
+func (TaskTag) TableName() string {
+	return "task_tags"
+}
+
+// Tag represents a custom tag that can be associated with tasks.
+// Names are unique per user ignoring case: the (user_id, name_key) unique index enforces it for
+// the tags that aren't deleted, since deleting a tag clears its name key.
 type Tag struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"type:varchar(50);not null"`
-	Color     string         `json:"color" gorm:"type:varchar(7)"`  // Hex color code (e.g., #FF5733)
-	UserID    uint           `json:"user_id" gorm:"not null;index"` // Tags are user-specific
-	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Tasks     []Task         `json:"tasks,omitempty" gorm:"many2many:task_tags;"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"type:varchar(50);not null"`
+	NameKey      *string        `json:"-" gorm:"type:varchar(50);uniqueIndex:idx_tags_user_name_key,priority:2"`     // TagNameKey of Name, set on save; nil once the tag is deleted
+	Color        string         `json:"color" gorm:"type:varchar(7)"`                                                // Hex color code (e.g., #FF5733)
+	DefaultColor bool           `json:"default_color" gorm:"default:false"`                                          // True when Color was assigned by the server because none was given
+	UserID       uint           `json:"user_id" gorm:"not null;index;uniqueIndex:idx_tags_user_name_key,priority:1"` // Tags are user-specific
+	User         User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Tasks        []Task         `json:"tasks,omitempty" gorm:"many2many:task_tags;"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TagNameKey returns the key tag names are compared by: two names with the same key are the same tag
+func TagNameKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// BeforeSave keeps NameKey in sync with Name
+func (t *Tag) BeforeSave(tx *gorm.DB) error {
+	key := TagNameKey(t.Name)
+	t.NameKey = &key
+	return nil
 }
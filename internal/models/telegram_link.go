@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// TelegramLinkCode represents a short-lived code used to link a Telegram chat to a user account.
+// The user generates the code in the app and sends "/start <code>" to the bot.
+type TelegramLinkCode struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"type:varchar(16);uniqueIndex;not null"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	User      User      `json:"-" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time `json:"created_at"`
+}
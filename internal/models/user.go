@@ -6,14 +6,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// Language is the language a user's notifications are written in
+type Language string
+
+const (
+	// LanguagePortuguese is the default language
+	LanguagePortuguese Language = "pt"
+	// LanguageEnglish represents English
+	LanguageEnglish Language = "en"
+	// LanguageSpanish represents Spanish
+	LanguageSpanish Language = "es"
+)
+
+// Languages lists the supported notification languages
+var Languages = []Language{LanguagePortuguese, LanguageEnglish, LanguageSpanish}
+
 // User represents a user in the system
 type User struct {
 	ID                   uint           `json:"id" gorm:"primaryKey"`
 	Username             string         `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
 	Email                string         `json:"email" gorm:"type:varchar(255);uniqueIndex;not null"`
-	Password             string         `json:"-" gorm:"type:varchar(255);not null"`       // Hashed password, not exposed in JSON
-	TelegramChatID       *string        `json:"telegram_chat_id" gorm:"type:varchar(50)"`  // Telegram chat ID for notifications
-	NotificationsEnabled bool           `json:"notifications_enabled" gorm:"default:true"` // Enable/disable notifications
+	Password             string         `json:"-" gorm:"type:varchar(255);not null"`                       // Hashed password, not exposed in JSON
+	TelegramChatID       *string        `json:"telegram_chat_id" gorm:"type:varchar(50)"`                  // Telegram chat ID for notifications
+	TelegramThreadID     *int           `json:"telegram_message_thread_id"`                                // Topic (message thread) in a Telegram supergroup, nil = general thread
+	NotificationsEnabled bool           `json:"notifications_enabled" gorm:"default:true"`                 // Enable/disable notifications
+	NotifyDueSoon        bool           `json:"notify_due_soon" gorm:"default:true"`                       // Receive due soon reminders
+	NotifyDueToday       bool           `json:"notify_due_today" gorm:"default:true"`                      // Receive due today reminders
+	NotifyOverdue        bool           `json:"notify_overdue" gorm:"default:true"`                        // Receive overdue reminders
+	PreferredChannels    string         `json:"preferred_channels" gorm:"type:varchar(50);default:'both'"` // "both" sends to every channel; an ordered list like "telegram,email" sends to the first one that succeeds
+	Language             Language       `json:"language" gorm:"type:varchar(5);default:'pt'"`              // Language of the notifications (pt, en, es)
 	CreatedAt            time.Time      `json:"created_at"`
 	UpdatedAt            time.Time      `json:"updated_at"`
 	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
@@ -1,107 +1,422 @@
 package notifications
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/smtp"
+	"net/textproto"
+	"sync"
 	"todo-go-backend/internal/models"
 )
 
+// SMTP TLS modes
+const (
+	// SMTPModeNone sends mail over a plain connection (e.g. a local relay)
+	SMTPModeNone = "none"
+	// SMTPModeStartTLS upgrades the connection with STARTTLS and fails if the server doesn't support it
+	SMTPModeStartTLS = "starttls"
+	// SMTPModeTLS uses implicit TLS from the start of the connection (usually port 465)
+	SMTPModeTLS = "tls"
+)
+
 // EmailService handles email notifications
 type EmailService struct {
-	host     string
-	port     string
-	user     string
-	password string
-	from     string
+	host            string
+	port            string
+	user            string
+	password        string
+	from            string
+	mode            string
+	frontendBaseURL string // Used to link to the task; empty omits the link
+	keepAlive       bool   // Reuse one connection for consecutive emails instead of dialing for each
+
+	mu     sync.Mutex   // Serializes sends over the kept connection
+	client *smtp.Client // Kept connection when keepAlive is set; nil until the first send
 }
 
-// NewEmailService creates a new email service
-func NewEmailService(host, port, user, password, from string) *EmailService {
+// NewEmailService creates a new email service. frontendBaseURL is the web app address used to
+// link to tasks in the emails; empty omits the links. keepAlive sends consecutive emails over one
+// connection, which stays open until Close.
+func NewEmailService(host, port, user, password, from, mode, frontendBaseURL string, keepAlive bool) *EmailService {
+	if mode == "" {
+		mode = SMTPModeStartTLS
+	}
 	return &EmailService{
-		host:     host,
-		port:     port,
-		user:     user,
-		password: password,
-		from:     from,
+		host:            host,
+		port:            port,
+		user:            user,
+		password:        password,
+		from:            from,
+		mode:            mode,
+		frontendBaseURL: frontendBaseURL,
+		keepAlive:       keepAlive,
 	}
 }
 
-// SendNotification sends a notification email
-func (s *EmailService) SendNotification(user *models.User, task *models.Task, notificationType models.NotificationType) error {
-	if s.host == "" || s.user == "" || s.password == "" {
-		return fmt.Errorf("email service not configured")
+// IsConfigured returns true if the service has enough settings to send mail.
+// Credentials are optional only when TLS is disabled (local relays usually don't authenticate).
+func (s *EmailService) IsConfigured() bool {
+	if s.host == "" || s.from == "" {
+		return false
+	}
+	if s.mode == SMTPModeNone {
+		return true
 	}
+	return s.user != "" && s.password != ""
+}
 
-	subject, body := s.buildEmailContent(task, notificationType)
+// Name returns the email channel
+func (s *EmailService) Name() models.NotificationChannel {
+	return models.NotificationChannelEmail
+}
 
-	// Setup authentication
-	auth := smtp.PlainAuth("", s.user, s.password, s.host)
+// Recipient returns the user's email address
+func (s *EmailService) Recipient(user *models.User) (string, error) {
+	if user.Email == "" {
+		return "", errors.New("user has no email address")
+	}
+	return user.Email, nil
+}
+
+// Send sends a notification email. comment is set only for comment notifications.
+func (s *EmailService) Send(ctx context.Context, user *models.User, task *models.Task, comment *models.Comment, notificationType models.NotificationType) (Message, error) {
+	if !s.IsConfigured() {
+		return Message{}, fmt.Errorf("email service not configured")
+	}
+
+	var subject, htmlBody, textBody string
+	if comment != nil {
+		subject, htmlBody, textBody = s.buildCommentEmailContent(task, comment, user.Language)
+	} else {
+		subject, htmlBody, textBody = s.buildEmailContent(task, notificationType, user.Language)
+	}
+
+	if err := s.SendEmail(user.Email, subject, htmlBody, textBody); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: subject, Body: textBody}, nil
+}
+
+// SendTest sends the test email to the user
+func (s *EmailService) SendTest(ctx context.Context, user *models.User) error {
+	if _, err := s.Recipient(user); err != nil {
+		return err
+	}
+	title := translate(user.Language, "test_title")
+	body := translate(user.Language, "test_email")
+	return s.SendEmail(
+		user.Email,
+		"🔔 "+title,
+		fmt.Sprintf("<html><body><h2>%s</h2><p>%s</p></body></html>", title, body),
+		fmt.Sprintf("%s\n\n%s\n", title, body),
+	)
+}
+
+// SendEmail sends a multipart/alternative email (plain text and HTML) to a single recipient
+func (s *EmailService) SendEmail(to, subject, htmlBody, textBody string) error {
+	if !s.IsConfigured() {
+		return fmt.Errorf("email service not configured")
+	}
 
 	// Email message
-	msg := []byte(fmt.Sprintf("To: %s\r\n", user.Email) +
-		fmt.Sprintf("Subject: %s\r\n", subject) +
-		"MIME-Version: 1.0\r\n" +
-		"Content-Type: text/html; charset=UTF-8\r\n" +
-		"\r\n" +
-		body)
+	msg, err := buildMultipartMessage(to, subject, htmlBody, textBody)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
 
 	// Send email
-	addr := fmt.Sprintf("%s:%s", s.host, s.port)
-	err := smtp.SendMail(addr, auth, s.from, []string{user.Email}, msg)
-	if err != nil {
+	if err := s.send(to, msg); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	return nil
 }
 
-// buildEmailContent builds email subject and body based on notification type
-func (s *EmailService) buildEmailContent(task *models.Task, notificationType models.NotificationType) (string, string) {
-	var subject string
-	var body string
+// send delivers a raw message. Without keepAlive each message gets its own connection. With it,
+// the kept connection is reused; if the server dropped it, a new one is dialed, and a connection
+// that fails mid-send is discarded so the next message reconnects.
+func (s *EmailService) send(to string, msg []byte) error {
+	if !s.keepAlive {
+		client, err := s.dial()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		if err := s.deliver(client, to, msg); err != nil {
+			return err
+		}
+		return client.Quit()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// RSET checks that the kept connection is still usable before anything is sent over it
+	if s.client != nil && s.client.Reset() != nil {
+		s.client.Close()
+		s.client = nil
+	}
+	if s.client == nil {
+		client, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.client = client
+	}
+	if err := s.deliver(s.client, to, msg); err != nil {
+		s.client.Close()
+		s.client = nil
+		return err
+	}
+	return nil
+}
+
+// Close ends the kept connection, if any. The next email dials a new one.
+func (s *EmailService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Quit()
+	if err != nil {
+		s.client.Close()
+	}
+	s.client = nil
+	return err
+}
+
+// dial connects to the server according to the configured TLS mode and authenticates
+func (s *EmailService) dial() (*smtp.Client, error) {
+	addr := fmt.Sprintf("%s:%s", s.host, s.port)
+	tlsConfig := &tls.Config{ServerName: s.host}
+
+	var client *smtp.Client
+	if s.mode == SMTPModeTLS {
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("tls dial %s: %w", addr, err)
+		}
+		client, err = smtp.NewClient(conn, s.host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	} else {
+		var err error
+		client, err = smtp.Dial(addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.setUp(client, addr, tlsConfig); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// setUp upgrades the connection with STARTTLS when required and authenticates
+func (s *EmailService) setUp(client *smtp.Client, addr string, tlsConfig *tls.Config) error {
+	if s.mode == SMTPModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server %s does not support STARTTLS (set SMTP_MODE=tls or none)", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	// Setup authentication
+	if s.user != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+				return fmt.Errorf("auth: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// deliver sends one message over an open connection
+func (s *EmailService) deliver(client *smtp.Client, to string, msg []byte) error {
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// buildMultipartMessage builds the raw message with a text/plain part followed by a text/html part.
+// Clients render the last part they support, so HTML is preferred and plain text is the fallback.
+func buildMultipartMessage(to, subject, htmlBody, textBody string) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	}
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(partWriter)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject)))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q\r\n", writer.Boundary()))
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// buildEmailContent builds email subject, HTML body and plain text body based on notification type,
+// in the given language
+func (s *EmailService) buildEmailContent(task *models.Task, notificationType models.NotificationType, lang models.Language) (string, string, string) {
+	var emoji string
+	var title string
 
 	switch notificationType {
 	case models.NotificationTypeDueSoon:
-		subject = fmt.Sprintf("⏰ Tarefa vence amanhã: %s", task.Title)
-		body = fmt.Sprintf(`
-			<html>
-			<body>
-				<h2>Tarefa vence amanhã!</h2>
-				<p><strong>%s</strong></p>
-				<p>%s</p>
-				<p><strong>Prioridade:</strong> %s</p>
-				<p><strong>Data de vencimento:</strong> %s</p>
-			</body>
-			</html>
-		`, task.Title, task.Description, task.Priority, task.DueDate.Format("02/01/2006"))
+		emoji = "⏰"
+		title = translate(lang, "due_soon", dueSoonLabel(task.DueDate, lang))
 	case models.NotificationTypeDueToday:
-		subject = fmt.Sprintf("📅 Tarefa vence hoje: %s", task.Title)
-		body = fmt.Sprintf(`
+		emoji = "📅"
+		title = translate(lang, "due_today")
+	case models.NotificationTypeOverdue:
+		emoji = "⚠️"
+		title = translate(lang, "overdue")
+	case models.NotificationTypeAssigned:
+		emoji = "📌"
+		title = translate(lang, "assigned", assignerName(task, lang))
+	}
+	subject := fmt.Sprintf("%s %s: %s", emoji, title, task.Title)
+	heading := title + "!"
+
+	dueDateStr := formatDueDate(task.DueDate, lang)
+	priorityLabelText := translate(lang, "priority")
+	dueDateLabelText := translate(lang, "due_date")
+	priority := priorityLabel(task.Priority, lang)
+
+	htmlBody := fmt.Sprintf(`
 			<html>
 			<body>
-				<h2>Tarefa vence hoje!</h2>
+				<h2>%s</h2>
 				<p><strong>%s</strong></p>
 				<p>%s</p>
-				<p><strong>Prioridade:</strong> %s</p>
-				<p><strong>Data de vencimento:</strong> %s</p>
+				<p><strong>%s:</strong> %s</p>
+				<p><strong>%s:</strong> %s</p>
+				%s
 			</body>
 			</html>
-		`, task.Title, task.Description, task.Priority, task.DueDate.Format("02/01/2006"))
-	case models.NotificationTypeOverdue:
-		subject = fmt.Sprintf("⚠️ Tarefa atrasada: %s", task.Title)
-		body = fmt.Sprintf(`
+		`, heading, task.Title, task.Description, priorityLabelText, priority, dueDateLabelText, dueDateStr, s.taskLinkHTML(task, lang))
+
+	textBody := fmt.Sprintf(
+		"%s\n\n"+
+			"%s\n"+
+			"%s\n\n"+
+			"%s: %s\n"+
+			"%s: %s\n",
+		heading,
+		task.Title,
+		task.Description,
+		priorityLabelText,
+		priority,
+		dueDateLabelText,
+		dueDateStr,
+	) + s.taskLinkText(task, lang)
+
+	return subject, htmlBody, textBody
+}
+
+// buildCommentEmailContent builds email subject, HTML body and plain text body for a new comment,
+// in the given language
+func (s *EmailService) buildCommentEmailContent(task *models.Task, comment *models.Comment, lang models.Language) (string, string, string) {
+	author := commentAuthorName(comment, lang)
+	snippet := commentSnippet(comment)
+	subject := fmt.Sprintf("💬 %s: %s", translate(lang, "comment_subject", author), task.Title)
+	heading := translate(lang, "comment", author)
+
+	htmlBody := fmt.Sprintf(`
 			<html>
 			<body>
-				<h2>Tarefa atrasada!</h2>
+				<h2>%s</h2>
 				<p><strong>%s</strong></p>
-				<p>%s</p>
-				<p><strong>Prioridade:</strong> %s</p>
-				<p><strong>Data de vencimento:</strong> %s</p>
+				<blockquote>%s</blockquote>
+				%s
 			</body>
 			</html>
-		`, task.Title, task.Description, task.Priority, task.DueDate.Format("02/01/2006"))
-	}
+		`, html.EscapeString(heading), html.EscapeString(task.Title), html.EscapeString(snippet), s.taskLinkHTML(task, lang))
 
-	return subject, body
+	textBody := fmt.Sprintf(
+		"%s\n\n"+
+			"%s\n\n"+
+			"\"%s\"\n",
+		heading,
+		task.Title,
+		snippet,
+	) + s.taskLinkText(task, lang)
+
+	return subject, htmlBody, textBody
+}
+
+// taskLinkHTML renders the "open task" button, or nothing when no frontend URL is configured
+func (s *EmailService) taskLinkHTML(task *models.Task, lang models.Language) string {
+	url := taskURL(s.frontendBaseURL, task)
+	if url == "" {
+		return ""
+	}
+	return fmt.Sprintf(
+		`<p><a href="%s" style="display:inline-block;padding:10px 18px;background-color:#2563EB;color:#FFFFFF;text-decoration:none;border-radius:6px;font-weight:bold;">%s</a></p>`,
+		html.EscapeString(url),
+		translate(lang, "open_task"),
+	)
 }
 
+// taskLinkText is the plain text version of taskLinkHTML
+func (s *EmailService) taskLinkText(task *models.Task, lang models.Language) string {
+	url := taskURL(s.frontendBaseURL, task)
+	if url == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n%s: %s\n", translate(lang, "open_task"), url)
+}
@@ -0,0 +1,232 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"todo-go-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailTaskLink(t *testing.T) {
+	task := &models.Task{ID: 42, Title: "Pay bills", Priority: models.PriorityAlta}
+
+	t.Run("HTML and text bodies link to the task when a frontend URL is configured", func(t *testing.T) {
+		service := NewEmailService("smtp.example.com", "587", "user", "pass", "noreply@example.com", SMTPModeStartTLS, "https://todo.example.com", false)
+
+		_, htmlBody, textBody := service.buildEmailContent(task, models.NotificationTypeDueToday, models.LanguagePortuguese)
+		assert.Contains(t, htmlBody, `href="https://todo.example.com/tasks/42"`)
+		assert.Contains(t, textBody, "Abrir tarefa: https://todo.example.com/tasks/42")
+	})
+
+	t.Run("The link is omitted without a frontend URL", func(t *testing.T) {
+		service := NewEmailService("smtp.example.com", "587", "user", "pass", "noreply@example.com", SMTPModeStartTLS, "", false)
+
+		_, htmlBody, textBody := service.buildEmailContent(task, models.NotificationTypeDueToday, models.LanguagePortuguese)
+		assert.NotContains(t, htmlBody, "href")
+		assert.NotContains(t, textBody, "Abrir tarefa")
+	})
+}
+
+// smtpTestServer is a minimal SMTP server that accepts every message and counts connections and messages
+type smtpTestServer struct {
+	listener    net.Listener
+	mu          sync.Mutex
+	connections int
+	messages    int
+	conns       []net.Conn
+}
+
+func newSMTPTestServer(t *testing.T) *smtpTestServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &smtpTestServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.connections++
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *smtpTestServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ready")
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if inData {
+			if line == "." {
+				inData = false
+				s.mu.Lock()
+				s.messages++
+				s.mu.Unlock()
+				reply("250 OK")
+			}
+			continue
+		}
+		switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			inData = true
+			reply("354 Go ahead")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// dropConnections closes the server side of every open connection, like an idle timeout would
+func (s *smtpTestServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *smtpTestServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, s.messages
+}
+
+func TestEmailKeepAlive(t *testing.T) {
+	sendAll := func(service *EmailService, count int) {
+		for i := 0; i < count; i++ {
+			assert.NoError(t, service.SendEmail("ana@example.com", "Subject", "<p>Body</p>", "Body"))
+		}
+	}
+
+	t.Run("Without keepAlive every email dials", func(t *testing.T) {
+		server := newSMTPTestServer(t)
+		host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+		service := NewEmailService(host, port, "", "", "noreply@example.com", SMTPModeNone, "", false)
+
+		sendAll(service, 3)
+		connections, messages := server.counts()
+		assert.Equal(t, 3, connections)
+		assert.Equal(t, 3, messages)
+	})
+
+	t.Run("With keepAlive emails share a connection until Close", func(t *testing.T) {
+		server := newSMTPTestServer(t)
+		host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+		service := NewEmailService(host, port, "", "", "noreply@example.com", SMTPModeNone, "", true)
+
+		sendAll(service, 3)
+		connections, messages := server.counts()
+		assert.Equal(t, 1, connections)
+		assert.Equal(t, 3, messages)
+
+		assert.NoError(t, service.Close())
+		sendAll(service, 1)
+		connections, _ = server.counts()
+		assert.Equal(t, 2, connections)
+	})
+
+	t.Run("A connection dropped by the server is replaced", func(t *testing.T) {
+		server := newSMTPTestServer(t)
+		host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+		service := NewEmailService(host, port, "", "", "noreply@example.com", SMTPModeNone, "", true)
+
+		sendAll(service, 1)
+		server.dropConnections()
+		sendAll(service, 1)
+
+		connections, messages := server.counts()
+		assert.Equal(t, 2, connections)
+		assert.Equal(t, 2, messages)
+	})
+}
+
+func TestEmailSendReturnsMessage(t *testing.T) {
+	server := newSMTPTestServer(t)
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	service := NewEmailService(host, port, "", "", "noreply@example.com", SMTPModeNone, "", false)
+	task := &models.Task{ID: 1, Title: "Pay bills"}
+
+	message, err := service.Send(context.Background(), &models.User{Email: "ana@example.com"}, task, nil, models.NotificationTypeOverdue)
+	assert.NoError(t, err)
+	assert.Contains(t, message.Subject, "Pay bills")
+	assert.Contains(t, message.Body, "Pay bills")
+	assert.NotContains(t, message.Body, "<html>")
+}
+
+func TestEmailSMTPMode(t *testing.T) {
+	t.Run("STARTTLS is the default mode", func(t *testing.T) {
+		service := NewEmailService("smtp.example.com", "587", "user", "pass", "noreply@example.com", "", "", false)
+		assert.Equal(t, SMTPModeStartTLS, service.mode)
+	})
+
+	t.Run("Credentials are optional only without TLS", func(t *testing.T) {
+		assert.True(t, NewEmailService("relay", "25", "", "", "noreply@example.com", SMTPModeNone, "", false).IsConfigured())
+		assert.False(t, NewEmailService("smtp.example.com", "587", "", "", "noreply@example.com", SMTPModeStartTLS, "", false).IsConfigured())
+		assert.False(t, NewEmailService("smtp.example.com", "465", "", "", "noreply@example.com", SMTPModeTLS, "", false).IsConfigured())
+		assert.True(t, NewEmailService("smtp.example.com", "465", "user", "pass", "noreply@example.com", SMTPModeTLS, "", false).IsConfigured())
+	})
+
+	// The test server speaks plain SMTP and doesn't offer STARTTLS
+	t.Run("Plain mode sends without TLS", func(t *testing.T) {
+		server := newSMTPTestServer(t)
+		host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+		service := NewEmailService(host, port, "", "", "noreply@example.com", SMTPModeNone, "", false)
+
+		assert.NoError(t, service.SendEmail("ana@example.com", "Subject", "<p>Body</p>", "Body"))
+		_, messages := server.counts()
+		assert.Equal(t, 1, messages)
+	})
+
+	t.Run("STARTTLS mode refuses a server without STARTTLS", func(t *testing.T) {
+		server := newSMTPTestServer(t)
+		host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+		service := NewEmailService(host, port, "user", "pass", "noreply@example.com", SMTPModeStartTLS, "", false)
+
+		err := service.SendEmail("ana@example.com", "Subject", "<p>Body</p>", "Body")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "does not support STARTTLS")
+		}
+		_, messages := server.counts()
+		assert.Equal(t, 0, messages)
+	})
+
+	t.Run("TLS mode starts with a TLS handshake", func(t *testing.T) {
+		server := newSMTPTestServer(t)
+		host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+		service := NewEmailService(host, port, "user", "pass", "noreply@example.com", SMTPModeTLS, "", false)
+
+		// The plain greeting is not a TLS handshake, so the dial fails before any SMTP command
+		err := service.SendEmail("ana@example.com", "Subject", "<p>Body</p>", "Body")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "tls dial")
+		}
+		_, messages := server.counts()
+		assert.Equal(t, 0, messages)
+	})
+}
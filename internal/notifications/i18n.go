@@ -0,0 +1,106 @@
+package notifications
+
+import (
+	"fmt"
+	"todo-go-backend/internal/models"
+)
+
+// messages holds the notification strings by language and key. Portuguese is complete and is used
+// for users with an unknown language and for keys missing from another language.
+var messages = map[models.Language]map[string]string{
+	models.LanguagePortuguese: {
+		"due_soon":         "Tarefa vence %s",
+		"due_today":        "Tarefa vence hoje",
+		"overdue":          "Tarefa atrasada",
+		"assigned":         "%s atribuiu uma tarefa a você",
+		"comment":          "%s comentou em uma tarefa",
+		"comment_subject":  "%s comentou em",
+		"priority":         "Prioridade",
+		"due_date":         "Data de vencimento",
+		"no_due_date":      "sem data",
+		"tomorrow":         "amanhã",
+		"in_days":          "em %d dias",
+		"soon":             "em breve",
+		"someone":          "Alguém",
+		"open_task":        "Abrir tarefa",
+		"test_title":       "Notificação de teste",
+		"test_email":       "Seu email está configurado corretamente para receber notificações de tarefas.",
+		"test_telegram":    "Seu Telegram está configurado corretamente para receber notificações de tarefas.",
+		"date_format":      "02/01/2006",
+		"date_time_format": "02/01/2006 15:04",
+		"priority_baixa":   "baixa",
+		"priority_media":   "media",
+		"priority_alta":    "alta",
+		"priority_urgente": "urgente",
+	},
+	models.LanguageEnglish: {
+		"due_soon":         "Task due %s",
+		"due_today":        "Task due today",
+		"overdue":          "Task overdue",
+		"assigned":         "%s assigned you a task",
+		"comment":          "%s commented on a task",
+		"comment_subject":  "%s commented on",
+		"priority":         "Priority",
+		"due_date":         "Due date",
+		"no_due_date":      "no date",
+		"tomorrow":         "tomorrow",
+		"in_days":          "in %d days",
+		"soon":             "soon",
+		"someone":          "Someone",
+		"open_task":        "Open task",
+		"test_title":       "Test notification",
+		"test_email":       "Your email is set up correctly to receive task notifications.",
+		"test_telegram":    "Your Telegram is set up correctly to receive task notifications.",
+		"date_format":      "01/02/2006",
+		"date_time_format": "01/02/2006 3:04 PM",
+		"priority_baixa":   "low",
+		"priority_media":   "medium",
+		"priority_alta":    "high",
+		"priority_urgente": "urgent",
+	},
+	models.LanguageSpanish: {
+		"due_soon":         "La tarea vence %s",
+		"due_today":        "La tarea vence hoy",
+		"overdue":          "Tarea atrasada",
+		"assigned":         "%s te asignó una tarea",
+		"comment":          "%s comentó en una tarea",
+		"comment_subject":  "%s comentó en",
+		"priority":         "Prioridad",
+		"due_date":         "Fecha de vencimiento",
+		"no_due_date":      "sin fecha",
+		"tomorrow":         "mañana",
+		"in_days":          "en %d días",
+		"soon":             "pronto",
+		"someone":          "Alguien",
+		"open_task":        "Abrir tarea",
+		"test_title":       "Notificación de prueba",
+		"test_email":       "Tu correo está configurado correctamente para recibir notificaciones de tareas.",
+		"test_telegram":    "Tu Telegram está configurado correctamente para recibir notificaciones de tareas.",
+		"date_format":      "02/01/2006",
+		"date_time_format": "02/01/2006 15:04",
+		"priority_baixa":   "baja",
+		"priority_media":   "media",
+		"priority_alta":    "alta",
+		"priority_urgente": "urgente",
+	},
+}
+
+// translate returns the string for key in lang, formatted with args when given
+func translate(lang models.Language, key string, args ...any) string {
+	text, ok := messages[lang][key]
+	if !ok {
+		text = messages[models.LanguagePortuguese][key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// priorityLabel returns the name of a priority in lang; unknown priorities are shown as stored
+func priorityLabel(priority models.Priority, lang models.Language) string {
+	if _, ok := messages[models.LanguagePortuguese]["priority_"+string(priority)]; !ok {
+		return string(priority)
+	}
+	return translate(lang, "priority_"+string(priority))
+}
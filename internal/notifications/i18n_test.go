@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+	"todo-go-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationLanguage(t *testing.T) {
+	dueDate := time.Date(2024, 12, 31, 0, 0, 0, 0, time.Local)
+	task := &models.Task{ID: 42, Title: "Pay bills", Priority: models.PriorityAlta, DueDate: &dueDate}
+	emailService := NewEmailService("smtp.example.com", "587", "user", "pass", "noreply@example.com", SMTPModeStartTLS, "https://todo.example.com", false)
+	telegramService := NewTelegramService("token", "https://todo.example.com")
+
+	t.Run("Portuguese is used by default", func(t *testing.T) {
+		subject, _, textBody := emailService.buildEmailContent(task, models.NotificationTypeOverdue, "")
+		assert.Equal(t, "⚠️ Tarefa atrasada: Pay bills", subject)
+		assert.Contains(t, textBody, "Prioridade: alta")
+		assert.Contains(t, textBody, "Data de vencimento: 31/12/2024")
+	})
+
+	t.Run("English", func(t *testing.T) {
+		subject, _, textBody := emailService.buildEmailContent(task, models.NotificationTypeOverdue, models.LanguageEnglish)
+		assert.Equal(t, "⚠️ Task overdue: Pay bills", subject)
+		assert.Contains(t, textBody, "Priority: high")
+		assert.Contains(t, textBody, "Due date: 12/31/2024")
+		assert.Contains(t, textBody, "Open task: https://todo.example.com/tasks/42")
+	})
+
+	t.Run("Spanish", func(t *testing.T) {
+		message := telegramService.buildMessage(task, models.NotificationTypeOverdue, models.LanguageSpanish)
+		assert.Contains(t, message, "<b>Tarea atrasada!</b>")
+		assert.Contains(t, message, "<b>Prioridad:</b> alta")
+		assert.Contains(t, message, ">Abrir tarea</a>")
+
+		comment := &models.Comment{Content: "Listo"}
+		assert.Contains(t, telegramService.buildCommentMessage(task, comment, models.LanguageSpanish), "Alguien comentó en una tarea")
+	})
+
+	t.Run("Every language has every key", func(t *testing.T) {
+		for lang, strings := range messages {
+			for key := range messages[models.LanguagePortuguese] {
+				assert.Contains(t, strings, key, "language %s", lang)
+			}
+		}
+	})
+}
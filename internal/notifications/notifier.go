@@ -0,0 +1,31 @@
+package notifications
+
+import (
+	"context"
+	"todo-go-backend/internal/models"
+)
+
+// Notifier delivers notifications through one channel. NotificationService sends through the
+// notifiers it was created with, so a new channel only needs to implement Notifier and be registered.
+type Notifier interface {
+	// Name is the channel the notifier sends through, as recorded in the dedupe table and listed
+	// in User.PreferredChannels
+	Name() models.NotificationChannel
+	// IsConfigured reports whether the server has the settings needed to send through the channel
+	IsConfigured() bool
+	// Recipient returns the address the user is reached at on this channel (used in logs), or an
+	// error when the user hasn't set the channel up
+	Recipient(user *models.User) (string, error)
+	// Send sends a notification to the user and returns what was sent. comment is set only for
+	// comment notifications.
+	Send(ctx context.Context, user *models.User, task *models.Task, comment *models.Comment, notificationType models.NotificationType) (Message, error)
+	// SendTest sends a fixed test message to the user
+	SendTest(ctx context.Context, user *models.User) error
+}
+
+// Message is the content of a sent notification, recorded with it so the history shows what the
+// user received
+type Message struct {
+	Subject string // Empty on channels without a subject
+	Body    string // Plain text for email, the HTML formatted text for Telegram
+}
@@ -1,36 +1,208 @@
 package notifications
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"sync"
 	"todo-go-backend/internal/config"
+	"todo-go-backend/internal/repositories"
 
 	"github.com/robfig/cron/v3"
 )
 
-// StartScheduler starts the notification scheduler
-func StartScheduler(cfg *config.Config, notificationService *NotificationService) {
-	if !cfg.NotificationsEnabled {
+// Setting keys used to persist scheduler overrides
+const (
+	settingSchedulerPaused   = "scheduler.paused"
+	settingSchedulerInterval = "scheduler.interval"
+)
+
+// ErrDisabledByConfig is returned when resuming the scheduler while NOTIFICATIONS_ENABLED=false
+var ErrDisabledByConfig = errors.New("notifications are disabled by NOTIFICATIONS_ENABLED=false")
+
+// SchedulerStatus represents the current state of the notification scheduler
+type SchedulerStatus struct {
+	Running          bool   `json:"running" example:"true"`
+	Interval         string `json:"interval" example:"0 * * * *"`
+	DisabledByConfig bool   `json:"disabled_by_config" example:"false"` // NOTIFICATIONS_ENABLED=false: the scheduler can't be resumed
+}
+
+// Scheduler runs the notification check periodically and can be paused, resumed
+// and re-scheduled at runtime. Overrides are persisted so they survive restarts.
+type Scheduler struct {
+	mu                  sync.Mutex
+	cron                *cron.Cron
+	entryID             cron.EntryID
+	interval            string
+	paused              bool
+	disabledByConfig    bool // NOTIFICATIONS_ENABLED=false, which wins over a persisted resume
+	notificationService *NotificationService
+	settingRepo         repositories.SettingRepository
+}
+
+// NewScheduler creates a notification scheduler. The initial state comes from the configuration
+// (NOTIFICATIONS_ENABLED, NOTIFICATION_CHECK_INTERVAL) unless an override was persisted. An
+// operator can always turn notifications off with NOTIFICATIONS_ENABLED=false: a persisted resume
+// is then ignored.
+func NewScheduler(cfg *config.Config, notificationService *NotificationService, settingRepo repositories.SettingRepository) *Scheduler {
+	s := &Scheduler{
+		cron:                cron.New(),
+		interval:            cfg.NotificationCheckInterval,
+		paused:              !cfg.NotificationsEnabled,
+		disabledByConfig:    !cfg.NotificationsEnabled,
+		notificationService: notificationService,
+		settingRepo:         settingRepo,
+	}
+
+	if value, ok, err := settingRepo.Get(settingSchedulerInterval); err != nil {
+		log.Printf("Failed to load scheduler interval override: %v", err)
+	} else if ok {
+		if err := ValidateInterval(value); err != nil {
+			log.Printf("Ignoring invalid persisted scheduler interval %q: %v", value, err)
+		} else {
+			s.interval = value
+		}
+	}
+
+	if value, ok, err := settingRepo.Get(settingSchedulerPaused); err != nil {
+		log.Printf("Failed to load scheduler paused override: %v", err)
+	} else if ok {
+		if paused, err := strconv.ParseBool(value); err == nil {
+			if !paused && s.disabledByConfig {
+				log.Println("Ignoring persisted scheduler resume: NOTIFICATIONS_ENABLED=false")
+			} else {
+				s.paused = paused
+			}
+		}
+	}
+	notificationService.SetPaused(s.paused)
+
+	return s
+}
+
+// Start starts the cron runner and schedules the notification check unless paused
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cron.Start()
+
+	if s.paused {
 		log.Println("Notifications are disabled")
 		return
 	}
 
-	c := cron.New()
+	if err := s.schedule(); err != nil {
+		log.Fatalf("Failed to schedule notifications: %v", err)
+	}
+}
+
+// Pause removes the scheduled notification check and persists the override
+func (s *Scheduler) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.settingRepo.Set(settingSchedulerPaused, "true"); err != nil {
+		return err
+	}
+	s.unschedule()
+	s.paused = true
+	s.notificationService.SetPaused(true)
+	log.Println("Notification scheduler paused")
+	return nil
+}
+
+// Resume schedules the notification check again and persists the override. It fails with
+// ErrDisabledByConfig while NOTIFICATIONS_ENABLED=false.
+func (s *Scheduler) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabledByConfig {
+		return ErrDisabledByConfig
+	}
+
+	if err := s.settingRepo.Set(settingSchedulerPaused, "false"); err != nil {
+		return err
+	}
+	s.paused = false
+	s.notificationService.SetPaused(false)
+	if s.entryID == 0 {
+		if err := s.schedule(); err != nil {
+			return err
+		}
+	}
+	log.Println("Notification scheduler resumed")
+	return nil
+}
+
+// ValidateInterval checks that interval is a valid standard (5 field) cron expression
+func ValidateInterval(interval string) error {
+	if _, err := cron.ParseStandard(interval); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+	return nil
+}
+
+// SetInterval validates a cron expression, re-schedules the check with it and persists the override
+func (s *Scheduler) SetInterval(interval string) error {
+	if err := ValidateInterval(interval); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Add notification check job
-	_, err := c.AddFunc(cfg.NotificationCheckInterval, func() {
+	if err := s.settingRepo.Set(settingSchedulerInterval, interval); err != nil {
+		return err
+	}
+	s.interval = interval
+	if !s.paused {
+		s.unschedule()
+		if err := s.schedule(); err != nil {
+			return err
+		}
+	}
+	log.Printf("Notification scheduler interval changed to: %s", interval)
+	return nil
+}
+
+// Status returns whether the check is scheduled and with which interval
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SchedulerStatus{
+		Running:          !s.paused,
+		Interval:         s.interval,
+		DisabledByConfig: s.disabledByConfig,
+	}
+}
+
+// schedule adds the notification check job. Callers must hold s.mu.
+func (s *Scheduler) schedule() error {
+	entryID, err := s.cron.AddFunc(s.interval, func() {
 		log.Println("Running notification check...")
-		if err := notificationService.CheckAndSendNotifications(); err != nil {
+		if err := s.notificationService.CheckAndSendNotifications(); err != nil {
 			log.Printf("Error checking notifications: %v", err)
 		} else {
 			log.Println("Notification check completed")
 		}
 	})
-
 	if err != nil {
-		log.Fatalf("Failed to schedule notifications: %v", err)
+		return err
 	}
 
-	log.Printf("Notification scheduler started with interval: %s", cfg.NotificationCheckInterval)
-	c.Start()
+	s.entryID = entryID
+	log.Printf("Notification scheduler started with interval: %s", s.interval)
+	return nil
 }
 
+// unschedule removes the notification check job if present. Callers must hold s.mu.
+func (s *Scheduler) unschedule() {
+	if s.entryID != 0 {
+		s.cron.Remove(s.entryID)
+		s.entryID = 0
+	}
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"errors"
+	"testing"
+	"todo-go-backend/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSettingRepository keeps settings in memory
+type fakeSettingRepository map[string]string
+
+func (r fakeSettingRepository) Get(key string) (string, bool, error) {
+	value, ok := r[key]
+	return value, ok, nil
+}
+
+func (r fakeSettingRepository) Set(key, value string) error {
+	r[key] = value
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	newScheduler := func(enabled bool, settings fakeSettingRepository) *Scheduler {
+		cfg := &config.Config{NotificationsEnabled: enabled, NotificationCheckInterval: "0 * * * *"}
+		return NewScheduler(cfg, NewNotificationService(nil, nil, nil, nil, nil, 1), settings)
+	}
+
+	t.Run("Starts from the configuration", func(t *testing.T) {
+		assert.True(t, newScheduler(true, fakeSettingRepository{}).Status().Running)
+		assert.False(t, newScheduler(false, fakeSettingRepository{}).Status().Running)
+	})
+
+	t.Run("Pause and resume are persisted and survive a restart", func(t *testing.T) {
+		settings := fakeSettingRepository{}
+		scheduler := newScheduler(true, settings)
+
+		assert.NoError(t, scheduler.Pause())
+		assert.Equal(t, "true", settings[settingSchedulerPaused])
+		assert.False(t, newScheduler(true, settings).Status().Running)
+
+		assert.NoError(t, scheduler.Resume())
+		assert.Equal(t, "false", settings[settingSchedulerPaused])
+		assert.True(t, newScheduler(true, settings).Status().Running)
+	})
+
+	t.Run("A persisted interval replaces the configured one", func(t *testing.T) {
+		settings := fakeSettingRepository{}
+		assert.NoError(t, newScheduler(true, settings).SetInterval("*/15 * * * *"))
+		assert.Equal(t, "*/15 * * * *", newScheduler(true, settings).Status().Interval)
+
+		settings[settingSchedulerInterval] = "not a cron"
+		assert.Equal(t, "0 * * * *", newScheduler(true, settings).Status().Interval)
+	})
+
+	t.Run("Event notifications follow the scheduler state", func(t *testing.T) {
+		service := NewNotificationService(nil, nil, nil, nil, nil, 1)
+		cfg := &config.Config{NotificationsEnabled: false, NotificationCheckInterval: "0 * * * *"}
+		NewScheduler(cfg, service, fakeSettingRepository{})
+		assert.True(t, service.paused.Load())
+
+		cfg.NotificationsEnabled = true
+		scheduler := NewScheduler(cfg, service, fakeSettingRepository{})
+		assert.False(t, service.paused.Load())
+		assert.NoError(t, scheduler.Pause())
+		assert.True(t, service.paused.Load())
+		assert.NoError(t, scheduler.Resume())
+		assert.False(t, service.paused.Load())
+	})
+
+	t.Run("NOTIFICATIONS_ENABLED=false wins over a persisted resume", func(t *testing.T) {
+		settings := fakeSettingRepository{settingSchedulerPaused: "false"}
+		scheduler := newScheduler(false, settings)
+
+		status := scheduler.Status()
+		assert.False(t, status.Running)
+		assert.True(t, status.DisabledByConfig)
+		assert.True(t, errors.Is(scheduler.Resume(), ErrDisabledByConfig))
+		assert.False(t, scheduler.Status().Running)
+		assert.Equal(t, "false", settings[settingSchedulerPaused])
+	})
+}
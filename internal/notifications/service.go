@@ -1,7 +1,13 @@
 package notifications
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
@@ -10,55 +16,90 @@ import (
 
 // NotificationService handles notification logic
 type NotificationService struct {
-	emailService     *EmailService
-	telegramService  *TelegramService
+	notifiers        []Notifier // Channels in the order notifications are sent to every channel
 	notificationRepo repositories.NotificationRepository
 	taskRepo         repositories.TaskRepository
+	commentRepo      repositories.CommentRepository
 	userRepo         repositories.UserRepository
+	dueSoonDays      int
+	runMu            sync.Mutex  // Serializes CheckAndSendNotifications runs
+	paused           atomic.Bool // Notifications are turned off server-wide (see SetPaused)
 }
 
-// NewNotificationService creates a new notification service
+// NewNotificationService creates a new notification service that sends through notifiers. Users
+// who want every channel get them in the order given.
 func NewNotificationService(
-	emailService *EmailService,
-	telegramService *TelegramService,
+	notifiers []Notifier,
 	notificationRepo repositories.NotificationRepository,
 	taskRepo repositories.TaskRepository,
+	commentRepo repositories.CommentRepository,
 	userRepo repositories.UserRepository,
+	dueSoonDays int,
 ) *NotificationService {
+	if dueSoonDays < 1 {
+		dueSoonDays = 1
+	}
 	return &NotificationService{
-		emailService:     emailService,
-		telegramService:  telegramService,
+		notifiers:        notifiers,
 		notificationRepo: notificationRepo,
 		taskRepo:         taskRepo,
+		commentRepo:      commentRepo,
 		userRepo:         userRepo,
+		dueSoonDays:      dueSoonDays,
 	}
 }
 
-// CheckAndSendNotifications checks for tasks that need notifications and sends them
+// CheckAndSendNotifications checks for tasks that need notifications and sends them.
+// Runs don't overlap: a run started while another is in progress (e.g. a manual check during a
+// scheduled one) waits for it, so the dedupe table always sees what the previous run sent.
 func (s *NotificationService) CheckAndSendNotifications() error {
+	_, err := s.checkNotifications(false)
+	return err
+}
+
+// PlannedNotification is a due date reminder a notification check would send
+type PlannedNotification struct {
+	UserID    uint                       `json:"user_id" example:"1"`
+	TaskID    uint                       `json:"task_id" example:"12"`
+	TaskTitle string                     `json:"task_title" example:"Pay bills"`
+	Type      models.NotificationType    `json:"type" example:"due_today"`
+	Channel   models.NotificationChannel `json:"channel" example:"telegram"`
+}
+
+// PreviewNotifications is a dry run of CheckAndSendNotifications: it returns the reminders the
+// check would send, through which channels, without sending or recording anything. Every send is
+// assumed to succeed, so with fallback channels only the first usable one is listed.
+func (s *NotificationService) PreviewNotifications() ([]PlannedNotification, error) {
+	return s.checkNotifications(true)
+}
+
+// checkNotifications finds the due date reminders to send and sends them, or with dryRun only
+// returns them
+func (s *NotificationService) checkNotifications(dryRun bool) ([]PlannedNotification, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	defer s.closeConnections()
+
 	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	tomorrow := today.Add(24 * time.Hour)
+	tomorrow, dueSoonEnd := dueWindows(now, s.dueSoonDays)
 
 	log.Printf("Starting notification check at %s", now.Format("2006-01-02 15:04:05"))
-	log.Printf("Today: %s, Tomorrow: %s", today.Format("2006-01-02"), tomorrow.Format("2006-01-02"))
+	log.Printf("Due today until: %s, Due soon until: %s (%d days)", tomorrow.Format("2006-01-02 15:04"), dueSoonEnd.Format("2006-01-02 15:04"), s.dueSoonDays)
 
-	// Get all active tasks (not completed)
+	// Get all active tasks (not completed nor archived)
 	var tasks []models.Task
 	if err := database.DB.
-		Where("completed = ? AND due_date IS NOT NULL", false).
+		Where("completed = ? AND archived = ? AND due_date IS NOT NULL", false, false).
 		Preload("User").
 		Find(&tasks).Error; err != nil {
 		log.Printf("Error fetching tasks: %v", err)
-		return err
+		return nil, err
 	}
 
 	log.Printf("Found %d tasks with due dates", len(tasks))
 
-	processedCount := 0
 	skippedCount := 0
-	notificationCount := 0
-
+	var candidates []models.Task
 	for _, task := range tasks {
 		if task.DueDate == nil {
 			log.Printf("Task %d: skipping (no due date)", task.ID)
@@ -66,8 +107,6 @@ func (s *NotificationService) CheckAndSendNotifications() error {
 			continue
 		}
 
-		dueDate := time.Date(task.DueDate.Year(), task.DueDate.Month(), task.DueDate.Day(), 0, 0, 0, 0, task.DueDate.Location())
-
 		// Check if user has notifications enabled
 		if !task.User.NotificationsEnabled {
 			log.Printf("Task %d: skipping (user notifications disabled)", task.ID)
@@ -76,107 +115,529 @@ func (s *NotificationService) CheckAndSendNotifications() error {
 		}
 
 		log.Printf("Task %d: due_date=%s, user_id=%d, notifications_enabled=%v, email=%s, telegram_chat_id=%v",
-			task.ID, dueDate.Format("2006-01-02"), task.UserID, task.User.NotificationsEnabled,
+			task.ID, task.DueDate.In(now.Location()).Format("2006-01-02 15:04"), task.UserID, task.User.NotificationsEnabled,
 			task.User.Email, task.User.TelegramChatID)
+		candidates = append(candidates, task)
+	}
+
+	reminders := dueReminders(candidates, now, s.dueSoonDays)
+	notificationCount := 0
+	var planned []PlannedNotification
+	for _, reminder := range reminders {
+		task := reminder.task
+		log.Printf("Task %d: %s (due %s)", task.ID, reminder.notificationType, task.DueDate.In(now.Location()).Format("2006-01-02 15:04"))
 
-		// Check for overdue tasks
-		if dueDate.Before(today) {
-			log.Printf("Task %d: OVERDUE (due %s)", task.ID, dueDate.Format("2006-01-02"))
-			s.sendNotification(&task, models.NotificationTypeOverdue, today)
-			notificationCount++
-		} else if dueDate.Equal(today) {
-			log.Printf("Task %d: DUE TODAY", task.ID)
-			s.sendNotification(&task, models.NotificationTypeDueToday, today)
-			notificationCount++
-		} else if dueDate.Equal(tomorrow) {
-			log.Printf("Task %d: DUE SOON (due tomorrow)", task.ID)
-			s.sendNotification(&task, models.NotificationTypeDueSoon, today)
-			notificationCount++
+		if !typeEnabled(&task.User, reminder.notificationType) {
+			log.Printf("Task %d: skipping (user disabled %s notifications)", task.ID, reminder.notificationType)
+			continue
+		}
+		if dryRun {
+			planned = append(planned, s.plannedNotifications(&task.User, task, reminder.notificationType, now)...)
 		} else {
-			log.Printf("Task %d: not due yet (due %s)", task.ID, dueDate.Format("2006-01-02"))
+			s.sendNotification(&task.User, task, nil, reminder.notificationType, now)
 		}
-		processedCount++
+		notificationCount++
 	}
 
-	log.Printf("Notification check completed: %d processed, %d skipped, %d notifications sent", processedCount, skippedCount, notificationCount)
-	return nil
+	if dryRun {
+		log.Printf("Notification dry run completed: %d processed, %d skipped, %d due, %d notifications would be sent", len(candidates), skippedCount, len(reminders), len(planned))
+		return planned, nil
+	}
+	log.Printf("Notification check completed: %d processed, %d skipped, %d due, %d notifications sent", len(candidates), skippedCount, len(reminders), notificationCount)
+	return nil, nil
 }
 
-// sendNotification sends notification via configured channels
-func (s *NotificationService) sendNotification(task *models.Task, notificationType models.NotificationType, date time.Time) {
-	user := task.User
-
-	// Send email notification
-	if user.Email != "" {
-		log.Printf("Checking if email notification already sent for task %d, type %s", task.ID, notificationType)
-		exists, err := s.notificationRepo.Exists(
-			task.UserID,
-			task.ID,
-			notificationType,
-			models.NotificationChannelEmail,
-			date,
-		)
+// plannedNotifications returns the channels sendNotification would send a reminder through,
+// following the same dedupe and fallback rules but without sending anything
+func (s *NotificationService) plannedNotifications(user *models.User, task *models.Task, notificationType models.NotificationType, checkedAt time.Time) []PlannedNotification {
+	var planned []PlannedNotification
+	for _, notifier := range s.userNotifiers(user) {
+		channel := notifier.notifier.Name()
+		if !notifier.notifier.IsConfigured() {
+			continue
+		}
+		if _, err := notifier.notifier.Recipient(user); err != nil {
+			continue
+		}
+		exists, err := s.alreadySent(user.ID, task, nil, notificationType, channel, checkedAt)
 		if err != nil {
-			log.Printf("Error checking email notification existence: %v", err)
-		} else if exists {
-			log.Printf("Email notification already sent today for task %d, skipping", task.ID)
-		} else {
-			log.Printf("Sending email notification for task %d to %s", task.ID, user.Email)
-			if err := s.emailService.SendNotification(&user, task, notificationType); err != nil {
-				log.Printf("Failed to send email notification: %v", err)
-			} else {
-				log.Printf("Email notification sent successfully for task %d", task.ID)
-				// Record notification
-				notification := &models.Notification{
-					UserID:  task.UserID,
-					TaskID:  task.ID,
-					Type:    notificationType,
-					Channel: models.NotificationChannelEmail,
-					SentAt:  time.Now(),
-				}
-				if err := s.notificationRepo.Create(notification); err != nil {
-					log.Printf("Failed to record email notification: %v", err)
-				}
+			log.Printf("Error checking %s notification existence: %v", channel, err)
+			continue
+		}
+		if !exists {
+			planned = append(planned, PlannedNotification{
+				UserID:    user.ID,
+				TaskID:    task.ID,
+				TaskTitle: task.Title,
+				Type:      notificationType,
+				Channel:   channel,
+			})
+		}
+		if notifier.fallback {
+			break
+		}
+	}
+	return planned
+}
+
+// closeConnections ends the connections the notifiers kept open to send the run's notifications
+// (see EmailService keepAlive), so none stays idle until the next run
+func (s *NotificationService) closeConnections() {
+	for _, notifier := range s.notifiers {
+		closer, ok := notifier.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close %s connection: %v", notifier.Name(), err)
+		}
+	}
+}
+
+// dueReminder is a task that gets a due date reminder in the current run
+type dueReminder struct {
+	task             *models.Task
+	notificationType models.NotificationType
+}
+
+// dueWindows returns the end of today (tomorrow's midnight) and the end of the due soon window
+func dueWindows(now time.Time, dueSoonDays int) (time.Time, time.Time) {
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	return tomorrow, tomorrow.AddDate(0, 0, dueSoonDays)
+}
+
+// dueReminderType classifies a due date by its exact timestamp, checking the most urgent bucket
+// first: due at or before now is overdue, before tomorrow's midnight is due today and before
+// dueSoonEnd is due soon. A due date at midnight has no time of day (see isDateOnly): the task is
+// due during that whole day, so it is due today on that day and overdue only from the next one.
+// "" means no reminder.
+func dueReminderType(dueDate, now, tomorrow, dueSoonEnd time.Time) models.NotificationType {
+	deadline := dueDate
+	if isDateOnly(dueDate, now.Location()) {
+		deadline = dueDate.AddDate(0, 0, 1)
+	}
+	switch {
+	case !deadline.After(now):
+		return models.NotificationTypeOverdue
+	case dueDate.Before(tomorrow):
+		return models.NotificationTypeDueToday
+	case dueDate.Before(dueSoonEnd):
+		return models.NotificationTypeDueSoon
+	default:
+		return ""
+	}
+}
+
+// isDateOnly reports whether a due date falls exactly on midnight in loc, which is how tasks due on
+// a day, without a time, are stored
+func isDateOnly(dueDate time.Time, loc *time.Location) bool {
+	local := dueDate.In(loc)
+	return local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0 && local.Nanosecond() == 0
+}
+
+// reminderUrgency ranks the due date reminders, most urgent highest
+var reminderUrgency = map[models.NotificationType]int{
+	models.NotificationTypeDueSoon:  1,
+	models.NotificationTypeDueToday: 2,
+	models.NotificationTypeOverdue:  3,
+}
+
+// dueReminders returns one reminder per task ID, in the order the tasks were given. If the same
+// task is listed more than once, only its most urgent reminder is kept.
+func dueReminders(tasks []models.Task, now time.Time, dueSoonDays int) []dueReminder {
+	tomorrow, dueSoonEnd := dueWindows(now, dueSoonDays)
+
+	var reminders []dueReminder
+	index := make(map[uint]int)
+	for i := range tasks {
+		task := &tasks[i]
+		if task.DueDate == nil {
+			continue
+		}
+		notificationType := dueReminderType(*task.DueDate, now, tomorrow, dueSoonEnd)
+		if notificationType == "" {
+			continue
+		}
+		if existing, ok := index[task.ID]; ok {
+			if reminderUrgency[notificationType] > reminderUrgency[reminders[existing].notificationType] {
+				reminders[existing] = dueReminder{task: task, notificationType: notificationType}
 			}
+			continue
+		}
+		index[task.ID] = len(reminders)
+		reminders = append(reminders, dueReminder{task: task, notificationType: notificationType})
+	}
+	return reminders
+}
+
+// SetPaused turns the event notifications (assigned, comment) off or back on server-wide. The
+// Scheduler keeps it in sync with its own state, so NOTIFICATIONS_ENABLED=false and an admin pause
+// stop every notification, not only the due date reminders.
+func (s *NotificationService) SetPaused(paused bool) {
+	s.paused.Store(paused)
+}
+
+// NotifyAssigned tells the owner of a task that someone else created it for them. It returns
+// immediately and sends in the background; each channel is notified only once per task.
+func (s *NotificationService) NotifyAssigned(task *models.Task) {
+	if task.AssignedBy == nil {
+		return
+	}
+	if s.paused.Load() {
+		log.Printf("Task %d: skipping assigned notification (notifications paused)", task.ID)
+		return
+	}
+	if !task.User.NotificationsEnabled {
+		log.Printf("Task %d: skipping assigned notification (user notifications disabled)", task.ID)
+		return
+	}
+
+	assigned := *task
+	go s.sendNotification(&assigned.User, &assigned, nil, models.NotificationTypeAssigned, time.Now())
+}
+
+// NotifyCommented tells the recipients that a comment was added to a task. It returns immediately
+// and sends in the background; each recipient and channel is notified only once per comment.
+func (s *NotificationService) NotifyCommented(task *models.Task, comment *models.Comment, recipients []models.User) {
+	if s.paused.Load() {
+		log.Printf("Comment %d: skipping notifications (notifications paused)", comment.ID)
+		return
+	}
+
+	commented := *task
+	added := *comment
+	for i := range recipients {
+		recipient := recipients[i]
+		if !recipient.NotificationsEnabled {
+			log.Printf("Comment %d: skipping notification to user %d (user notifications disabled)", comment.ID, recipient.ID)
+			continue
 		}
-	} else {
-		log.Printf("Task %d: user has no email address, skipping email notification", task.ID)
+		go s.sendNotification(&recipient, &commented, &added, models.NotificationTypeComment, time.Now())
 	}
+}
+
+// alreadySent checks the dedupe table. Due date reminders go out at most once a day, assigned
+// notifications only once per task and comment notifications only once per comment.
+func (s *NotificationService) alreadySent(userID uint, task *models.Task, comment *models.Comment, notificationType models.NotificationType, channel models.NotificationChannel, checkedAt time.Time) (bool, error) {
+	switch {
+	case comment != nil:
+		return s.notificationRepo.ExistsForComment(userID, comment.ID, channel)
+	case notificationType == models.NotificationTypeAssigned:
+		return s.notificationRepo.ExistsAnyDay(userID, task.ID, notificationType, channel)
+	default:
+		return s.notificationRepo.Exists(userID, task.ID, notificationType, channel, checkedAt)
+	}
+}
 
-	// Send Telegram notification
-	if user.TelegramChatID != nil && *user.TelegramChatID != "" {
-		log.Printf("Checking if telegram notification already sent for task %d, type %s", task.ID, notificationType)
-		exists, err := s.notificationRepo.Exists(
-			task.UserID,
-			task.ID,
-			notificationType,
-			models.NotificationChannelTelegram,
-			date,
-		)
+// sendNotification sends a notification to the user via their configured channels, in the order
+// set by the user's preferred channels. comment is set only for comment notifications.
+// checkedAt is used both for the dedupe lookup and as SentAt, so a run that crosses midnight
+// records notifications on the same day it checked for them.
+func (s *NotificationService) sendNotification(user *models.User, task *models.Task, comment *models.Comment, notificationType models.NotificationType, checkedAt time.Time) {
+	for _, notifier := range s.userNotifiers(user) {
+		if s.sendToChannel(user, task, comment, notificationType, notifier.notifier, checkedAt) && notifier.fallback {
+			return
+		}
+	}
+}
+
+// userNotifier is a notifier the user receives notifications through. fallback means the next
+// notifiers are tried only if this one fails.
+type userNotifier struct {
+	notifier Notifier
+	fallback bool
+}
+
+// userNotifiers returns the notifiers for the user's preferred channels, in order. Users who want
+// every channel (or whose preference can't be parsed) get all notifiers.
+func (s *NotificationService) userNotifiers(user *models.User) []userNotifier {
+	channels, fallback, err := ParsePreferredChannels(user.PreferredChannels)
+	if err != nil {
+		log.Printf("User %d: %v, sending to every channel", user.ID, err)
+		fallback = false
+	}
+
+	var notifiers []userNotifier
+	if !fallback {
+		for _, notifier := range s.notifiers {
+			notifiers = append(notifiers, userNotifier{notifier: notifier})
+		}
+		return notifiers
+	}
+	for _, channel := range channels {
+		if notifier := s.notifier(channel); notifier != nil {
+			notifiers = append(notifiers, userNotifier{notifier: notifier, fallback: true})
+		}
+	}
+	return notifiers
+}
+
+// notifier returns the registered notifier of the channel, or nil
+func (s *NotificationService) notifier(channel models.NotificationChannel) Notifier {
+	for _, notifier := range s.notifiers {
+		if notifier.Name() == channel {
+			return notifier
+		}
+	}
+	return nil
+}
+
+// sendToChannel sends a notification through a single channel and records it. It returns true when
+// the user has the notification on that channel, either sent now or already sent before.
+func (s *NotificationService) sendToChannel(user *models.User, task *models.Task, comment *models.Comment, notificationType models.NotificationType, notifier Notifier, checkedAt time.Time) bool {
+	channel := notifier.Name()
+	recipient, err := notifier.Recipient(user)
+	if err != nil {
+		log.Printf("Task %d: user %d: %v, skipping %s notification", task.ID, user.ID, err, channel)
+		return false
+	}
+
+	log.Printf("Checking if %s notification already sent for task %d, type %s", channel, task.ID, notificationType)
+	exists, err := s.alreadySent(user.ID, task, comment, notificationType, channel, checkedAt)
+	if err != nil {
+		log.Printf("Error checking %s notification existence: %v", channel, err)
+		return false
+	}
+	if exists {
+		log.Printf("%s notification already sent for task %d, skipping", channel, task.ID)
+		return true
+	}
+
+	log.Printf("Sending %s notification for task %d to %s", channel, task.ID, recipient)
+	message, err := notifier.Send(context.Background(), user, task, comment, notificationType)
+	if err != nil {
+		log.Printf("Failed to send %s notification: %v", channel, err)
+		return false
+	}
+	log.Printf("%s notification sent successfully for task %d", channel, task.ID)
+
+	if _, err := s.record(user.ID, task, comment, notificationType, channel, checkedAt, message); err != nil {
+		log.Printf("Failed to record %s notification: %v", channel, err)
+	}
+	return true
+}
+
+// record adds a sent notification to the notifications table, which is also the dedupe table
+func (s *NotificationService) record(userID uint, task *models.Task, comment *models.Comment, notificationType models.NotificationType, channel models.NotificationChannel, sentAt time.Time, message Message) (*models.Notification, error) {
+	var commentID *uint
+	if comment != nil {
+		commentID = &comment.ID
+	}
+	notification := &models.Notification{
+		UserID:    userID,
+		TaskID:    task.ID,
+		CommentID: commentID,
+		Type:      notificationType,
+		Channel:   channel,
+		SentAt:    sentAt.UTC(),
+		Subject:   message.Subject,
+		Body:      message.Body,
+	}
+	// The notification is returned even when it can't be saved, since it was sent
+	return notification, s.notificationRepo.Create(notification)
+}
+
+// Resend sends a notification again, through the channel it was sent on, and records it as a new
+// notification. It is an explicit request of the user, so the dedupe table is not checked. Once the
+// message is sent, a failure to record it is only logged: the returned notification then has no ID.
+func (s *NotificationService) Resend(notification *models.Notification) (*models.Notification, error) {
+	notifier := s.notifier(notification.Channel)
+	if notifier == nil {
+		return nil, fmt.Errorf("%s channel is not available", notification.Channel)
+	}
+
+	user, err := s.userRepo.FindByID(notification.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	task, err := s.taskRepo.FindByID(context.Background(), notification.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+	var comment *models.Comment
+	if notification.CommentID != nil {
+		comment, err = s.commentRepo.FindByID(*notification.CommentID)
 		if err != nil {
-			log.Printf("Error checking telegram notification existence: %v", err)
-		} else if exists {
-			log.Printf("Telegram notification already sent today for task %d, skipping", task.ID)
+			return nil, fmt.Errorf("failed to load comment: %w", err)
+		}
+	}
+
+	sentAt := time.Now()
+	message, err := notifier.Send(context.Background(), user, task, comment, notification.Type)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%s notification %d resent for task %d", notification.Channel, notification.ID, task.ID)
+
+	resent, err := s.record(user.ID, task, comment, notification.Type, notification.Channel, sentAt, message)
+	if err != nil {
+		log.Printf("Failed to record resent %s notification %d: %v", notification.Channel, notification.ID, err)
+	}
+	return resent, nil
+}
+
+// allChannels are the channels used, in this order, when the user wants every channel
+var allChannels = []models.NotificationChannel{models.NotificationChannelEmail, models.NotificationChannelTelegram}
+
+// ParsePreferredChannels parses a User.PreferredChannels value. "both" (or empty) returns every
+// channel with fallback false: the notification goes to all of them. A comma-separated list such
+// as "telegram,email" returns the channels in that order with fallback true: each one is tried
+// only if the previous ones failed.
+func ParsePreferredChannels(value string) ([]models.NotificationChannel, bool, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == models.PreferredChannelsBoth {
+		return allChannels, false, nil
+	}
+
+	var channels []models.NotificationChannel
+	seen := make(map[models.NotificationChannel]bool)
+	for _, part := range strings.Split(value, ",") {
+		channel := models.NotificationChannel(strings.TrimSpace(part))
+		switch channel {
+		case models.NotificationChannelEmail, models.NotificationChannelTelegram:
+		default:
+			return nil, false, fmt.Errorf("invalid preferred channel %q (expected email or telegram)", part)
+		}
+		if seen[channel] {
+			return nil, false, fmt.Errorf("preferred channel %q is listed more than once", channel)
+		}
+		seen[channel] = true
+		channels = append(channels, channel)
+	}
+	return channels, true, nil
+}
+
+// ChannelResult reports the outcome of sending a message through one channel
+type ChannelResult struct {
+	Channel models.NotificationChannel `json:"channel" example:"telegram"`
+	Success bool                       `json:"success" example:"true"`
+	Error   string                     `json:"error,omitempty" example:"chat not found: user needs to send a message to the bot first"`
+}
+
+// SendTestMessage sends a fixed test message to every channel of the user immediately.
+// It bypasses the due date logic and the dedupe table and nothing is recorded.
+func (s *NotificationService) SendTestMessage(user *models.User) []ChannelResult {
+	results := make([]ChannelResult, 0, len(s.notifiers))
+	for _, notifier := range s.notifiers {
+		result := ChannelResult{Channel: notifier.Name()}
+		if err := notifier.SendTest(context.Background(), user); err != nil {
+			result.Error = err.Error()
 		} else {
-			log.Printf("Sending telegram notification for task %d to chat %s", task.ID, *user.TelegramChatID)
-			if err := s.telegramService.SendNotification(*user.TelegramChatID, task, notificationType); err != nil {
-				log.Printf("Failed to send telegram notification: %v", err)
-			} else {
-				log.Printf("Telegram notification sent successfully for task %d", task.ID)
-				// Record notification
-				notification := &models.Notification{
-					UserID:  task.UserID,
-					TaskID:  task.ID,
-					Type:    notificationType,
-					Channel: models.NotificationChannelTelegram,
-					SentAt:  time.Now(),
-				}
-				if err := s.notificationRepo.Create(notification); err != nil {
-					log.Printf("Failed to record telegram notification: %v", err)
-				}
-			}
+			result.Success = true
 		}
-	} else {
-		log.Printf("Task %d: user has no telegram chat ID, skipping telegram notification", task.ID)
+		results = append(results, result)
+	}
+
+	log.Printf("Test message sent to user %d: %+v", user.ID, results)
+	return results
+}
+
+// ChannelsConfigured reports, for every registered channel, whether the server has the settings
+// needed to send through it
+func (s *NotificationService) ChannelsConfigured() map[models.NotificationChannel]bool {
+	configured := make(map[models.NotificationChannel]bool, len(s.notifiers))
+	for _, notifier := range s.notifiers {
+		configured[notifier.Name()] = notifier.IsConfigured()
+	}
+	return configured
+}
+
+// SendTestMessageTo sends the fixed test message to a single channel of the user, so each channel
+// can be checked on its own. A channel with no registered notifier is reported as failed.
+func (s *NotificationService) SendTestMessageTo(user *models.User, channel models.NotificationChannel) ChannelResult {
+	result := ChannelResult{Channel: channel, Error: fmt.Sprintf("%s channel is not available", channel)}
+	for _, notifier := range s.notifiers {
+		if notifier.Name() != channel {
+			continue
+		}
+		result.Error = ""
+		if err := notifier.SendTest(context.Background(), user); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		break
+	}
+
+	log.Printf("Test message sent to user %d: %+v", user.ID, result)
+	return result
+}
+
+// dueSoonLabel describes how far away a due soon task is ("amanhã" or "em N dias" in Portuguese)
+func dueSoonLabel(dueDate *time.Time, lang models.Language) string {
+	if dueDate == nil {
+		return translate(lang, "soon")
+	}
+	now := time.Now()
+	local := dueDate.In(now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	due := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, now.Location())
+	days := int(due.Sub(today).Hours() / 24)
+	if days <= 1 {
+		return translate(lang, "tomorrow")
+	}
+	return translate(lang, "in_days", days)
+}
+
+// assignerName returns the username of whoever created the task for its owner
+func assignerName(task *models.Task, lang models.Language) string {
+	if task.AssignedByUser != nil && task.AssignedByUser.Username != "" {
+		return task.AssignedByUser.Username
+	}
+	return translate(lang, "someone")
+}
+
+// typeEnabled reports whether the user wants reminders of the given type. Only the due date
+// reminders can be turned off individually; other types follow NotificationsEnabled alone.
+func typeEnabled(user *models.User, notificationType models.NotificationType) bool {
+	switch notificationType {
+	case models.NotificationTypeDueSoon:
+		return user.NotifyDueSoon
+	case models.NotificationTypeDueToday:
+		return user.NotifyDueToday
+	case models.NotificationTypeOverdue:
+		return user.NotifyOverdue
+	default:
+		return true
+	}
+}
+
+// taskURL returns the link to a task in the web app, or "" when no base URL is configured
+func taskURL(baseURL string, task *models.Task) string {
+	if baseURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/tasks/%d", baseURL, task.ID)
+}
+
+// commentSnippetLength is how many characters of a comment are quoted in its notification
+const commentSnippetLength = 200
+
+// commentSnippet returns the start of a comment's content, shortened to commentSnippetLength characters
+func commentSnippet(comment *models.Comment) string {
+	content := []rune(strings.TrimSpace(comment.Content))
+	if len(content) <= commentSnippetLength {
+		return string(content)
+	}
+	return strings.TrimSpace(string(content[:commentSnippetLength])) + "…"
+}
+
+// commentAuthorName returns the username of a comment's author
+func commentAuthorName(comment *models.Comment, lang models.Language) string {
+	if comment.User.Username != "" {
+		return comment.User.Username
+	}
+	return translate(lang, "someone")
+}
+
+// formatDueDate formats a due date in local time, with the time of day unless the due date is date-only
+func formatDueDate(dueDate *time.Time, lang models.Language) string {
+	if dueDate == nil {
+		return translate(lang, "no_due_date")
+	}
+	local := dueDate.In(time.Local)
+	if isDateOnly(local, time.Local) {
+		return local.Format(translate(lang, "date_format"))
 	}
+	return local.Format(translate(lang, "date_time_format"))
 }
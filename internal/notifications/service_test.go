@@ -0,0 +1,333 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDueReminders(t *testing.T) {
+	now := time.Date(2024, 12, 10, 15, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(2024, 12, 11, 0, 0, 0, 0, time.UTC)
+	taskDue := func(id uint, due time.Time) models.Task {
+		return models.Task{ID: id, Title: "Task", DueDate: &due}
+	}
+	typeOf := func(due time.Time) models.NotificationType {
+		reminders := dueReminders([]models.Task{taskDue(1, due)}, now, 1)
+		if len(reminders) == 0 {
+			return ""
+		}
+		return reminders[0].notificationType
+	}
+
+	t.Run("A task due exactly now is overdue, not due today", func(t *testing.T) {
+		assert.Equal(t, models.NotificationTypeOverdue, typeOf(now))
+		assert.Equal(t, models.NotificationTypeDueToday, typeOf(now.Add(time.Second)))
+	})
+
+	t.Run("A task due exactly at midnight belongs to the next day", func(t *testing.T) {
+		assert.Equal(t, models.NotificationTypeDueToday, typeOf(tomorrow.Add(-time.Second)))
+		assert.Equal(t, models.NotificationTypeDueSoon, typeOf(tomorrow))
+	})
+
+	t.Run("A date-only task is due today during its whole day", func(t *testing.T) {
+		today := time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, models.NotificationTypeDueToday, typeOf(today))
+		assert.Equal(t, models.NotificationTypeOverdue, typeOf(today.AddDate(0, 0, -1)))
+		// A time of day, even a second after midnight, is taken as it is
+		assert.Equal(t, models.NotificationTypeOverdue, typeOf(today.Add(time.Second)))
+	})
+
+	t.Run("The due soon window ends at midnight after dueSoonDays days", func(t *testing.T) {
+		assert.Equal(t, models.NotificationTypeDueSoon, typeOf(tomorrow.AddDate(0, 0, 1).Add(-time.Second)))
+		assert.Equal(t, models.NotificationType(""), typeOf(tomorrow.AddDate(0, 0, 1)))
+	})
+
+	t.Run("A task listed twice gets a single reminder, the most urgent one", func(t *testing.T) {
+		tasks := []models.Task{
+			taskDue(1, tomorrow),
+			taskDue(2, now.Add(time.Hour)),
+			taskDue(1, now),
+			taskDue(2, now.Add(time.Hour)),
+		}
+
+		reminders := dueReminders(tasks, now, 1)
+		if assert.Len(t, reminders, 2) {
+			assert.Equal(t, uint(1), reminders[0].task.ID)
+			assert.Equal(t, models.NotificationTypeOverdue, reminders[0].notificationType)
+			assert.Equal(t, uint(2), reminders[1].task.ID)
+			assert.Equal(t, models.NotificationTypeDueToday, reminders[1].notificationType)
+		}
+	})
+}
+
+func TestFormatDueDate(t *testing.T) {
+	t.Run("A date-only due date is shown without the time", func(t *testing.T) {
+		due := time.Date(2024, 12, 10, 0, 0, 0, 0, time.Local)
+		assert.Equal(t, "10/12/2024", formatDueDate(&due, models.LanguagePortuguese))
+	})
+
+	t.Run("Any other due date includes the time", func(t *testing.T) {
+		due := time.Date(2024, 12, 10, 17, 30, 0, 0, time.Local)
+		assert.Equal(t, "10/12/2024 17:30", formatDueDate(&due, models.LanguagePortuguese))
+	})
+}
+
+// fakeNotifier records the messages it is asked to send
+type fakeNotifier struct {
+	name  models.NotificationChannel
+	err   error
+	tests int
+	sends int
+}
+
+func (n *fakeNotifier) Name() models.NotificationChannel { return n.name }
+
+func (n *fakeNotifier) IsConfigured() bool { return n.err == nil }
+
+func (n *fakeNotifier) Recipient(user *models.User) (string, error) { return user.Username, nil }
+
+func (n *fakeNotifier) Send(ctx context.Context, user *models.User, task *models.Task, comment *models.Comment, notificationType models.NotificationType) (Message, error) {
+	n.sends++
+	return Message{}, n.err
+}
+
+func (n *fakeNotifier) SendTest(ctx context.Context, user *models.User) error {
+	n.tests++
+	return n.err
+}
+
+func TestUserNotifiers(t *testing.T) {
+	email := &fakeNotifier{name: models.NotificationChannelEmail}
+	telegram := &fakeNotifier{name: models.NotificationChannelTelegram, err: errors.New("chat not found")}
+	service := NewNotificationService([]Notifier{email, telegram}, nil, nil, nil, nil, 1)
+
+	names := func(preferred string) ([]models.NotificationChannel, []bool) {
+		var channels []models.NotificationChannel
+		var fallbacks []bool
+		for _, notifier := range service.userNotifiers(&models.User{PreferredChannels: preferred}) {
+			channels = append(channels, notifier.notifier.Name())
+			fallbacks = append(fallbacks, notifier.fallback)
+		}
+		return channels, fallbacks
+	}
+
+	t.Run("Every channel, in registration order", func(t *testing.T) {
+		channels, fallbacks := names(models.PreferredChannelsBoth)
+		assert.Equal(t, []models.NotificationChannel{models.NotificationChannelEmail, models.NotificationChannelTelegram}, channels)
+		assert.Equal(t, []bool{false, false}, fallbacks)
+	})
+
+	t.Run("Preferred channels in the user's order, as fallbacks", func(t *testing.T) {
+		channels, fallbacks := names("telegram,email")
+		assert.Equal(t, []models.NotificationChannel{models.NotificationChannelTelegram, models.NotificationChannelEmail}, channels)
+		assert.Equal(t, []bool{true, true}, fallbacks)
+	})
+
+	t.Run("Unregistered channels are skipped", func(t *testing.T) {
+		emailOnly := NewNotificationService([]Notifier{email}, nil, nil, nil, nil, 1)
+		notifiers := emailOnly.userNotifiers(&models.User{PreferredChannels: "telegram,email"})
+		if assert.Len(t, notifiers, 1) {
+			assert.Equal(t, models.NotificationChannelEmail, notifiers[0].notifier.Name())
+		}
+	})
+
+	t.Run("Test message goes through every notifier", func(t *testing.T) {
+		results := service.SendTestMessage(&models.User{Username: "ana"})
+		assert.Equal(t, []ChannelResult{
+			{Channel: models.NotificationChannelEmail, Success: true},
+			{Channel: models.NotificationChannelTelegram, Error: "chat not found"},
+		}, results)
+		assert.Equal(t, 1, email.tests)
+		assert.Equal(t, 1, telegram.tests)
+	})
+
+	t.Run("Configured channels", func(t *testing.T) {
+		assert.Equal(t, map[models.NotificationChannel]bool{
+			models.NotificationChannelEmail:    true,
+			models.NotificationChannelTelegram: false,
+		}, service.ChannelsConfigured())
+	})
+
+	t.Run("Test message to a single channel", func(t *testing.T) {
+		user := &models.User{Username: "ana"}
+		assert.Equal(t, ChannelResult{Channel: models.NotificationChannelTelegram, Error: "chat not found"},
+			service.SendTestMessageTo(user, models.NotificationChannelTelegram))
+		assert.Equal(t, 1, email.tests)
+		assert.Equal(t, 2, telegram.tests)
+
+		emailOnly := NewNotificationService([]Notifier{email}, nil, nil, nil, nil, 1)
+		result := emailOnly.SendTestMessageTo(user, models.NotificationChannelTelegram)
+		assert.False(t, result.Success)
+		assert.Equal(t, "telegram channel is not available", result.Error)
+	})
+}
+
+func TestPreviewNotifications(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "preview.db")), &gorm.Config{NowFunc: database.NowUTC})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.Task{}, &models.Tag{}, &models.Comment{}, &models.Notification{}))
+	database.DB = db
+
+	email := &fakeNotifier{name: models.NotificationChannelEmail}
+	telegram := &fakeNotifier{name: models.NotificationChannelTelegram}
+	service := NewNotificationService([]Notifier{email, telegram}, repositories.NewNotificationRepository(), nil, nil, nil, 1)
+
+	createUser := func(username, preferred string) models.User {
+		user := models.User{Username: username, Email: username + "@example.com", Password: "hashed", PreferredChannels: preferred}
+		db.Create(&user)
+		return user
+	}
+	both := createUser("ana", models.PreferredChannelsBoth)
+	fallback := createUser("bia", "telegram,email")
+	disabled := createUser("caio", models.PreferredChannelsBoth)
+	db.Model(&disabled).Update("notifications_enabled", false)
+
+	overdue := time.Now().Add(-time.Hour)
+	createTask := func(title string, userID uint) models.Task {
+		task := models.Task{Title: title, Type: models.TaskTypeCasa, UserID: userID, DueDate: &overdue}
+		db.Create(&task)
+		return task
+	}
+	bothTask := createTask("Pay bills", both.ID)
+	fallbackTask := createTask("Call mom", fallback.ID)
+	createTask("Walk the dog", disabled.ID)
+	db.Create(&models.Notification{UserID: both.ID, TaskID: bothTask.ID, Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelEmail, SentAt: time.Now()})
+
+	planned, err := service.PreviewNotifications()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []PlannedNotification{
+		{UserID: both.ID, TaskID: bothTask.ID, TaskTitle: "Pay bills", Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelTelegram},
+		{UserID: fallback.ID, TaskID: fallbackTask.ID, TaskTitle: "Call mom", Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelTelegram},
+	}, planned)
+
+	t.Run("Nothing is sent or recorded", func(t *testing.T) {
+		assert.Equal(t, 0, email.sends)
+		assert.Equal(t, 0, telegram.sends)
+		var count int64
+		db.Model(&models.Notification{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("An unconfigured channel falls back to the next one", func(t *testing.T) {
+		telegram.err = errors.New("telegram bot token not configured")
+		planned, err := service.PreviewNotifications()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []PlannedNotification{
+			{UserID: fallback.ID, TaskID: fallbackTask.ID, TaskTitle: "Call mom", Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelEmail},
+		}, planned)
+	})
+}
+
+func TestNotifyPaused(t *testing.T) {
+	email := &fakeNotifier{name: models.NotificationChannelEmail}
+	service := NewNotificationService([]Notifier{email}, nil, nil, nil, nil, 1)
+	service.SetPaused(true)
+	user := models.User{ID: 1, NotificationsEnabled: true}
+
+	assignedBy := uint(2)
+	task := &models.Task{ID: 1, UserID: 1, AssignedBy: &assignedBy, User: user}
+	service.NotifyAssigned(task)
+	service.NotifyCommented(task, &models.Comment{ID: 1, TaskID: 1, UserID: 2}, []models.User{user})
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, email.sends)
+}
+
+// failingNotificationRepository fails to save notifications
+type failingNotificationRepository struct {
+	repositories.NotificationRepository
+}
+
+func (r *failingNotificationRepository) Create(notification *models.Notification) error {
+	return errors.New("database is locked")
+}
+
+func TestResend(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "resend.db")), &gorm.Config{NowFunc: database.NowUTC})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.Task{}, &models.Tag{}, &models.Comment{}, &models.Notification{}))
+	database.DB = db
+
+	email := &fakeNotifier{name: models.NotificationChannelEmail}
+	newService := func(notificationRepo repositories.NotificationRepository) *NotificationService {
+		return NewNotificationService([]Notifier{email}, notificationRepo, repositories.NewTaskRepository(), repositories.NewCommentRepository(), repositories.NewUserRepository(), 1)
+	}
+	service := newService(repositories.NewNotificationRepository())
+
+	user := models.User{Username: "ana", Email: "ana@example.com", Password: "hashed"}
+	db.Create(&user)
+	overdue := time.Now().Add(-time.Hour)
+	task := models.Task{Title: "Pay bills", Type: models.TaskTypeCasa, UserID: user.ID, DueDate: &overdue}
+	db.Create(&task)
+	// The overdue reminder was already sent today, so a notification check would skip it
+	original := models.Notification{UserID: user.ID, TaskID: task.ID, Type: models.NotificationTypeOverdue, Channel: models.NotificationChannelEmail, SentAt: time.Now()}
+	db.Create(&original)
+
+	countNotifications := func() int64 {
+		var count int64
+		db.Model(&models.Notification{}).Count(&count)
+		return count
+	}
+
+	t.Run("Sends again and records a new notification", func(t *testing.T) {
+		sends := email.sends
+		resent, err := service.Resend(&original)
+		assert.NoError(t, err)
+		assert.Equal(t, sends+1, email.sends)
+		if assert.NotNil(t, resent) {
+			assert.NotZero(t, resent.ID)
+			assert.NotEqual(t, original.ID, resent.ID)
+			assert.Equal(t, models.NotificationTypeOverdue, resent.Type)
+			assert.Equal(t, models.NotificationChannelEmail, resent.Channel)
+		}
+		assert.Equal(t, int64(2), countNotifications())
+	})
+
+	t.Run("Loads the comment of a comment notification", func(t *testing.T) {
+		comment := models.Comment{Content: "Done?", TaskID: task.ID, UserID: user.ID}
+		db.Create(&comment)
+		commented := models.Notification{UserID: user.ID, TaskID: task.ID, CommentID: &comment.ID, Type: models.NotificationTypeComment, Channel: models.NotificationChannelEmail, SentAt: time.Now()}
+		db.Create(&commented)
+
+		resent, err := service.Resend(&commented)
+		assert.NoError(t, err)
+		if assert.NotNil(t, resent) && assert.NotNil(t, resent.CommentID) {
+			assert.Equal(t, comment.ID, *resent.CommentID)
+		}
+	})
+
+	t.Run("A failed send is an error and nothing is recorded", func(t *testing.T) {
+		email.err = errors.New("smtp unavailable")
+		defer func() { email.err = nil }()
+		before := countNotifications()
+		resent, err := service.Resend(&original)
+		assert.Error(t, err)
+		assert.Nil(t, resent)
+		assert.Equal(t, before, countNotifications())
+	})
+
+	t.Run("A sent notification that can't be recorded is still returned", func(t *testing.T) {
+		sends := email.sends
+		resent, err := newService(&failingNotificationRepository{}).Resend(&original)
+		assert.NoError(t, err)
+		assert.Equal(t, sends+1, email.sends)
+		if assert.NotNil(t, resent) {
+			assert.Zero(t, resent.ID)
+			assert.Equal(t, task.ID, resent.TaskID)
+		}
+	})
+}
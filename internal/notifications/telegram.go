@@ -2,38 +2,102 @@ package notifications
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"todo-go-backend/internal/models"
 )
 
 // TelegramService handles Telegram notifications
 type TelegramService struct {
-	botToken string
-	apiURL   string
+	botToken        string
+	apiURL          string
+	frontendBaseURL string // Used to link to the task; empty omits the link
 }
 
-// NewTelegramService creates a new Telegram service
-func NewTelegramService(botToken string) *TelegramService {
+// NewTelegramService creates a new Telegram service. frontendBaseURL is the web app address used
+// to link to tasks in the messages; empty omits the links.
+func NewTelegramService(botToken, frontendBaseURL string) *TelegramService {
 	return &TelegramService{
-		botToken: botToken,
-		apiURL:   "https://api.telegram.org/bot" + botToken,
+		botToken:        botToken,
+		apiURL:          "https://api.telegram.org/bot" + botToken,
+		frontendBaseURL: frontendBaseURL,
 	}
 }
 
-// SendNotification sends a notification via Telegram
-func (s *TelegramService) SendNotification(chatID string, task *models.Task, notificationType models.NotificationType) error {
+// Name returns the Telegram channel
+func (s *TelegramService) Name() models.NotificationChannel {
+	return models.NotificationChannelTelegram
+}
+
+// Recipient returns the user's Telegram chat
+func (s *TelegramService) Recipient(user *models.User) (string, error) {
+	if user.TelegramChatID == nil || *user.TelegramChatID == "" {
+		return "", errors.New("user has no telegram chat ID configured")
+	}
+	return "chat " + *user.TelegramChatID, nil
+}
+
+// Send sends a notification to the user's Telegram chat. comment is set only for comment notifications.
+func (s *TelegramService) Send(ctx context.Context, user *models.User, task *models.Task, comment *models.Comment, notificationType models.NotificationType) (Message, error) {
+	if _, err := s.Recipient(user); err != nil {
+		return Message{}, err
+	}
+	message, err := s.SendNotification(*user.TelegramChatID, user.TelegramThreadID, user.Language, task, comment, notificationType)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Body: message}, nil
+}
+
+// SendTest sends the test message to the user's Telegram chat
+func (s *TelegramService) SendTest(ctx context.Context, user *models.User) error {
+	if _, err := s.Recipient(user); err != nil {
+		return err
+	}
+	return s.SendMessage(
+		*user.TelegramChatID,
+		user.TelegramThreadID,
+		fmt.Sprintf("🔔 <b>%s</b>\n\n%s", translate(user.Language, "test_title"), translate(user.Language, "test_telegram")),
+	)
+}
+
+// SendNotification sends a notification via Telegram, written in lang, and returns the message sent. comment
+// is set only for comment notifications. threadID routes the message to a topic of a supergroup; nil sends it
+// to the general thread.
+func (s *TelegramService) SendNotification(chatID string, threadID *int, lang models.Language, task *models.Task, comment *models.Comment, notificationType models.NotificationType) (string, error) {
 	if s.botToken == "" {
-		return fmt.Errorf("telegram bot token not configured")
+		return "", fmt.Errorf("telegram bot token not configured")
 	}
 
 	if chatID == "" {
-		return fmt.Errorf("user telegram chat ID not configured")
+		return "", fmt.Errorf("user telegram chat ID not configured")
+	}
+
+	var message string
+	if comment != nil {
+		message = s.buildCommentMessage(task, comment, lang)
+	} else {
+		message = s.buildMessage(task, notificationType, lang)
+	}
+
+	if err := s.SendMessage(chatID, threadID, message); err != nil {
+		return "", err
 	}
+	return message, nil
+}
 
-	message := s.buildMessage(task, notificationType)
+// SendMessage sends an HTML formatted text message to a Telegram chat (and topic, if threadID is set)
+func (s *TelegramService) SendMessage(chatID string, threadID *int, message string) error {
+	if s.botToken == "" {
+		return fmt.Errorf("telegram bot token not configured")
+	}
 
 	url := fmt.Sprintf("%s/sendMessage", s.apiURL)
 	
@@ -42,6 +106,9 @@ func (s *TelegramService) SendNotification(chatID string, task *models.Task, not
 		"text":    message,
 		"parse_mode": "HTML",
 	}
+	if threadID != nil {
+		payload["message_thread_id"] = *threadID
+	}
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -86,42 +153,123 @@ func (s *TelegramService) SendNotification(chatID string, task *models.Task, not
 	return nil
 }
 
-// buildMessage builds Telegram message based on notification type
-func (s *TelegramService) buildMessage(task *models.Task, notificationType models.NotificationType) string {
+// buildMessage builds Telegram message based on notification type, in the given language
+func (s *TelegramService) buildMessage(task *models.Task, notificationType models.NotificationType, lang models.Language) string {
 	var emoji string
 	var title string
 
 	switch notificationType {
 	case models.NotificationTypeDueSoon:
 		emoji = "⏰"
-		title = "Tarefa vence amanhã!"
+		title = translate(lang, "due_soon", dueSoonLabel(task.DueDate, lang))
 	case models.NotificationTypeDueToday:
 		emoji = "📅"
-		title = "Tarefa vence hoje!"
+		title = translate(lang, "due_today")
 	case models.NotificationTypeOverdue:
 		emoji = "⚠️"
-		title = "Tarefa atrasada!"
+		title = translate(lang, "overdue")
+	case models.NotificationTypeAssigned:
+		emoji = "📌"
+		title = translate(lang, "assigned", assignerName(task, lang))
 	}
 
-	dueDateStr := ""
-	if task.DueDate != nil {
-		dueDateStr = task.DueDate.Format("02/01/2006")
-	}
+	dueDateStr := formatDueDate(task.DueDate, lang)
 
 	message := fmt.Sprintf(
-		"%s <b>%s</b>\n\n"+
+		"%s <b>%s!</b>\n\n"+
 			"<b>%s</b>\n"+
 			"%s\n\n"+
-			"<b>Prioridade:</b> %s\n"+
-			"<b>Data de vencimento:</b> %s",
+			"<b>%s:</b> %s\n"+
+			"<b>%s:</b> %s",
 		emoji,
 		title,
 		task.Title,
 		task.Description,
-		task.Priority,
+		translate(lang, "priority"),
+		priorityLabel(task.Priority, lang),
+		translate(lang, "due_date"),
 		dueDateStr,
 	)
 
-	return message
+	return message + s.taskLink(task, lang)
+}
+
+// buildCommentMessage builds the Telegram message for a new comment, in the given language
+func (s *TelegramService) buildCommentMessage(task *models.Task, comment *models.Comment, lang models.Language) string {
+	return fmt.Sprintf(
+		"💬 <b>%s</b>\n\n"+
+			"<b>%s</b>\n"+
+			"<i>%s</i>",
+		html.EscapeString(translate(lang, "comment", commentAuthorName(comment, lang))),
+		html.EscapeString(task.Title),
+		html.EscapeString(commentSnippet(comment)),
+	) + s.taskLink(task, lang)
+}
+
+// taskLink renders the link to the task as an HTML anchor, or nothing when no frontend URL is configured
+func (s *TelegramService) taskLink(task *models.Task, lang models.Language) string {
+	url := taskURL(s.frontendBaseURL, task)
+	if url == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n<a href=\"%s\">%s</a>", html.EscapeString(url), translate(lang, "open_task"))
+}
+
+// TelegramUpdate represents an incoming update delivered to the bot webhook
+type TelegramUpdate struct {
+	UpdateID int              `json:"update_id"`
+	Message  *TelegramMessage `json:"message"`
+}
+
+// TelegramMessage represents a message contained in an update
+type TelegramMessage struct {
+	MessageID       int          `json:"message_id"`
+	MessageThreadID int          `json:"message_thread_id"`
+	IsTopicMessage  bool         `json:"is_topic_message"`
+	Chat            TelegramChat `json:"chat"`
+	Text            string       `json:"text"`
+}
+
+// TelegramChat represents the chat a message was sent in
+type TelegramChat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// ParseUpdate decodes a Telegram update payload
+func (s *TelegramService) ParseUpdate(r io.Reader) (*TelegramUpdate, error) {
+	var update TelegramUpdate
+	if err := json.NewDecoder(r).Decode(&update); err != nil {
+		return nil, fmt.Errorf("failed to decode telegram update: %w", err)
+	}
+	return &update, nil
+}
+
+// ParseCommand extracts the bot command and its argument from an update (e.g. "/start ABC123").
+// The bot username suffix used in groups ("/start@my_bot") is stripped. ok is false when the
+// update does not carry a command.
+func (s *TelegramService) ParseCommand(update *TelegramUpdate) (command string, argument string, chatID string, ok bool) {
+	if update == nil || update.Message == nil {
+		return "", "", "", false
+	}
+	text := strings.TrimSpace(update.Message.Text)
+	if !strings.HasPrefix(text, "/") {
+		return "", "", "", false
+	}
+
+	parts := strings.Fields(text)
+	command = parts[0]
+	if at := strings.Index(command, "@"); at != -1 {
+		command = command[:at]
+	}
+	if len(parts) > 1 {
+		argument = parts[1]
+	}
+	chatID = strconv.FormatInt(update.Message.Chat.ID, 10)
+	return command, argument, chatID, true
 }
 
+// IsConfigured returns true if a bot token is set
+func (s *TelegramService) IsConfigured() bool {
+	return s.botToken != ""
+}
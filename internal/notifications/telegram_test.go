@@ -0,0 +1,29 @@
+package notifications
+
+import (
+	"testing"
+	"todo-go-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegramTaskLink(t *testing.T) {
+	task := &models.Task{ID: 42, Title: "Pay bills", Priority: models.PriorityAlta}
+	comment := &models.Comment{ID: 7, Content: "Done <soon>", User: models.User{Username: "ana"}}
+
+	t.Run("Messages link to the task when a frontend URL is configured", func(t *testing.T) {
+		service := NewTelegramService("token", "https://todo.example.com")
+
+		assert.Contains(t, service.buildMessage(task, models.NotificationTypeOverdue, models.LanguagePortuguese), `<a href="https://todo.example.com/tasks/42">Abrir tarefa</a>`)
+		message := service.buildCommentMessage(task, comment, models.LanguagePortuguese)
+		assert.Contains(t, message, `<a href="https://todo.example.com/tasks/42">`)
+		assert.Contains(t, message, "Done &lt;soon&gt;")
+	})
+
+	t.Run("The link is omitted without a frontend URL", func(t *testing.T) {
+		service := NewTelegramService("token", "")
+
+		assert.NotContains(t, service.buildMessage(task, models.NotificationTypeOverdue, models.LanguagePortuguese), "<a href")
+		assert.NotContains(t, service.buildCommentMessage(task, comment, models.LanguagePortuguese), "<a href")
+	})
+}
@@ -0,0 +1,89 @@
+package realtime
+
+import (
+	"log"
+	"sync"
+)
+
+// Event types pushed to connected clients
+const (
+	EventTaskCreated    = "task.created"
+	EventTaskUpdated    = "task.updated"
+	EventTaskCompleted  = "task.completed"
+	EventCommentCreated = "comment.created"
+)
+
+// subscriberBuffer is how many events a slow client can fall behind before events are dropped
+const subscriberBuffer = 32
+
+// Event is a change notification sent to the users who can access the changed resource
+type Event struct {
+	Type   string      `json:"type" example:"task.updated"`
+	TaskID uint        `json:"task_id" example:"1"`
+	Data   interface{} `json:"data"` // The task or comment that changed
+}
+
+// Publisher delivers events to users. Services depend on it instead of on the Hub directly.
+type Publisher interface {
+	Publish(userIDs []uint, event Event)
+}
+
+// Hub is an in-process fan-out of events to the connections of each user
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+// NewHub creates a new instance of Hub
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[uint]map[chan Event]struct{})}
+}
+
+// Subscribe registers a connection of the user. The returned function unregisters it and
+// must be called when the connection closes.
+func (h *Hub) Subscribe(userID uint) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends the event to every connection of the given users. Duplicate IDs receive it once.
+// It never blocks: connections whose buffer is full miss the event.
+func (h *Hub) Publish(userIDs []uint, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sent := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if sent[userID] {
+			continue
+		}
+		sent[userID] = true
+
+		for ch := range h.subscribers[userID] {
+			select {
+			case ch <- event:
+			default:
+				log.Printf("Dropping %s event for user %d: client is too slow", event.Type, userID)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub(t *testing.T) {
+	t.Run("Events reach every connection of the target users only", func(t *testing.T) {
+		hub := NewHub()
+		first, unsubscribeFirst := hub.Subscribe(1)
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := hub.Subscribe(1)
+		defer unsubscribeSecond()
+		other, unsubscribeOther := hub.Subscribe(2)
+		defer unsubscribeOther()
+
+		hub.Publish([]uint{1, 1}, Event{Type: EventTaskUpdated, TaskID: 7})
+
+		assert.Equal(t, uint(7), (<-first).TaskID)
+		assert.Equal(t, uint(7), (<-second).TaskID)
+		assert.Len(t, first, 0)
+		assert.Len(t, other, 0)
+	})
+
+	t.Run("Unsubscribed connections stop receiving events", func(t *testing.T) {
+		hub := NewHub()
+		events, unsubscribe := hub.Subscribe(1)
+		unsubscribe()
+		unsubscribe()
+
+		hub.Publish([]uint{1}, Event{Type: EventTaskCreated})
+
+		_, open := <-events
+		assert.False(t, open)
+	})
+
+	t.Run("Publishing to a full buffer does not block", func(t *testing.T) {
+		hub := NewHub()
+		events, unsubscribe := hub.Subscribe(1)
+		defer unsubscribe()
+
+		for i := 0; i < subscriberBuffer+5; i++ {
+			hub.Publish([]uint{1}, Event{Type: EventTaskUpdated})
+		}
+
+		assert.Len(t, events, subscriberBuffer)
+	})
+}
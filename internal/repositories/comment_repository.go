@@ -9,7 +9,9 @@ import (
 type CommentRepository interface {
 	Create(comment *models.Comment) error
 	FindByID(id uint) (*models.Comment, error)
-	FindByTaskID(taskID uint) ([]models.Comment, error)
+	FindByTaskID(taskID uint, includeDeleted bool) ([]models.Comment, error)
+	FindByTaskIDs(taskIDs []uint) ([]models.Comment, error)
+	CountPinnedByTaskID(taskID uint) (int64, error)
 	Update(comment *models.Comment) error
 	Delete(id uint) error
 	Exists(id uint) (bool, error)
@@ -37,11 +39,18 @@ func (r *commentRepository) FindByID(id uint) (*models.Comment, error) {
 	return &comment, nil
 }
 
-func (r *commentRepository) FindByTaskID(taskID uint) ([]models.Comment, error) {
+// FindByTaskID returns the comments of a task, pinned ones first. Soft deleted comments are only included when includeDeleted is true.
+func (r *commentRepository) FindByTaskID(taskID uint, includeDeleted bool) ([]models.Comment, error) {
+	query := database.DB
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+
 	var comments []models.Comment
-	if err := database.DB.
+	if err := query.
 		Where("task_id = ?", taskID).
 		Preload("User").
+		Order("pinned DESC").
 		Order("created_at ASC").
 		Find(&comments).Error; err != nil {
 		return nil, err
@@ -49,6 +58,32 @@ func (r *commentRepository) FindByTaskID(taskID uint) ([]models.Comment, error)
 	return comments, nil
 }
 
+// FindByTaskIDs returns the comments of all the given tasks with their authors, oldest first
+func (r *commentRepository) FindByTaskIDs(taskIDs []uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	if len(taskIDs) == 0 {
+		return comments, nil
+	}
+
+	if err := database.DB.
+		Where("task_id IN ?", taskIDs).
+		Preload("User").
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CountPinnedByTaskID counts the pinned comments of a task
+func (r *commentRepository) CountPinnedByTaskID(taskID uint) (int64, error) {
+	var count int64
+	if err := database.DB.Model(&models.Comment{}).Where("task_id = ? AND pinned = ?", taskID, true).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *commentRepository) Update(comment *models.Comment) error {
 	return database.DB.Save(comment).Error
 }
@@ -10,7 +10,19 @@ import (
 type NotificationRepository interface {
 	Create(notification *models.Notification) error
 	Exists(userID, taskID uint, notificationType models.NotificationType, channel models.NotificationChannel, date time.Time) (bool, error)
+	ExistsAnyDay(userID, taskID uint, notificationType models.NotificationType, channel models.NotificationChannel) (bool, error)
+	ExistsForComment(userID, commentID uint, channel models.NotificationChannel) (bool, error)
 	FindByUserID(userID uint) ([]models.Notification, error)
+	FindByIDAndUserID(id, userID uint) (*models.Notification, error)
+	FindByUserIDPaginated(userID uint, filters *NotificationFilters, page, limit int) ([]models.Notification, int64, error)
+}
+
+// NotificationFilters narrows the notification history of a user. Zero values don't filter.
+type NotificationFilters struct {
+	Type    models.NotificationType
+	Channel models.NotificationChannel
+	From    *time.Time // Sent at or after
+	To      *time.Time // Sent at or before
 }
 
 type notificationRepository struct{}
@@ -24,15 +36,15 @@ func (r *notificationRepository) Create(notification *models.Notification) error
 	return database.DB.Create(notification).Error
 }
 
-// Exists checks if a notification was already sent for a task on a specific date
+// Exists checks if a notification was already sent for a task on a specific date.
+// The date should be the same timestamp that is recorded as SentAt so both fall on the same day.
 func (r *notificationRepository) Exists(userID, taskID uint, notificationType models.NotificationType, channel models.NotificationChannel, date time.Time) (bool, error) {
 	var count int64
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	startOfDay, endOfDay := DayBounds(date)
 
 	err := database.DB.Model(&models.Notification{}).
-		Where("user_id = ? AND task_id = ? AND type = ? AND channel = ? AND sent_at BETWEEN ? AND ?",
-			userID, taskID, notificationType, channel, startOfDay, endOfDay).
+		Where("user_id = ? AND task_id = ? AND type = ? AND channel = ? AND sent_at >= ? AND sent_at < ?",
+			userID, taskID, notificationType, channel, startOfDay.UTC(), endOfDay.UTC()).
 		Count(&count).Error
 
 	if err != nil {
@@ -42,6 +54,51 @@ func (r *notificationRepository) Exists(userID, taskID uint, notificationType mo
 	return count > 0, nil
 }
 
+// ExistsAnyDay checks if a notification was ever sent for a task, for notifications that
+// must go out only once (e.g. assigned) rather than once a day
+func (r *notificationRepository) ExistsAnyDay(userID, taskID uint, notificationType models.NotificationType, channel models.NotificationChannel) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND task_id = ? AND type = ? AND channel = ?", userID, taskID, notificationType, channel).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ExistsForComment checks if the user was already notified about a comment
+func (r *notificationRepository) ExistsForComment(userID, commentID uint, channel models.NotificationChannel) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND comment_id = ? AND channel = ?", userID, commentID, channel).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// DayBounds returns the half-open interval [start, end) of the calendar day containing t, in t's
+// timezone (the server timezone for notifications)
+func DayBounds(t time.Time) (time.Time, time.Time) {
+	return startOfDay(t.Year(), t.Month(), t.Day(), t.Location()), startOfDay(t.Year(), t.Month(), t.Day()+1, t.Location())
+}
+
+// startOfDay returns the first instant of a calendar day in loc. That's midnight unless a DST change
+// skips it, in which case time.Date may land on the previous day and the day starts when the clocks
+// jump forward.
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	noon := time.Date(year, month, day, 12, 0, 0, 0, loc)
+	start := time.Date(noon.Year(), noon.Month(), noon.Day(), 0, 0, 0, 0, loc)
+	if start.Day() != noon.Day() {
+		_, start = start.ZoneBounds()
+	}
+	return start
+}
+
 func (r *notificationRepository) FindByUserID(userID uint) ([]models.Notification, error) {
 	var notifications []models.Notification
 	if err := database.DB.
@@ -54,3 +111,50 @@ func (r *notificationRepository) FindByUserID(userID uint) ([]models.Notificatio
 	return notifications, nil
 }
 
+// FindByIDAndUserID finds a notification sent to the user, with its task loaded
+func (r *notificationRepository) FindByIDAndUserID(id, userID uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := database.DB.
+		Where("id = ? AND user_id = ?", id, userID).
+		Preload("Task").
+		First(&notification).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// FindByUserIDPaginated returns a page of the notifications sent to the user, newest first, with
+// their tasks loaded
+func (r *notificationRepository) FindByUserIDPaginated(userID uint, filters *NotificationFilters, page, limit int) ([]models.Notification, int64, error) {
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+	if filters.Channel != "" {
+		query = query.Where("channel = ?", filters.Channel)
+	}
+	if filters.From != nil {
+		query = query.Where("sent_at >= ?", filters.From.UTC())
+	}
+	if filters.To != nil {
+		query = query.Where("sent_at <= ?", filters.To.UTC())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	offset := (page - 1) * limit
+	if err := query.
+		Preload("Task").
+		Order("sent_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
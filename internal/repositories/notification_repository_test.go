@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func inBounds(t time.Time, start, end time.Time) bool {
+	return !t.Before(start) && t.Before(end)
+}
+
+func TestDayBounds(t *testing.T) {
+	loc := time.UTC
+
+	t.Run("Notification sent at 23:59 is not deduped by a check at 00:01", func(t *testing.T) {
+		sentAt := time.Date(2024, 12, 31, 23, 59, 0, 0, loc)
+		checkedAt := time.Date(2025, 1, 1, 0, 1, 0, 0, loc)
+
+		start, end := DayBounds(checkedAt)
+		assert.False(t, inBounds(sentAt, start, end))
+	})
+
+	t.Run("SentAt equal to the check time falls inside the window", func(t *testing.T) {
+		checkedAt := time.Date(2024, 12, 31, 23, 59, 59, 0, loc)
+
+		start, end := DayBounds(checkedAt)
+		assert.True(t, inBounds(checkedAt, start, end))
+	})
+
+	t.Run("Exact midnight belongs only to the new day", func(t *testing.T) {
+		midnight := time.Date(2025, 1, 1, 0, 0, 0, 0, loc)
+
+		prevStart, prevEnd := DayBounds(midnight.Add(-time.Second))
+		assert.False(t, inBounds(midnight, prevStart, prevEnd))
+
+		start, end := DayBounds(midnight)
+		assert.True(t, inBounds(midnight, start, end))
+		assert.Equal(t, midnight, start)
+		assert.Equal(t, midnight.AddDate(0, 0, 1), end)
+	})
+
+	t.Run("Days that cross a DST change keep their local length", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skipf("timezone data not available: %v", err)
+		}
+
+		// Clocks go forward at 02:00 on 2024-03-10 and back at 02:00 on 2024-11-03
+		start, end := DayBounds(time.Date(2024, 3, 10, 12, 0, 0, 0, newYork))
+		assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), start)
+		assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, newYork), end)
+		assert.Equal(t, 23*time.Hour, end.Sub(start))
+
+		start, end = DayBounds(time.Date(2024, 11, 3, 23, 30, 0, 0, newYork))
+		assert.Equal(t, 25*time.Hour, end.Sub(start))
+		// 01:30 happens twice that day and both belong to it
+		firstOneThirty := time.Date(2024, 11, 3, 1, 30, 0, 0, newYork)
+		assert.True(t, inBounds(firstOneThirty, start, end))
+		assert.True(t, inBounds(firstOneThirty.Add(time.Hour), start, end))
+	})
+
+	t.Run("A day whose midnight is skipped by DST starts at the first valid time", func(t *testing.T) {
+		saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+		if err != nil {
+			t.Skipf("timezone data not available: %v", err)
+		}
+
+		// Clocks went from 00:00 straight to 01:00 on 2018-11-04
+		start, end := DayBounds(time.Date(2018, 11, 4, 10, 0, 0, 0, saoPaulo))
+		assert.Equal(t, 1, start.Hour())
+		assert.Equal(t, 23*time.Hour, end.Sub(start))
+
+		// The previous day ends exactly where this one starts, so no instant is lost or counted twice
+		_, prevEnd := DayBounds(time.Date(2018, 11, 3, 23, 59, 0, 0, saoPaulo))
+		assert.True(t, prevEnd.Equal(start))
+	})
+}
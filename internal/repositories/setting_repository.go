@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// SettingRepository defines the interface for runtime setting operations
+type SettingRepository interface {
+	Get(key string) (string, bool, error)
+	Set(key, value string) error
+}
+
+type settingRepository struct{}
+
+// NewSettingRepository creates a new instance of SettingRepository
+func NewSettingRepository() SettingRepository {
+	return &settingRepository{}
+}
+
+// Get returns the value of a setting and whether it exists
+func (r *settingRepository) Get(key string) (string, bool, error) {
+	var settings []models.Setting
+	if err := database.DB.Where(&models.Setting{Key: key}).Limit(1).Find(&settings).Error; err != nil {
+		return "", false, err
+	}
+	if len(settings) == 0 {
+		return "", false, nil
+	}
+	return settings[0].Value, true, nil
+}
+
+// Set creates or updates a setting
+func (r *settingRepository) Set(key, value string) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&models.Setting{Key: key, Value: value}).Error
+}
@@ -3,15 +3,20 @@ package repositories
 import (
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TagRepository defines the interface for tag operations
 type TagRepository interface {
 	Create(tag *models.Tag) error
+	FirstOrCreateByName(tag *models.Tag) (created bool, err error)
 	FindByID(id uint) (*models.Tag, error)
 	FindByUserID(userID uint) ([]models.Tag, error)
 	FindByIDAndUserID(id, userID uint) (*models.Tag, error)
 	FindByNameAndUserID(name string, userID uint) (*models.Tag, error)
+	FindByNameInsensitive(name string, userID uint) (*models.Tag, error)
 	Update(tag *models.Tag) error
 	Delete(id uint) error
 	FindByIDs(ids []uint, userID uint) ([]models.Tag, error)
@@ -29,6 +34,26 @@ func (r *tagRepository) Create(tag *models.Tag) error {
 	return database.DB.Create(tag).Error
 }
 
+// FirstOrCreateByName creates the tag unless the user already has one with the same name ignoring
+// case, in which case tag is replaced by the existing one. The unique (user_id, name_key) index
+// decides, so concurrent calls for the same name create a single tag.
+func (r *tagRepository) FirstOrCreateByName(tag *models.Tag) (bool, error) {
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(tag)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	existing, err := r.FindByNameInsensitive(tag.Name, tag.UserID)
+	if err != nil {
+		return false, err
+	}
+	*tag = *existing
+	return false, nil
+}
+
 func (r *tagRepository) FindByID(id uint) (*models.Tag, error) {
 	var tag models.Tag
 	if err := database.DB.First(&tag, id).Error; err != nil {
@@ -61,12 +86,27 @@ func (r *tagRepository) FindByNameAndUserID(name string, userID uint) (*models.T
 	return &tag, nil
 }
 
+// FindByNameInsensitive finds the user's tag whose name matches ignoring case
+func (r *tagRepository) FindByNameInsensitive(name string, userID uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := database.DB.Where("name_key = ? AND user_id = ?", models.TagNameKey(name), userID).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
 func (r *tagRepository) Update(tag *models.Tag) error {
 	return database.DB.Save(tag).Error
 }
 
+// Delete soft deletes the tag and clears its name key, so the user can create a tag with the same name
 func (r *tagRepository) Delete(id uint) error {
-	return database.DB.Delete(&models.Tag{}, id).Error
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Tag{}).Where("id = ?", id).UpdateColumn("name_key", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
 }
 
 func (r *tagRepository) FindByIDs(ids []uint, userID uint) ([]models.Tag, error) {
@@ -1,39 +1,91 @@
 package repositories
 
 import (
+	"context"
+	"strings"
 	"time"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// TaskRepository defines the interface for task operations
+// TaskRepository defines the interface for task operations. Queries run with ctx, so they stop
+// when it is cancelled or its deadline passes.
 type TaskRepository interface {
-	Create(task *models.Task) error
-	FindByID(id uint) (*models.Task, error)
-	FindByUserID(userID uint, filters *TaskFilters) ([]models.Task, int64, error)
-	FindByAssignedBy(assignedByID uint, filters *TaskFilters) ([]models.Task, int64, error)
-	Update(task *models.Task) error
-	Delete(id uint) error
-	Exists(id uint) (bool, error)
-	AddSharedWith(taskID, userID uint) error
-	RemoveSharedWith(taskID, userID uint) error
-	UserCanAccessTask(taskID, userID uint) (bool, error)
+	Create(ctx context.Context, task *models.Task) error
+	CreateMany(ctx context.Context, tasks []*models.Task) error
+	FindByID(ctx context.Context, id uint) (*models.Task, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]models.Task, error)
+	FindAllByOwner(ctx context.Context, userID uint) ([]models.Task, error)
+	FindByUserID(ctx context.Context, userID uint, filters *TaskFilters) ([]models.Task, int64, error)
+	FindByAssignedBy(ctx context.Context, assignedByID uint, filters *TaskFilters) ([]models.Task, int64, error)
+	CountByAssignedBy(ctx context.Context, assignedByID uint, filters *TaskFilters) (int64, error)
+	CountByAssignee(ctx context.Context, assignedByID uint, filters *TaskFilters) ([]AssigneeTaskCounts, error)
+	FindAssignedToUser(ctx context.Context, userID uint, filters *TaskFilters) ([]models.Task, int64, error)
+	CountAssignedToUser(ctx context.Context, userID uint, filters *TaskFilters) (int64, error)
+	CountByUserID(ctx context.Context, userID uint, filters *TaskFilters) (int64, error)
+	FindUpcomingByUserID(ctx context.Context, userID uint, from, to time.Time) ([]models.Task, error)
+	CountStatsByType(ctx context.Context, userID uint, from, to *time.Time, includeArchived bool) ([]TaskTypeStats, error)
+	Update(ctx context.Context, task *models.Task) error
+	Delete(ctx context.Context, id uint) error
+	DeleteCompletedByOwner(ctx context.Context, userID uint) (int64, error)
+	ArchiveCompletedByOwner(ctx context.Context, userID uint) (int64, error)
+	Exists(ctx context.Context, id uint) (bool, error)
+	AddSharedWith(ctx context.Context, taskID, userID uint) error
+	RemoveSharedWith(ctx context.Context, taskID, userID uint) error
+	AddTagToTasks(ctx context.Context, tagID uint, taskIDs []uint, updatedBy uint) error
+	RemoveTagFromTasks(ctx context.Context, tagID uint, taskIDs []uint, updatedBy uint) error
+	UserCanAccessTask(ctx context.Context, taskID, userID uint) (bool, error)
 }
 
 // TaskFilters defines filters for task search
 type TaskFilters struct {
-	Type         *models.TaskType
-	Completed    *bool
-	Priority     *models.Priority
-	Search       *string // Search in title and description
-	DueDateFrom  *time.Time
-	DueDateTo    *time.Time
-	AssignedBy   *uint
-	TagIDs       []uint  // Filter by tag IDs
-	Page         int
-	Limit        int
-	SortBy       string // created_at, due_date, title, priority
-	Order        string // asc, desc
+	Types           []models.TaskType // Filter by any of the given types
+	Completed       *bool
+	Priorities      []models.Priority // Filter by any of the given priorities
+	Search          *string           // Search in title and description
+	DueDateFrom     *time.Time
+	DueDateTo       *time.Time
+	Overdue         bool // Only pending tasks whose due date has passed
+	IncludeArchived bool // Archived tasks are excluded unless set
+	AssignedBy      *uint
+	AssigneeID      *uint    // Only tasks owned by this user (FindByAssignedBy only)
+	TagIDs          []uint   // Filter by tag IDs
+	HasComments     *bool    // Only tasks with (true) or without (false) comments
+	Include         []string // Relations to preload (see TaskRelations); nil preloads all of them
+	Page            int
+	Limit           int
+	SortBy          string // created_at, due_date, title, priority
+	Order           string // asc, desc
+}
+
+// TaskTypeStats holds the stats of the tasks of a type, as counted by CountStatsByType
+type TaskTypeStats struct {
+	Type      models.TaskType
+	Created   int64
+	Completed int64
+	Pending   int64
+	Overdue   int64
+}
+
+// AssigneeTaskCounts holds the number of tasks assigned to one user, as counted by CountByAssignee
+type AssigneeTaskCounts struct {
+	UserID    uint
+	Username  string
+	Total     int64
+	Completed int64
+	Overdue   int64
+}
+
+// TaskRelations maps the relation names accepted in TaskFilters.Include to the Task associations they preload
+var TaskRelations = map[string]string{
+	"user":             "User",
+	"assigned_by_user": "AssignedByUser",
+	"updated_by_user":  "UpdatedByUser",
+	"shared_with":      "SharedWithUsers",
+	"tags":             "Tags",
 }
 
 type taskRepository struct{}
@@ -43,15 +95,20 @@ func NewTaskRepository() TaskRepository {
 	return &taskRepository{}
 }
 
-func (r *taskRepository) Create(task *models.Task) error {
-	return database.DB.Create(task).Error
+// Create creates the task in a single transaction with its unsaved tags and, for a task created for
+// another user, the share with the user who assigned it (see createTask)
+func (r *taskRepository) Create(ctx context.Context, task *models.Task) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createTask(tx, task)
+	})
 }
 
-func (r *taskRepository) FindByID(id uint) (*models.Task, error) {
+func (r *taskRepository) FindByID(ctx context.Context, id uint) (*models.Task, error) {
 	var task models.Task
-	if err := database.DB.
+	if err := database.DB.WithContext(ctx).
 		Preload("User").
 		Preload("AssignedByUser").
+		Preload("UpdatedByUser").
 		Preload("SharedWithUsers").
 		Preload("Tags").
 		First(&task, id).Error; err != nil {
@@ -60,46 +117,145 @@ func (r *taskRepository) FindByID(id uint) (*models.Task, error) {
 	return &task, nil
 }
 
-func (r *taskRepository) FindByUserID(userID uint, filters *TaskFilters) ([]models.Task, int64, error) {
+func (r *taskRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Task, error) {
 	var tasks []models.Task
-	var total int64
+	if err := database.DB.WithContext(ctx).
+		Preload("User").
+		Preload("AssignedByUser").
+		Preload("UpdatedByUser").
+		Preload("SharedWithUsers").
+		Preload("Tags").
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
 
-	// Base query: tasks owned by user OR shared with user
-	subQuery := database.DB.Table("task_shared_with").Select("task_id").Where("user_id = ?", userID)
-	query := database.DB.Model(&models.Task{}).Where("user_id = ? OR id IN (?)", userID, subQuery)
+// FindAllByOwner returns every task owned by the user, archived included, with their tags
+func (r *taskRepository) FindAllByOwner(ctx context.Context, userID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := database.DB.WithContext(ctx).
+		Preload("Tags").
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
 
-	// Apply filters
-	if filters != nil {
-		if filters.Type != nil {
-			query = query.Where("type = ?", *filters.Type)
-		}
-		if filters.Completed != nil {
-			query = query.Where("completed = ?", *filters.Completed)
-		}
-		if filters.Priority != nil {
-			query = query.Where("priority = ?", *filters.Priority)
-		}
-		if filters.Search != nil && *filters.Search != "" {
-			searchPattern := "%" + *filters.Search + "%"
-			query = query.Where("(title LIKE ? OR description LIKE ?)", searchPattern, searchPattern)
-		}
-		if filters.DueDateFrom != nil {
-			query = query.Where("due_date >= ?", *filters.DueDateFrom)
+// CreateMany creates all tasks in a single transaction, each one like a single create
+func (r *taskRepository) CreateMany(ctx context.Context, tasks []*models.Task) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, task := range tasks {
+			if err := createTask(tx, task); err != nil {
+				return err
+			}
 		}
-		if filters.DueDateTo != nil {
-			query = query.Where("due_date <= ?", *filters.DueDateTo)
+		return nil
+	})
+}
+
+// createTask creates the task within tx. Its tags without an ID are the owner's copies of someone
+// else's tags: each one is found by name (ignoring case) or created, in tx too, so a failed create leaves no tag
+// behind and an earlier task of the same transaction may already have created it. A task created
+// for another user is shared with the user who assigned it.
+func createTask(tx *gorm.DB, task *models.Task) error {
+	for i := range task.Tags {
+		tag := &task.Tags[i]
+		if tag.ID != 0 {
+			continue
 		}
-		if filters.AssignedBy != nil {
-			query = query.Where("assigned_by = ?", *filters.AssignedBy)
+		if err := tx.Where("name_key = ? AND user_id = ?", models.TagNameKey(tag.Name), tag.UserID).FirstOrCreate(tag).Error; err != nil {
+			return err
 		}
-		// Filter by tags (tasks that have ALL specified tags)
-		if len(filters.TagIDs) > 0 {
-			query = query.Joins("JOIN task_tags ON tasks.id = task_tags.task_id").
-				Where("task_tags.tag_id IN ?", filters.TagIDs).
-				Group("tasks.id").
-				Having("COUNT(DISTINCT task_tags.tag_id) = ?", len(filters.TagIDs))
+	}
+	if err := tx.Create(task).Error; err != nil {
+		return err
+	}
+	if task.AssignedBy != nil && *task.AssignedBy != task.UserID {
+		shared := models.TaskSharedWith{TaskID: task.ID, UserID: *task.AssignedBy}
+		if err := tx.Create(&shared).Error; err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+func (r *taskRepository) FindByUserID(ctx context.Context, userID uint, filters *TaskFilters) ([]models.Task, int64, error) {
+	return findTasksPage(applyTaskFilters(userTasksQuery(ctx, userID), filters), filters, "created_at", "DESC")
+}
+
+func (r *taskRepository) FindByAssignedBy(ctx context.Context, assignedByID uint, filters *TaskFilters) ([]models.Task, int64, error) {
+	// Soonest due first by default, to follow up on what others have to deliver
+	return findTasksPage(applyTaskFilters(assignedByQuery(ctx, assignedByID, filters), filters), filters, "due_date", "ASC")
+}
+
+func (r *taskRepository) CountByAssignedBy(ctx context.Context, assignedByID uint, filters *TaskFilters) (int64, error) {
+	var total int64
+	if err := applyTaskFilters(assignedByQuery(ctx, assignedByID, filters), filters).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// CountByAssignee counts the filtered tasks the user assigned to others per assignee: total, completed
+// and overdue, ordered by the assignee's username
+func (r *taskRepository) CountByAssignee(ctx context.Context, assignedByID uint, filters *TaskFilters) ([]AssigneeTaskCounts, error) {
+	// The filters use unqualified column names, so they run in a subquery before users is joined
+	filtered := applyTaskFilters(assignedByQuery(ctx, assignedByID, filters), filters).Select("user_id, completed, due_date")
+
+	var counts []AssigneeTaskCounts
+	err := database.DB.WithContext(ctx).Table("(?) AS filtered", filtered).
+		Joins("JOIN users ON users.id = filtered.user_id").
+		Select("filtered.user_id, users.username, COUNT(*) AS total, "+
+			"SUM(CASE WHEN filtered.completed = ? THEN 1 ELSE 0 END) AS completed, "+
+			"SUM(CASE WHEN "+overdueCondition("filtered.")+" THEN 1 ELSE 0 END) AS overdue",
+			append([]interface{}{true}, overdueArgs(time.Now())...)...).
+		Group("filtered.user_id, users.username").
+		Order("users.username").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// assignedByQuery returns the base query for tasks the user assigned to someone else, narrowed to
+// filters.AssigneeID when set
+func assignedByQuery(ctx context.Context, assignedByID uint, filters *TaskFilters) *gorm.DB {
+	query := database.DB.WithContext(ctx).Model(&models.Task{}).Where("assigned_by = ? AND user_id <> ?", assignedByID, assignedByID)
+	if filters != nil && filters.AssigneeID != nil {
+		query = query.Where("user_id = ?", *filters.AssigneeID)
+	}
+	return query
+}
+
+func (r *taskRepository) FindAssignedToUser(ctx context.Context, userID uint, filters *TaskFilters) ([]models.Task, int64, error) {
+	return findTasksPage(applyTaskFilters(assignedToUserQuery(ctx, userID), filters), filters, "created_at", "DESC")
+}
+
+func (r *taskRepository) CountAssignedToUser(ctx context.Context, userID uint, filters *TaskFilters) (int64, error) {
+	var total int64
+	if err := applyTaskFilters(assignedToUserQuery(ctx, userID), filters).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// assignedToUserQuery returns the base query for tasks owned by the user that someone else assigned
+func assignedToUserQuery(ctx context.Context, userID uint) *gorm.DB {
+	return database.DB.WithContext(ctx).Model(&models.Task{}).
+		Where("user_id = ? AND assigned_by IS NOT NULL AND assigned_by <> ?", userID, userID)
+}
+
+// findTasksPage counts the filtered query, then loads the requested page sorted and with its relations.
+// defaultSortBy and defaultOrder apply when the filters don't set them.
+func findTasksPage(query *gorm.DB, filters *TaskFilters, defaultSortBy, defaultOrder string) ([]models.Task, int64, error) {
+	var tasks []models.Task
+	var total int64
 
 	// Count total before pagination
 	if err := query.Count(&total).Error; err != nil {
@@ -107,8 +263,8 @@ func (r *taskRepository) FindByUserID(userID uint, filters *TaskFilters) ([]mode
 	}
 
 	// Apply sorting
-	sortBy := "created_at"
-	order := "DESC"
+	sortBy := defaultSortBy
+	order := defaultOrder
 	if filters != nil {
 		if filters.SortBy != "" {
 			validSortFields := map[string]bool{
@@ -123,11 +279,11 @@ func (r *taskRepository) FindByUserID(userID uint, filters *TaskFilters) ([]mode
 		}
 		if filters.Order != "" {
 			if filters.Order == "asc" || filters.Order == "desc" {
-				order = filters.Order
+				order = strings.ToUpper(filters.Order)
 			}
 		}
 	}
-	query = query.Order(sortBy + " " + order)
+	query = query.Order(taskOrderClause(sortBy, order))
 
 	// Apply pagination
 	if filters != nil && filters.Limit > 0 {
@@ -139,108 +295,254 @@ func (r *taskRepository) FindByUserID(userID uint, filters *TaskFilters) ([]mode
 	}
 
 	// Execute query with preloads
-	if err := query.Preload("User").Preload("AssignedByUser").Preload("SharedWithUsers").Preload("Tags").Find(&tasks).Error; err != nil {
+	if err := preloadTaskRelations(query, filters).Find(&tasks).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return tasks, total, nil
 }
 
-func (r *taskRepository) FindByAssignedBy(assignedByID uint, filters *TaskFilters) ([]models.Task, int64, error) {
-	var tasks []models.Task
+func (r *taskRepository) CountByUserID(ctx context.Context, userID uint, filters *TaskFilters) (int64, error) {
 	var total int64
+	if err := applyTaskFilters(userTasksQuery(ctx, userID), filters).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
 
-	// Base query - tasks assigned by this user
-	query := database.DB.Model(&models.Task{}).Where("assigned_by = ?", assignedByID)
+// CountStatsByType counts, per type, the tasks accessible to the user created between from and to
+// (inclusive, nil for an open end), how many of those are pending or overdue, and the tasks completed
+// in the same range, in a single grouped query
+func (r *taskRepository) CountStatsByType(ctx context.Context, userID uint, from, to *time.Time, includeArchived bool) ([]TaskTypeStats, error) {
+	query := userTasksQuery(ctx, userID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	created, createdArgs := betweenCondition("created_at", from, to)
+	completed, completedArgs := betweenCondition("completed_at", from, to)
+	completed = "completed = ? AND " + completed
+	completedArgs = append([]interface{}{true}, completedArgs...)
 
-	// Apply filters
-	if filters != nil {
-		if filters.Type != nil {
-			query = query.Where("type = ?", *filters.Type)
-		}
-		if filters.Completed != nil {
-			query = query.Where("completed = ?", *filters.Completed)
-		}
-		if filters.Priority != nil {
-			query = query.Where("priority = ?", *filters.Priority)
-		}
-		if filters.Search != nil && *filters.Search != "" {
-			searchPattern := "%" + *filters.Search + "%"
-			query = query.Where("(title LIKE ? OR description LIKE ?)", searchPattern, searchPattern)
-		}
-		if filters.DueDateFrom != nil {
-			query = query.Where("due_date >= ?", *filters.DueDateFrom)
-		}
-		if filters.DueDateTo != nil {
-			query = query.Where("due_date <= ?", *filters.DueDateTo)
-		}
-		// Filter by tags (tasks that have ALL specified tags)
-		if len(filters.TagIDs) > 0 {
-			query = query.Joins("JOIN task_tags ON tasks.id = task_tags.task_id").
-				Where("task_tags.tag_id IN ?", filters.TagIDs).
-				Group("tasks.id").
-				Having("COUNT(DISTINCT task_tags.tag_id) = ?", len(filters.TagIDs))
-		}
+	var counts []TaskTypeStats
+	err := query.
+		Where("(("+created+") OR ("+completed+"))", joinArgs(createdArgs, completedArgs)...).
+		Select("type, "+
+			"SUM(CASE WHEN "+created+" THEN 1 ELSE 0 END) AS created, "+
+			"SUM(CASE WHEN "+completed+" THEN 1 ELSE 0 END) AS completed, "+
+			"SUM(CASE WHEN "+created+" AND completed = ? THEN 1 ELSE 0 END) AS pending, "+
+			"SUM(CASE WHEN "+created+" AND "+overdueCondition("")+" THEN 1 ELSE 0 END) AS overdue",
+			joinArgs(createdArgs, completedArgs, createdArgs, []interface{}{false}, createdArgs, overdueArgs(time.Now()))...).
+		Group("type").
+		Order("type").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
 	}
+	return counts, nil
+}
 
-	// Count total before pagination
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// betweenCondition returns the condition keeping the rows whose column is between from and to
+// (inclusive, nil for an open end), with its arguments
+func betweenCondition(column string, from, to *time.Time) (string, []interface{}) {
+	conditions := []string{column + " IS NOT NULL"}
+	var args []interface{}
+	if from != nil {
+		conditions = append(conditions, column+" >= ?")
+		args = append(args, from.UTC())
+	}
+	if to != nil {
+		conditions = append(conditions, column+" <= ?")
+		args = append(args, to.UTC())
 	}
+	return strings.Join(conditions, " AND "), args
+}
 
-	// Apply sorting
-	sortBy := "created_at"
-	order := "DESC"
-	if filters != nil {
-		if filters.SortBy != "" {
-			validSortFields := map[string]bool{
-				"created_at": true,
-				"due_date":   true,
-				"title":      true,
-				"priority":   true,
-			}
-			if validSortFields[filters.SortBy] {
-				sortBy = filters.SortBy
-			}
-		}
-		if filters.Order != "" {
-			if filters.Order == "asc" || filters.Order == "desc" {
-				order = filters.Order
-			}
-		}
+// joinArgs concatenates the arguments of several conditions, in the order they appear in the query
+func joinArgs(lists ...[]interface{}) []interface{} {
+	var args []interface{}
+	for _, list := range lists {
+		args = append(args, list...)
 	}
-	query = query.Order(sortBy + " " + order)
+	return args
+}
 
-	// Apply pagination
-	if filters != nil && filters.Limit > 0 {
-		query = query.Limit(filters.Limit)
-		if filters.Page > 0 {
-			offset := (filters.Page - 1) * filters.Limit
-			query = query.Offset(offset)
+// FindUpcomingByUserID returns the user's pending, non archived tasks due between from and to, soonest first
+func (r *taskRepository) FindUpcomingByUserID(ctx context.Context, userID uint, from, to time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := userTasksQuery(ctx, userID).
+		Where("completed = ? AND archived = ?", false, false).
+		Where("due_date >= ? AND due_date <= ?", from.UTC(), to.UTC()).
+		Order("due_date ASC").
+		Preload("User").
+		Preload("AssignedByUser").
+		Preload("UpdatedByUser").
+		Preload("SharedWithUsers").
+		Preload("Tags").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// preloadTaskRelations preloads the relations requested in filters.Include, or all of them when not set
+func preloadTaskRelations(query *gorm.DB, filters *TaskFilters) *gorm.DB {
+	if filters == nil || filters.Include == nil {
+		return query.Preload("User").Preload("AssignedByUser").Preload("UpdatedByUser").Preload("SharedWithUsers").Preload("Tags")
+	}
+	for _, relation := range filters.Include {
+		if association, ok := TaskRelations[relation]; ok {
+			query = query.Preload(association)
 		}
 	}
+	return query
+}
 
-	// Execute query with preloads
-	if err := query.Preload("User").Preload("AssignedByUser").Preload("SharedWithUsers").Preload("Tags").Find(&tasks).Error; err != nil {
-		return nil, 0, err
+// taskOrderClause builds the ORDER BY clause for a task listing. When sorting by due date, tasks
+// without one always come after the dated ones, in both directions. Databases disagree on where
+// NULLs sort and MySQL has no NULLS LAST, so the "due_date IS NULL" key (0 before 1) is used instead.
+func taskOrderClause(sortBy, order string) string {
+	if sortBy == "due_date" {
+		return "due_date IS NULL, due_date " + order
 	}
+	return sortBy + " " + order
+}
 
-	return tasks, total, nil
+// overdueCondition is the SQL condition for a pending task past its due date, on the columns of the
+// table with the given prefix (e.g. "filtered."). Its arguments come from overdueArgs. A due date at
+// today's midnight is a date-only task due today, which is not overdue until the day ends; this
+// matches the notification check.
+func overdueCondition(prefix string) string {
+	return prefix + "completed = ? AND " + prefix + "due_date < ? AND " + prefix + "due_date <> ?"
+}
+
+// overdueArgs returns the arguments of overdueCondition at now, whose day starts at midnight in now's location
+func overdueArgs(now time.Time) []interface{} {
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return []interface{}{false, now.UTC(), todayStart.UTC()}
+}
+
+// userTasksQuery returns the base query for tasks owned by the user OR shared with the user
+func userTasksQuery(ctx context.Context, userID uint) *gorm.DB {
+	subQuery := database.DB.Table("task_shared_with").Select("task_id").Where("user_id = ?", userID)
+	return database.DB.WithContext(ctx).Model(&models.Task{}).Where("user_id = ? OR id IN (?)", userID, subQuery)
+}
+
+// applyTaskFilters adds the WHERE clauses for the given filters (pagination and sorting excluded)
+func applyTaskFilters(query *gorm.DB, filters *TaskFilters) *gorm.DB {
+	if filters == nil || !filters.IncludeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	if filters == nil {
+		return query
+	}
+	if len(filters.Types) > 0 {
+		query = query.Where("type IN ?", filters.Types)
+	}
+	if filters.Completed != nil {
+		query = query.Where("completed = ?", *filters.Completed)
+	}
+	if len(filters.Priorities) > 0 {
+		query = query.Where("priority IN ?", filters.Priorities)
+	}
+	if filters.Search != nil && *filters.Search != "" {
+		searchPattern := "%" + *filters.Search + "%"
+		query = query.Where("(title LIKE ? OR description LIKE ?)", searchPattern, searchPattern)
+	}
+	if filters.DueDateFrom != nil {
+		query = query.Where("due_date >= ?", filters.DueDateFrom.UTC())
+	}
+	if filters.DueDateTo != nil {
+		query = query.Where("due_date <= ?", filters.DueDateTo.UTC())
+	}
+	if filters.Overdue {
+		query = query.Where(overdueCondition(""), overdueArgs(time.Now())...)
+	}
+	if filters.AssignedBy != nil {
+		query = query.Where("assigned_by = ?", *filters.AssignedBy)
+	}
+	// EXISTS instead of a join, so tasks with many comments are not repeated
+	if filters.HasComments != nil {
+		commentsQuery := database.DB.Model(&models.Comment{}).Select("1").Where("comments.task_id = tasks.id")
+		if *filters.HasComments {
+			query = query.Where("EXISTS (?)", commentsQuery)
+		} else {
+			query = query.Where("NOT EXISTS (?)", commentsQuery)
+		}
+	}
+	// Filter by tags (tasks that have ALL specified tags). A subquery instead of a join keeps
+	// one row per task, so Count matches the rows returned and pagination totals are exact.
+	if len(filters.TagIDs) > 0 {
+		taggedQuery := database.DB.Table("task_tags").
+			Select("task_id").
+			Where("tag_id IN ?", filters.TagIDs).
+			Group("task_id").
+			Having("COUNT(DISTINCT tag_id) = ?", len(filters.TagIDs))
+		query = query.Where("tasks.id IN (?)", taggedQuery)
+	}
+	return query
 }
 
-func (r *taskRepository) AddSharedWith(taskID, userID uint) error {
+// AddSharedWith shares the task with the user. The owner already has access, so no share
+// row is ever created for them.
+func (r *taskRepository) AddSharedWith(ctx context.Context, taskID, userID uint) error {
+	var task models.Task
+	if err := database.DB.WithContext(ctx).Select("id", "user_id").First(&task, taskID).Error; err != nil {
+		return err
+	}
+	if task.UserID == userID {
+		return nil
+	}
 	// FirstOrCreate avoids duplicate (DB-agnostic)
-	return database.DB.Where(models.TaskSharedWith{TaskID: taskID, UserID: userID}).
+	return database.DB.WithContext(ctx).Where(models.TaskSharedWith{TaskID: taskID, UserID: userID}).
 		FirstOrCreate(&models.TaskSharedWith{TaskID: taskID, UserID: userID}).Error
 }
 
-func (r *taskRepository) RemoveSharedWith(taskID, userID uint) error {
-	return database.DB.Delete(&models.TaskSharedWith{}, "task_id = ? AND user_id = ?", taskID, userID).Error
+func (r *taskRepository) RemoveSharedWith(ctx context.Context, taskID, userID uint) error {
+	return database.DB.WithContext(ctx).Delete(&models.TaskSharedWith{}, "task_id = ? AND user_id = ?", taskID, userID).Error
 }
 
-func (r *taskRepository) UserCanAccessTask(taskID, userID uint) (bool, error) {
+// AddTagToTasks attaches the tag to the tasks and records the change on them (updated_at and
+// updated_by) in a single transaction. Tasks that already have the tag keep it.
+func (r *taskRepository) AddTagToTasks(ctx context.Context, tagID uint, taskIDs []uint, updatedBy uint) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	rows := make([]models.TaskTag, len(taskIDs))
+	for i, taskID := range taskIDs {
+		rows[i] = models.TaskTag{TaskID: taskID, TagID: tagID}
+	}
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+		return touchTasks(tx, taskIDs, updatedBy)
+	})
+}
+
+// RemoveTagFromTasks detaches the tag from the tasks and records the change on them (updated_at
+// and updated_by) in a single transaction
+func (r *taskRepository) RemoveTagFromTasks(ctx context.Context, tagID uint, taskIDs []uint, updatedBy uint) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ? AND task_id IN ?", tagID, taskIDs).Delete(&models.TaskTag{}).Error; err != nil {
+			return err
+		}
+		return touchTasks(tx, taskIDs, updatedBy)
+	})
+}
+
+// touchTasks records that the tasks were changed by updatedBy when only their associations changed,
+// so updated_at reflects every change, like Save does for the task's own fields
+func touchTasks(tx *gorm.DB, taskIDs []uint, updatedBy uint) error {
+	return tx.Model(&models.Task{}).Where("id IN ?", taskIDs).
+		Updates(map[string]interface{}{"updated_at": tx.NowFunc(), "updated_by": updatedBy}).Error
+}
+
+func (r *taskRepository) UserCanAccessTask(ctx context.Context, taskID, userID uint) (bool, error) {
 	var task models.Task
-	if err := database.DB.Select("id", "user_id", "assigned_by").First(&task, taskID).Error; err != nil {
+	if err := database.DB.WithContext(ctx).Select("id", "user_id", "assigned_by").First(&task, taskID).Error; err != nil {
 		return false, err
 	}
 	if task.UserID == userID {
@@ -250,25 +552,127 @@ func (r *taskRepository) UserCanAccessTask(taskID, userID uint) (bool, error) {
 		return true, nil
 	}
 	var count int64
-	if err := database.DB.Table("task_shared_with").Where("task_id = ? AND user_id = ?", taskID, userID).Count(&count).Error; err != nil {
+	if err := database.DB.WithContext(ctx).Table("task_shared_with").Where("task_id = ? AND user_id = ?", taskID, userID).Count(&count).Error; err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-func (r *taskRepository) Update(task *models.Task) error {
-	return database.DB.Save(task).Error
+// Update saves the task. When its tags are loaded they replace the stored ones, since Save
+// alone only adds associations and would keep removed tags; nil tags are left untouched.
+// Save always sets updated_at, so changing only the tags also counts as an update.
+func (r *taskRepository) Update(ctx context.Context, task *models.Task) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").Save(task).Error; err != nil {
+			return err
+		}
+		if task.Tags == nil {
+			return nil
+		}
+		return syncTaskTags(tx, task.ID, task.Tags)
+	})
 }
 
-func (r *taskRepository) Delete(id uint) error {
-	return database.DB.Delete(&models.Task{}, id).Error
+// syncTaskTags makes the task's tags match tags by inserting and deleting only the task_tags
+// rows that changed, so the rows of tags the task keeps are never rewritten
+func syncTaskTags(tx *gorm.DB, taskID uint, tags []models.Tag) error {
+	var currentIDs []uint
+	if err := tx.Model(&models.TaskTag{}).Where("task_id = ?", taskID).Pluck("tag_id", &currentIDs).Error; err != nil {
+		return err
+	}
+	current := make(map[uint]bool, len(currentIDs))
+	for _, id := range currentIDs {
+		current[id] = true
+	}
+
+	wanted := make(map[uint]bool, len(tags))
+	var added []models.TaskTag
+	for _, tag := range tags {
+		if wanted[tag.ID] {
+			continue
+		}
+		wanted[tag.ID] = true
+		if !current[tag.ID] {
+			added = append(added, models.TaskTag{TaskID: taskID, TagID: tag.ID})
+		}
+	}
+	var removed []uint
+	for _, id := range currentIDs {
+		if !wanted[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := tx.Where("task_id = ? AND tag_id IN ?", taskID, removed).Delete(&models.TaskTag{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		return tx.Omit(clause.Associations).Create(&added).Error
+	}
+	return nil
+}
+
+// Delete soft-deletes the task in a transaction that also removes its shares and tag
+// associations and soft-deletes its comments and notifications, so nothing is left pointing at it
+func (r *taskRepository) Delete(ctx context.Context, id uint) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		related := []interface{}{
+			&models.TaskSharedWith{},
+			&models.TaskTag{},
+			&models.Comment{},
+			&models.Notification{},
+		}
+		for _, model := range related {
+			if err := tx.Where("task_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Task{}, id).Error
+	})
 }
 
-func (r *taskRepository) Exists(id uint) (bool, error) {
+// DeleteCompletedByOwner soft-deletes every completed task the user owns, cleaning up their
+// relations like Delete does, and returns how many tasks were deleted
+func (r *taskRepository) DeleteCompletedByOwner(ctx context.Context, userID uint) (int64, error) {
+	var deleted int64
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		completedQuery := tx.Model(&models.Task{}).Select("id").Where("user_id = ? AND completed = ?", userID, true)
+		related := []interface{}{
+			&models.TaskSharedWith{},
+			&models.TaskTag{},
+			&models.Comment{},
+			&models.Notification{},
+		}
+		for _, model := range related {
+			if err := tx.Where("task_id IN (?)", completedQuery).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Where("user_id = ? AND completed = ?", userID, true).Delete(&models.Task{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// ArchiveCompletedByOwner archives every completed, not yet archived task the user owns and
+// returns how many tasks were archived
+func (r *taskRepository) ArchiveCompletedByOwner(ctx context.Context, userID uint) (int64, error) {
+	result := database.DB.WithContext(ctx).Model(&models.Task{}).
+		Where("user_id = ? AND completed = ? AND archived = ?", userID, true, false).
+		Update("archived", true)
+	return result.RowsAffected, result.Error
+}
+
+func (r *taskRepository) Exists(ctx context.Context, id uint) (bool, error) {
 	var count int64
-	if err := database.DB.Model(&models.Task{}).Where("id = ?", id).Count(&count).Error; err != nil {
+	if err := database.DB.WithContext(ctx).Model(&models.Task{}).Where("id = ?", id).Count(&count).Error; err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
-
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"time"
+	"todo-go-backend/internal/database"
+	"todo-go-backend/internal/models"
+)
+
+// TelegramLinkRepository defines the interface for Telegram link code operations
+type TelegramLinkRepository interface {
+	Create(linkCode *models.TelegramLinkCode) error
+	FindValidByCode(code string, now time.Time) (*models.TelegramLinkCode, error)
+	DeleteByUserID(userID uint) error
+}
+
+type telegramLinkRepository struct{}
+
+// NewTelegramLinkRepository creates a new instance of TelegramLinkRepository
+func NewTelegramLinkRepository() TelegramLinkRepository {
+	return &telegramLinkRepository{}
+}
+
+func (r *telegramLinkRepository) Create(linkCode *models.TelegramLinkCode) error {
+	return database.DB.Create(linkCode).Error
+}
+
+// FindValidByCode finds a link code that has not expired yet
+func (r *telegramLinkRepository) FindValidByCode(code string, now time.Time) (*models.TelegramLinkCode, error) {
+	var linkCode models.TelegramLinkCode
+	if err := database.DB.Where("code = ? AND expires_at > ?", code, now.UTC()).First(&linkCode).Error; err != nil {
+		return nil, err
+	}
+	return &linkCode, nil
+}
+
+// DeleteByUserID removes all link codes of a user (used codes and older pending ones)
+func (r *telegramLinkRepository) DeleteByUserID(userID uint) error {
+	return database.DB.Where("user_id = ?", userID).Delete(&models.TelegramLinkCode{}).Error
+}
@@ -1,8 +1,18 @@
 package repositories
 
 import (
+	"errors"
+	"strings"
 	"todo-go-backend/internal/database"
 	"todo-go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Fields a login identifier can match, as returned by FindByUsernameOrEmailValue
+const (
+	MatchedByUsername = "username"
+	MatchedByEmail    = "email"
 )
 
 // UserRepository defines the interface for user operations
@@ -12,10 +22,13 @@ type UserRepository interface {
 	FindByUsername(username string) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
 	FindByUsernameOrEmail(username, email string) (*models.User, error)
-	FindByUsernameOrEmailValue(identifier string) (*models.User, error) // Find by username or email using a single value
+	FindByUsernameOrEmailValue(identifier string) (*models.User, string, error) // Find by email if the value contains '@', then by username; also returns the field that matched
 	ExistsByUsernameOrEmail(username, email string) (bool, error)
 	FindAll() ([]models.User, error) // Find all users
 	FindAllPaginated(page, limit int) ([]models.User, int64, error) // Find all users with pagination
+	SearchPaginated(query string, page, limit int) ([]models.User, int64, error) // Find users whose username or email contains query, with pagination
+	FindAssignablePaginated(userID uint, collaboratorsOnly bool, query string, page, limit int) ([]models.User, int64, error) // Find users the user can assign tasks to, with pagination
+	Update(user *models.User) error
 }
 
 type userRepository struct{}
@@ -61,12 +74,25 @@ func (r *userRepository) FindByUsernameOrEmail(username, email string) (*models.
 	return &user, nil
 }
 
-func (r *userRepository) FindByUsernameOrEmailValue(identifier string) (*models.User, error) {
+// FindByUsernameOrEmailValue resolves a login identifier without ambiguity: a value containing '@' matches
+// an exact email first and only then a username (usernames registered before '@' was disallowed);
+// any other value only matches a username, since every email contains '@'.
+func (r *userRepository) FindByUsernameOrEmailValue(identifier string) (*models.User, string, error) {
 	var user models.User
-	if err := database.DB.Where("username = ? OR email = ?", identifier, identifier).First(&user).Error; err != nil {
-		return nil, err
+	if strings.Contains(identifier, "@") {
+		err := database.DB.Where("email = ?", identifier).First(&user).Error
+		if err == nil {
+			return &user, MatchedByEmail, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
 	}
-	return &user, nil
+
+	if err := database.DB.Where("username = ?", identifier).First(&user).Error; err != nil {
+		return nil, "", err
+	}
+	return &user, MatchedByUsername, nil
 }
 
 func (r *userRepository) ExistsByUsernameOrEmail(username, email string) (bool, error) {
@@ -112,3 +138,69 @@ func (r *userRepository) FindAllPaginated(page, limit int) ([]models.User, int64
 	return users, total, nil
 }
 
+func (r *userRepository) SearchPaginated(query string, page, limit int) ([]models.User, int64, error) {
+	return findUsersPage(searchUsers(database.DB.Model(&models.User{}), query), page, limit)
+}
+
+// FindAssignablePaginated lists every user but userID, optionally only those who share at least
+// one task with them (as owner or shared user of the same task), filtered by query when set
+func (r *userRepository) FindAssignablePaginated(userID uint, collaboratorsOnly bool, query string, page, limit int) ([]models.User, int64, error) {
+	assignable := database.DB.Model(&models.User{}).Where("id <> ?", userID)
+	if collaboratorsOnly {
+		// Owners of tasks shared with the user
+		owners := database.DB.Table("task_shared_with").
+			Select("tasks.user_id").
+			Joins("JOIN tasks ON tasks.id = task_shared_with.task_id AND tasks.deleted_at IS NULL").
+			Where("task_shared_with.user_id = ?", userID)
+		// Users the user's tasks are shared with
+		sharedWith := database.DB.Table("task_shared_with").
+			Select("task_shared_with.user_id").
+			Joins("JOIN tasks ON tasks.id = task_shared_with.task_id AND tasks.deleted_at IS NULL").
+			Where("tasks.user_id = ?", userID)
+		// Users sharing the same task with the user
+		coShared := database.DB.Table("task_shared_with AS mine").
+			Select("others.user_id").
+			Joins("JOIN tasks ON tasks.id = mine.task_id AND tasks.deleted_at IS NULL").
+			Joins("JOIN task_shared_with AS others ON others.task_id = mine.task_id").
+			Where("mine.user_id = ?", userID)
+		assignable = assignable.Where("(id IN (?) OR id IN (?) OR id IN (?))", owners, sharedWith, coShared)
+	}
+	return findUsersPage(searchUsers(assignable, query), page, limit)
+}
+
+// searchUsers keeps the users whose username or email contains query; an empty query keeps all of them
+func searchUsers(users *gorm.DB, query string) *gorm.DB {
+	if query == "" {
+		return users
+	}
+	pattern := "%" + query + "%"
+	return users.Where("(username LIKE ? OR email LIKE ?)", pattern, pattern)
+}
+
+// findUsersPage counts the query, then loads the requested page ordered by username with only the public fields
+func findUsersPage(users *gorm.DB, page, limit int) ([]models.User, int64, error) {
+	var found []models.User
+	var total int64
+
+	if err := users.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+
+	// Only public fields, like FindAllPaginated
+	if err := users.
+		Select("id", "username", "email", "created_at", "updated_at").
+		Order("username ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&found).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return found, total, nil
+}
+
+func (r *userRepository) Update(user *models.User) error {
+	return database.DB.Save(user).Error
+}
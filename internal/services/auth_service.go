@@ -1,6 +1,7 @@
 package services
 
 import (
+	"todo-go-backend/internal/auth"
 	"todo-go-backend/internal/errors"
 	"todo-go-backend/internal/models"
 	"todo-go-backend/internal/repositories"
@@ -9,37 +10,38 @@ import (
 
 // AuthService defines the interface for authentication operations
 type AuthService interface {
-	Register(username, email, password string) (*models.User, string, error)
-	Login(identifier, password string) (*models.User, string, error) // identifier can be username or email
+	Register(username, email, password string) (*models.User, *utils.SignedToken, error)
+	Login(identifier, password string) (*models.User, *utils.SignedToken, string, error) // identifier can be username or email; also returns the field it matched
+	GetUser(userID uint) (*models.User, error)
 }
 
 type authService struct {
 	userRepo repositories.UserRepository
-	jwtSecret string
+	jwtKeys  *auth.KeySet
 }
 
-// NewAuthService creates a new instance of AuthService
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret string) AuthService {
+// NewAuthService creates a new instance of AuthService. Tokens are signed with the key set's current key.
+func NewAuthService(userRepo repositories.UserRepository, jwtKeys *auth.KeySet) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo: userRepo,
+		jwtKeys:  jwtKeys,
 	}
 }
 
-func (s *authService) Register(username, email, password string) (*models.User, string, error) {
+func (s *authService) Register(username, email, password string) (*models.User, *utils.SignedToken, error) {
 	// Check if user already exists
 	exists, err := s.userRepo.ExistsByUsernameOrEmail(username, email)
 	if err != nil {
-		return nil, "", errors.NewInternalServerError(err)
+		return nil, nil, errors.NewInternalServerError(err)
 	}
 	if exists {
-		return nil, "", errors.NewUserAlreadyExistsError()
+		return nil, nil, errors.NewUserAlreadyExistsError()
 	}
 
 	// Hash password
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		return nil, "", errors.NewInternalServerError(err)
+		return nil, nil, errors.NewInternalServerError(err)
 	}
 
 	// Create user
@@ -50,36 +52,43 @@ func (s *authService) Register(username, email, password string) (*models.User,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
-		return nil, "", errors.NewInternalServerError(err)
+		return nil, nil, errors.NewInternalServerError(err)
 	}
 
 	// Generate token
-	token, err := utils.GenerateToken(user.ID, user.Username, s.jwtSecret)
+	token, err := utils.GenerateTokenWithKeySet(user.ID, user.Username, s.jwtKeys)
 	if err != nil {
-		return nil, "", errors.NewInternalServerError(err)
+		return nil, nil, errors.NewInternalServerError(err)
 	}
 
 	return user, token, nil
 }
 
-func (s *authService) Login(identifier, password string) (*models.User, string, error) {
+func (s *authService) Login(identifier, password string) (*models.User, *utils.SignedToken, string, error) {
 	// Find user by username or email
-	user, err := s.userRepo.FindByUsernameOrEmailValue(identifier)
+	user, matchedBy, err := s.userRepo.FindByUsernameOrEmailValue(identifier)
 	if err != nil {
-		return nil, "", errors.NewInvalidCredentialsError()
+		return nil, nil, "", errors.NewInvalidCredentialsError()
 	}
 
 	// Verify password
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return nil, "", errors.NewInvalidCredentialsError()
+		return nil, nil, "", errors.NewInvalidCredentialsError()
 	}
 
 	// Generate token
-	token, err := utils.GenerateToken(user.ID, user.Username, s.jwtSecret)
+	token, err := utils.GenerateTokenWithKeySet(user.ID, user.Username, s.jwtKeys)
 	if err != nil {
-		return nil, "", errors.NewInternalServerError(err)
+		return nil, nil, "", errors.NewInternalServerError(err)
 	}
 
-	return user, token, nil
+	return user, token, matchedBy, nil
 }
 
+func (s *authService) GetUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.NewUserNotFoundError()
+	}
+	return user, nil
+}
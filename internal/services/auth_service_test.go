@@ -1,7 +1,9 @@
 package services
 
 import (
+	"strings"
 	"testing"
+	"todo-go-backend/internal/auth"
 	"todo-go-backend/internal/errors"
 	"todo-go-backend/internal/models"
 	"todo-go-backend/internal/repositories"
@@ -69,14 +71,16 @@ func (m *MockUserRepository) FindByUsernameOrEmail(username, email string) (*mod
 	return nil, errors.ErrUserNotFound
 }
 
-func (m *MockUserRepository) FindByUsernameOrEmailValue(identifier string) (*models.User, error) {
-	if user, ok := m.usersByUser[identifier]; ok {
-		return user, nil
+func (m *MockUserRepository) FindByUsernameOrEmailValue(identifier string) (*models.User, string, error) {
+	if strings.Contains(identifier, "@") {
+		if user, ok := m.usersByEmail[identifier]; ok {
+			return user, repositories.MatchedByEmail, nil
+		}
 	}
-	if user, ok := m.usersByEmail[identifier]; ok {
-		return user, nil
+	if user, ok := m.usersByUser[identifier]; ok {
+		return user, repositories.MatchedByUsername, nil
 	}
-	return nil, errors.ErrUserNotFound
+	return nil, "", errors.ErrUserNotFound
 }
 
 func (m *MockUserRepository) ExistsByUsernameOrEmail(username, email string) (bool, error) {
@@ -124,9 +128,39 @@ func (m *MockUserRepository) FindAllPaginated(page, limit int) ([]models.User, i
 	return paginatedUsers, total, nil
 }
 
+func (m *MockUserRepository) SearchPaginated(query string, page, limit int) ([]models.User, int64, error) {
+	var matches []models.User
+	for _, user := range m.users {
+		if strings.Contains(user.Username, query) || strings.Contains(user.Email, query) {
+			matches = append(matches, *user)
+		}
+	}
+	return matches, int64(len(matches)), nil
+}
+
+func (m *MockUserRepository) FindAssignablePaginated(userID uint, collaboratorsOnly bool, query string, page, limit int) ([]models.User, int64, error) {
+	var assignable []models.User
+	for _, user := range m.users {
+		if user.ID != userID {
+			assignable = append(assignable, *user)
+		}
+	}
+	return assignable, int64(len(assignable)), nil
+}
+
+func (m *MockUserRepository) Update(user *models.User) error {
+	if _, ok := m.users[user.ID]; !ok {
+		return errors.ErrUserNotFound
+	}
+	m.users[user.ID] = user
+	m.usersByUser[user.Username] = user
+	m.usersByEmail[user.Email] = user
+	return nil
+}
+
 func TestAuthService_Register(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	service := NewAuthService(mockRepo, "test-secret")
+	service := NewAuthService(mockRepo, auth.NewKeySet("default", "test-secret", nil))
 
 	t.Run("Register new user successfully", func(t *testing.T) {
 		user, token, err := service.Register("testuser", "test@example.com", "password123")
@@ -150,21 +184,29 @@ func TestAuthService_Register(t *testing.T) {
 
 func TestAuthService_Login(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	service := NewAuthService(mockRepo, "test-secret")
+	service := NewAuthService(mockRepo, auth.NewKeySet("default", "test-secret", nil))
 
 	// Create a user first
 	_, _, _ = service.Register("testuser", "test@example.com", "password123")
 
 	t.Run("Login with valid credentials", func(t *testing.T) {
-		user, token, err := service.Login("testuser", "password123")
+		user, token, matchedBy, err := service.Login("testuser", "password123")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.NotEmpty(t, token)
+		assert.Equal(t, repositories.MatchedByUsername, matchedBy)
+	})
+
+	t.Run("Login with email", func(t *testing.T) {
+		_, _, matchedBy, err := service.Login("test@example.com", "password123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, repositories.MatchedByEmail, matchedBy)
 	})
 
 	t.Run("Login with invalid password", func(t *testing.T) {
-		_, _, err := service.Login("testuser", "wrongpassword")
+		_, _, _, err := service.Login("testuser", "wrongpassword")
 
 		assert.Error(t, err)
 		assert.IsType(t, &errors.AppError{}, err)
@@ -173,7 +215,7 @@ func TestAuthService_Login(t *testing.T) {
 	})
 
 	t.Run("Login with non-existent user", func(t *testing.T) {
-		_, _, err := service.Login("nonexistent", "password123")
+		_, _, _, err := service.Login("nonexistent", "password123")
 
 		assert.Error(t, err)
 		assert.IsType(t, &errors.AppError{}, err)
@@ -0,0 +1,20 @@
+package services
+
+// BulkResult is the result of an operation applied to several tasks at once: how many tasks were
+// changed and, for operations where some tasks can fail while the others go through, which
+// tasks were left unchanged and why
+type BulkResult struct {
+	Affected int64         `json:"affected" example:"12"`
+	Failed   []BulkFailure `json:"failed"`
+}
+
+// BulkFailure is a task left unchanged by a bulk operation, with the reason
+type BulkFailure struct {
+	TaskID  uint   `json:"task_id" example:"7"`
+	Message string `json:"message" example:"Task already has the tag"`
+}
+
+// newBulkResult returns a result with an empty (not null) list of failures
+func newBulkResult() *BulkResult {
+	return &BulkResult{Failed: []BulkFailure{}}
+}
@@ -1,18 +1,26 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
 	"todo-go-backend/internal/errors"
 	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
 	"todo-go-backend/internal/repositories"
+	"unicode/utf8"
 )
 
 // CommentService defines the interface for comment operations
 type CommentService interface {
 	Create(userID uint, req *CreateCommentRequest) (*models.Comment, error)
 	GetByID(userID, commentID uint) (*models.Comment, error)
-	GetByTaskID(userID, taskID uint) ([]models.Comment, error)
+	GetByTaskID(userID, taskID uint, includeDeleted bool) ([]models.Comment, error)
 	Update(userID, commentID uint, req *UpdateCommentRequest) (*models.Comment, error)
 	Delete(userID, commentID uint) error
+	SetPinned(userID, commentID uint, pinned bool) (*models.Comment, error)
 }
 
 // CreateCommentRequest represents a comment creation request
@@ -27,37 +35,64 @@ type UpdateCommentRequest struct {
 }
 
 type commentService struct {
-	commentRepo repositories.CommentRepository
-	taskRepo    repositories.TaskRepository
+	commentRepo      repositories.CommentRepository
+	taskRepo         repositories.TaskRepository
+	publisher        realtime.Publisher
+	notifier         TaskNotifier
+	maxPinned        int
+	lockClosed       bool
+	notifyAll        bool
+	hideInaccessible bool
+	maxLength        int
 }
 
-// NewCommentService creates a new instance of CommentService
-func NewCommentService(commentRepo repositories.CommentRepository, taskRepo repositories.TaskRepository) CommentService {
+// CommentServiceOptions holds the settings of the comment service
+type CommentServiceOptions struct {
+	MaxPinned        int  // Maximum number of pinned comments per task
+	LockClosed       bool // New comments are rejected on tasks that are completed and archived
+	NotifyAll        bool // The assigner and the shared users are notified about new comments, not just the owner
+	HideInaccessible bool // Comments on tasks the user can't access are answered as not found instead of forbidden
+	MaxLength        int  // Maximum number of characters in a comment
+}
+
+// NewCommentService creates a new instance of CommentService. notifier may be nil to skip comment
+// notifications.
+func NewCommentService(commentRepo repositories.CommentRepository, taskRepo repositories.TaskRepository, publisher realtime.Publisher, notifier TaskNotifier, opts CommentServiceOptions) CommentService {
 	return &commentService{
-		commentRepo: commentRepo,
-		taskRepo:    taskRepo,
+		commentRepo:      commentRepo,
+		taskRepo:         taskRepo,
+		publisher:        publisher,
+		notifier:         notifier,
+		maxPinned:        opts.MaxPinned,
+		lockClosed:       opts.LockClosed,
+		notifyAll:        opts.NotifyAll,
+		hideInaccessible: opts.HideInaccessible,
+		maxLength:        opts.MaxLength,
 	}
 }
 
 func (s *commentService) Create(userID uint, req *CreateCommentRequest) (*models.Comment, error) {
 	// Validate content
-	if req.Content == "" || len(req.Content) > 5000 {
-		return nil, errors.NewInvalidInputError("Comment content must be between 1 and 5000 characters")
+	content, err := s.commentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anyone who can access the task (owner, assigner or shared user) can comment
+	if err := s.checkTaskAccess(req.TaskID, userID); err != nil {
+		return nil, err
 	}
 
-	// Check if task exists and user has access
-	task, err := s.taskRepo.FindByID(req.TaskID)
+	task, err := s.taskRepo.FindByID(context.TODO(), req.TaskID)
 	if err != nil {
 		return nil, errors.NewTaskNotFoundError()
 	}
-
-	// User can comment if they own the task or assigned it
-	if task.UserID != userID && (task.AssignedBy == nil || *task.AssignedBy != userID) {
-		return nil, errors.NewForbiddenError()
+	if commentsClosed(task, s.lockClosed) {
+		return nil, errors.NewAppError(errors.ErrForbidden, "Comments are closed on completed and archived tasks", http.StatusForbidden)
 	}
 
 	comment := &models.Comment{
-		Content: req.Content,
+		Content: content,
 		TaskID:  req.TaskID,
 		UserID:  userID,
 	}
@@ -72,6 +107,12 @@ func (s *commentService) Create(userID uint, req *CreateCommentRequest) (*models
 		return nil, errors.NewInternalServerError(err)
 	}
 
+	s.publishCommentCreated(comment)
+	if s.notifier != nil {
+		if recipients := commentRecipients(task, userID, s.notifyAll); len(recipients) > 0 {
+			s.notifier.NotifyCommented(task, comment, recipients)
+		}
+	}
 	return comment, nil
 }
 
@@ -82,35 +123,46 @@ func (s *commentService) GetByID(userID, commentID uint) (*models.Comment, error
 	}
 
 	// Check if user has access to the task
-	task, err := s.taskRepo.FindByID(comment.TaskID)
-	if err != nil {
-		return nil, errors.NewTaskNotFoundError()
-	}
-
-	if task.UserID != userID && (task.AssignedBy == nil || *task.AssignedBy != userID) {
-		return nil, errors.NewForbiddenError()
+	if err := s.checkTaskAccess(comment.TaskID, userID); err != nil {
+		return nil, err
 	}
 
 	return comment, nil
 }
 
-func (s *commentService) GetByTaskID(userID, taskID uint) ([]models.Comment, error) {
-	// Check if task exists and user has access
-	task, err := s.taskRepo.FindByID(taskID)
-	if err != nil {
-		return nil, errors.NewTaskNotFoundError()
+// commentContent trims the content of a new or edited comment and checks its length, so comments of
+// only whitespace are rejected. This is the only place the maximum length is enforced.
+func (s *commentService) commentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" || utf8.RuneCountInString(content) > s.maxLength {
+		return "", errors.NewInvalidInputError(fmt.Sprintf("Comment content must be between 1 and %d characters", s.maxLength))
 	}
+	return content, nil
+}
 
-	// User can view comments if they own the task or assigned it
-	if task.UserID != userID && (task.AssignedBy == nil || *task.AssignedBy != userID) {
-		return nil, errors.NewForbiddenError()
+// DeletedCommentContent replaces the content of deleted comments listed as placeholders
+const DeletedCommentContent = "[deleted]"
+
+// GetByTaskID returns the comments of a task. When includeDeleted is true, deleted comments are kept
+// in the thread as placeholders with their content replaced by DeletedCommentContent.
+func (s *commentService) GetByTaskID(userID, taskID uint, includeDeleted bool) ([]models.Comment, error) {
+	// Anyone who can access the task can view its comments
+	if err := s.checkTaskAccess(taskID, userID); err != nil {
+		return nil, err
 	}
 
-	comments, err := s.commentRepo.FindByTaskID(taskID)
+	comments, err := s.commentRepo.FindByTaskID(taskID, includeDeleted)
 	if err != nil {
 		return nil, errors.NewInternalServerError(err)
 	}
 
+	for i := range comments {
+		if comments[i].DeletedAt.Valid {
+			comments[i].Deleted = true
+			comments[i].Content = DeletedCommentContent
+		}
+	}
+
 	return comments, nil
 }
 
@@ -122,15 +174,16 @@ func (s *commentService) Update(userID, commentID uint, req *UpdateCommentReques
 
 	// Only the comment author can update their comment
 	if comment.UserID != userID {
-		return nil, errors.NewForbiddenError()
+		return nil, s.notAllowedError(comment.TaskID, userID)
 	}
 
 	// Validate content if provided
 	if req.Content != nil {
-		if *req.Content == "" || len(*req.Content) > 5000 {
-			return nil, errors.NewInvalidInputError("Comment content must be between 1 and 5000 characters")
+		content, err := s.commentContent(*req.Content)
+		if err != nil {
+			return nil, err
 		}
-		comment.Content = *req.Content
+		comment.Content = content
 	}
 
 	if err := s.commentRepo.Update(comment); err != nil {
@@ -154,7 +207,7 @@ func (s *commentService) Delete(userID, commentID uint) error {
 
 	// Only the comment author can delete their comment
 	if comment.UserID != userID {
-		return errors.NewForbiddenError()
+		return s.notAllowedError(comment.TaskID, userID)
 	}
 
 	if err := s.commentRepo.Delete(commentID); err != nil {
@@ -164,3 +217,79 @@ func (s *commentService) Delete(userID, commentID uint) error {
 	return nil
 }
 
+// SetPinned pins or unpins a comment. Only the owner of the comment's task can do it, and a task
+// can have at most maxPinned pinned comments.
+func (s *commentService) SetPinned(userID, commentID uint, pinned bool) (*models.Comment, error) {
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return nil, errors.NewTaskNotFoundError()
+	}
+
+	if comment.Task.UserID != userID {
+		return nil, s.notAllowedError(comment.TaskID, userID)
+	}
+
+	if comment.Pinned == pinned {
+		return comment, nil
+	}
+
+	if pinned {
+		count, err := s.commentRepo.CountPinnedByTaskID(comment.TaskID)
+		if err != nil {
+			return nil, errors.NewInternalServerError(err)
+		}
+		if count >= int64(s.maxPinned) {
+			return nil, errors.NewInvalidInputError(fmt.Sprintf("A task can have at most %d pinned comments", s.maxPinned))
+		}
+	}
+
+	comment.Pinned = pinned
+	if err := s.commentRepo.Update(comment); err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	return comment, nil
+}
+
+// publishCommentCreated sends a comment.created event to everyone with access to the comment's task
+func (s *commentService) publishCommentCreated(comment *models.Comment) {
+	task, err := s.taskRepo.FindByID(context.TODO(), comment.TaskID)
+	if err != nil {
+		log.Printf("Failed to load task %d to publish comment %d: %v", comment.TaskID, comment.ID, err)
+		return
+	}
+
+	s.publisher.Publish(taskAudience(task), realtime.Event{Type: realtime.EventCommentCreated, TaskID: task.ID, Data: comment})
+}
+
+// checkTaskAccess returns a not found error if the task doesn't exist and a forbidden error
+// if the user is not its owner, its assigner or a user it was shared with
+func (s *commentService) checkTaskAccess(taskID, userID uint) error {
+	exists, err := s.taskRepo.Exists(context.TODO(), taskID)
+	if err != nil {
+		return errors.NewInternalServerError(err)
+	}
+	if !exists {
+		return errors.NewTaskNotFoundError()
+	}
+
+	canAccess, err := s.taskRepo.UserCanAccessTask(context.TODO(), taskID, userID)
+	if err != nil {
+		return errors.NewInternalServerError(err)
+	}
+	if !canAccess {
+		return inaccessibleTaskError(s.hideInaccessible)
+	}
+	return nil
+}
+
+// commentsClosed reports whether new comments are rejected on the task: with lockClosed set, tasks
+// that are completed and archived take no more comments.
+func commentsClosed(task *models.Task, lockClosed bool) bool {
+	return lockClosed && task.Completed && task.Archived
+}
+
+// notAllowedError returns the error for a user who may not change a comment on the task.
+func (s *commentService) notAllowedError(taskID, userID uint) error {
+	return deniedTaskError(context.TODO(), s.taskRepo, s.hideInaccessible, taskID, userID)
+}
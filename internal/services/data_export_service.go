@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"time"
+	"todo-go-backend/internal/errors"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/repositories"
+)
+
+// DataExportVersion is the version of the document produced by DataExportService
+const DataExportVersion = 1
+
+// DataExportService assembles everything stored for a user into a single document (data takeout)
+type DataExportService interface {
+	Export(userID uint) (*DataExport, error)
+}
+
+// DataExport is the takeout document of a user. Unlike TaskExport it keeps IDs and covers the
+// profile, tags, comments and notification settings too.
+type DataExport struct {
+	Version              int                            `json:"version" example:"1"`
+	ExportedAt           time.Time                      `json:"exported_at" example:"2024-12-31T23:59:59Z"`
+	Profile              DataExportProfile              `json:"profile"`
+	NotificationSettings DataExportNotificationSettings `json:"notification_settings"`
+	Tags                 []DataExportTag                `json:"tags"`
+	Tasks                []DataExportTask               `json:"tasks"`
+}
+
+// DataExportProfile is the user's account data (the password is never exported)
+type DataExportProfile struct {
+	ID        uint      `json:"id" example:"1"`
+	Username  string    `json:"username" example:"johndoe"`
+	Email     string    `json:"email" example:"john@example.com"`
+	CreatedAt time.Time `json:"created_at" example:"2024-12-01T10:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-12-01T10:00:00Z"`
+}
+
+// DataExportNotificationSettings are the user's notification preferences
+type DataExportNotificationSettings struct {
+	NotificationsEnabled bool            `json:"notifications_enabled" example:"true"`
+	Language             models.Language `json:"language" example:"pt"`
+	NotifyDueSoon        bool            `json:"notify_due_soon" example:"true"`
+	NotifyDueToday       bool            `json:"notify_due_today" example:"true"`
+	NotifyOverdue        bool            `json:"notify_overdue" example:"true"`
+	PreferredChannels    string          `json:"preferred_channels" example:"telegram,email"`
+	TelegramChatID       *string         `json:"telegram_chat_id" example:"123456789"`
+	TelegramThreadID     *int            `json:"telegram_message_thread_id" example:"42"`
+}
+
+// DataExportTag is one of the user's tags
+type DataExportTag struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Urgente"`
+	Color     string    `json:"color" example:"#FF5733"`
+	CreatedAt time.Time `json:"created_at" example:"2024-12-01T10:00:00Z"`
+}
+
+// DataExportTask is a task owned by the user with its tags and comments
+type DataExportTask struct {
+	ID          uint                `json:"id" example:"1"`
+	Title       string              `json:"title" example:"Clean the house"`
+	Description string              `json:"description" example:"Clean all rooms"`
+	Type        models.TaskType     `json:"type" example:"casa"`
+	Priority    models.Priority     `json:"priority" example:"media"`
+	DueDate     *time.Time          `json:"due_date" example:"2024-12-31T23:59:59Z"`
+	Completed   bool                `json:"completed" example:"false"`
+	CompletedAt *time.Time          `json:"completed_at" example:"2024-12-20T18:30:00Z"`
+	Archived    bool                `json:"archived" example:"false"`
+	AssignedBy  *uint               `json:"assigned_by" example:"2"`
+	TagIDs      []uint              `json:"tag_ids"`
+	Comments    []DataExportComment `json:"comments"`
+	CreatedAt   time.Time           `json:"created_at" example:"2024-12-01T10:00:00Z"`
+	UpdatedAt   time.Time           `json:"updated_at" example:"2024-12-01T10:00:00Z"`
+}
+
+// DataExportComment is a comment on one of the user's tasks, identified by its author's username
+type DataExportComment struct {
+	ID        uint      `json:"id" example:"1"`
+	Author    string    `json:"author" example:"johndoe"`
+	Content   string    `json:"content" example:"Remember to buy cleaning supplies"`
+	CreatedAt time.Time `json:"created_at" example:"2024-12-01T10:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-12-01T10:00:00Z"`
+}
+
+type dataExportService struct {
+	userRepo    repositories.UserRepository
+	taskRepo    repositories.TaskRepository
+	tagRepo     repositories.TagRepository
+	commentRepo repositories.CommentRepository
+}
+
+// NewDataExportService creates a new instance of DataExportService
+func NewDataExportService(userRepo repositories.UserRepository, taskRepo repositories.TaskRepository, tagRepo repositories.TagRepository, commentRepo repositories.CommentRepository) DataExportService {
+	return &dataExportService{
+		userRepo:    userRepo,
+		taskRepo:    taskRepo,
+		tagRepo:     tagRepo,
+		commentRepo: commentRepo,
+	}
+}
+
+// Export returns the user's profile, notification settings, tags and every task they own
+// (archived included) with its tags and comments
+func (s *dataExportService) Export(userID uint) (*DataExport, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.NewUserNotFoundError()
+	}
+
+	tags, err := s.tagRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	tasks, err := s.taskRepo.FindAllByOwner(context.TODO(), userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	taskIDs := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+	comments, err := s.commentRepo.FindByTaskIDs(taskIDs)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	commentsByTask := make(map[uint][]DataExportComment, len(tasks))
+	for _, comment := range comments {
+		commentsByTask[comment.TaskID] = append(commentsByTask[comment.TaskID], DataExportComment{
+			ID:        comment.ID,
+			Author:    comment.User.Username,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		})
+	}
+
+	export := &DataExport{
+		Version:    DataExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Profile: DataExportProfile{
+			ID:        user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		NotificationSettings: DataExportNotificationSettings{
+			NotificationsEnabled: user.NotificationsEnabled,
+			Language:             user.Language,
+			NotifyDueSoon:        user.NotifyDueSoon,
+			NotifyDueToday:       user.NotifyDueToday,
+			NotifyOverdue:        user.NotifyOverdue,
+			PreferredChannels:    user.PreferredChannels,
+			TelegramChatID:       user.TelegramChatID,
+			TelegramThreadID:     user.TelegramThreadID,
+		},
+		Tags:  make([]DataExportTag, 0, len(tags)),
+		Tasks: make([]DataExportTask, 0, len(tasks)),
+	}
+
+	for _, tag := range tags {
+		export.Tags = append(export.Tags, DataExportTag{
+			ID:        tag.ID,
+			Name:      tag.Name,
+			Color:     tag.Color,
+			CreatedAt: tag.CreatedAt,
+		})
+	}
+
+	for _, task := range tasks {
+		tagIDs := make([]uint, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+		taskComments := commentsByTask[task.ID]
+		if taskComments == nil {
+			taskComments = []DataExportComment{}
+		}
+
+		export.Tasks = append(export.Tasks, DataExportTask{
+			ID:          task.ID,
+			Title:       task.Title,
+			Description: task.Description,
+			Type:        task.Type,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+			Completed:   task.Completed,
+			CompletedAt: task.CompletedAt,
+			Archived:    task.Archived,
+			AssignedBy:  task.AssignedBy,
+			TagIDs:      tagIDs,
+			Comments:    taskComments,
+			CreatedAt:   task.CreatedAt,
+			UpdatedAt:   task.UpdatedAt,
+		})
+	}
+
+	return export, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import "todo-go-backend/internal/models"
+
+// TaskNotifier sends immediate notifications (email, Telegram) about task activity.
+// Services depend on it instead of on the notifications package directly; nil disables them.
+type TaskNotifier interface {
+	// NotifyAssigned tells the owner of a task that another user created it for them
+	NotifyAssigned(task *models.Task)
+	// NotifyCommented tells the recipients that a comment was added to the task
+	NotifyCommented(task *models.Task, comment *models.Comment, recipients []models.User)
+}
+
+// notifyAssigned notifies the owner of a task created by someone else
+func notifyAssigned(notifier TaskNotifier, task *models.Task) {
+	if notifier == nil || task.AssignedBy == nil {
+		return
+	}
+	notifier.NotifyAssigned(task)
+}
+
+// commentRecipients returns who is notified about a new comment: the task owner and, when
+// includeCollaborators is set, the assigner and the users the task is shared with. The comment
+// author is never included. The task must be loaded with its users.
+func commentRecipients(task *models.Task, authorID uint, includeCollaborators bool) []models.User {
+	seen := map[uint]bool{authorID: true}
+	var recipients []models.User
+	add := func(user models.User) {
+		if user.ID == 0 || seen[user.ID] {
+			return
+		}
+		seen[user.ID] = true
+		recipients = append(recipients, user)
+	}
+
+	add(task.User)
+	if includeCollaborators {
+		if task.AssignedByUser != nil {
+			add(*task.AssignedByUser)
+		}
+		for _, user := range task.SharedWithUsers {
+			add(user)
+		}
+	}
+	return recipients
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"log"
+	"strings"
 	"todo-go-backend/internal/errors"
 	"todo-go-backend/internal/models"
 	"todo-go-backend/internal/repositories"
@@ -9,12 +11,17 @@ import (
 // TagService defines the interface for tag operations
 type TagService interface {
 	Create(userID uint, req *CreateTagRequest) (*models.Tag, error)
+	EnsureByName(userID uint, name, color string) (tag *models.Tag, created bool, err error)
 	GetByID(userID, tagID uint) (*models.Tag, error)
 	GetByUserID(userID uint) ([]models.Tag, error)
 	Update(userID, tagID uint, req *UpdateTagRequest) (*models.Tag, error)
 	Delete(userID, tagID uint) error
+	Palette() []string
 }
 
+// DefaultTagColor is the color given to tags created without one when no palette is configured
+const DefaultTagColor = "#808080"
+
 // CreateTagRequest represents a tag creation request
 type CreateTagRequest struct {
 	Name  string
@@ -24,58 +31,83 @@ type CreateTagRequest struct {
 // UpdateTagRequest represents a tag update request
 type UpdateTagRequest struct {
 	Name  *string
-	Color *string
+	Color *string // An empty color resets the tag to the default color
 }
 
 type tagService struct {
 	tagRepo repositories.TagRepository
+	palette []string // Allowed colors in uppercase; empty allows any hex color
 }
 
-// NewTagService creates a new instance of TagService
-func NewTagService(tagRepo repositories.TagRepository) TagService {
+// NewTagService creates a new instance of TagService. When palette is not empty, tag colors
+// must be one of its colors (compared case-insensitively). Invalid palette entries are ignored.
+func NewTagService(tagRepo repositories.TagRepository, palette []string) TagService {
+	allowed := make([]string, 0, len(palette))
+	for _, color := range palette {
+		if !isValidHexColor(color) {
+			log.Printf("Ignoring invalid tag palette color %q", color)
+			continue
+		}
+		allowed = append(allowed, strings.ToUpper(color))
+	}
+
 	return &tagService{
 		tagRepo: tagRepo,
+		palette: allowed,
 	}
 }
 
 func (s *tagService) Create(userID uint, req *CreateTagRequest) (*models.Tag, error) {
-	// Check if tag with same name already exists for this user
-	exists, err := s.tagRepo.ExistsByNameAndUserID(req.Name, userID)
+	tag, created, err := s.EnsureByName(userID, req.Name, req.Color)
 	if err != nil {
-		return nil, errors.NewInternalServerError(err)
+		return nil, err
 	}
-	if exists {
+	if !created {
 		return nil, errors.NewInvalidInputError("A tag with this name already exists")
 	}
+	return tag, nil
+}
 
-	// Validate color format if provided
-	if req.Color != "" && !isValidHexColor(req.Color) {
-		return nil, errors.NewInvalidInputError("Invalid color format. Use hex color code (e.g., #FF5733)")
-	}
-
-	// Set default color if not provided
-	color := req.Color
-	if color == "" {
-		color = "#808080" // Default gray
+// EnsureByName returns the user's tag with the given name (case-insensitive), creating it if it doesn't exist.
+// The color is only used when the tag is created. The tag is created or found in a single insert
+// that the unique (user_id, name_key) index resolves, so concurrent calls never create duplicates.
+func (s *tagService) EnsureByName(userID uint, name, color string) (*models.Tag, bool, error) {
+	name = normalizeTagName(name)
+	if name == "" {
+		return nil, false, errors.NewInvalidInputError("Tag name is required")
+	}
+
+	// Validate color if provided; an existing tag is still returned, as its color isn't changed
+	if color != "" {
+		if err := s.validateColor(color); err != nil {
+			if existing, findErr := s.tagRepo.FindByNameInsensitive(name, userID); findErr == nil {
+				return existing, false, nil
+			}
+			return nil, false, err
+		}
 	}
 
 	tag := &models.Tag{
-		Name:   req.Name,
+		Name:   name,
 		Color:  color,
 		UserID: userID,
 	}
-
-	if err := s.tagRepo.Create(tag); err != nil {
-		return nil, errors.NewInternalServerError(err)
+	if tag.Color == "" {
+		tag.Color = s.defaultColor()
+		tag.DefaultColor = true
 	}
 
-	return tag, nil
+	created, err := s.tagRepo.FirstOrCreateByName(tag)
+	if err != nil {
+		return nil, false, errors.NewInternalServerError(err)
+	}
+	return tag, created, nil
 }
 
 func (s *tagService) GetByID(userID, tagID uint) (*models.Tag, error) {
 	tag, err := s.tagRepo.FindByIDAndUserID(tagID, userID)
 	if err != nil {
-		return nil, errors.NewTaskNotFoundError() // Reuse error type
+		return nil, errors.NewTagNotFoundError()
 	}
 	return tag, nil
 }
@@ -91,22 +123,32 @@ func (s *tagService) GetByUserID(userID uint) ([]models.Tag, error) {
 func (s *tagService) Update(userID, tagID uint, req *UpdateTagRequest) (*models.Tag, error) {
 	tag, err := s.tagRepo.FindByIDAndUserID(tagID, userID)
 	if err != nil {
-		return nil, errors.NewTaskNotFoundError()
+		return nil, errors.NewTagNotFoundError()
 	}
 
 	if req.Name != nil {
-		// Check if another tag with the same name already exists for this user
-		existingTag, err := s.tagRepo.FindByNameAndUserID(*req.Name, userID)
+		name := normalizeTagName(*req.Name)
+		if name == "" {
+			return nil, errors.NewInvalidInputError("Tag name is required")
+		}
+		// Check if another tag with the same name (ignoring case) already exists for this user
+		existingTag, err := s.tagRepo.FindByNameInsensitive(name, userID)
 		if err == nil && existingTag.ID != tagID {
 			return nil, errors.NewInvalidInputError("A tag with this name already exists")
 		}
-		tag.Name = *req.Name
+		tag.Name = name
 	}
 	if req.Color != nil {
-		if !isValidHexColor(*req.Color) {
-			return nil, errors.NewInvalidInputError("Invalid color format. Use hex color code (e.g., #FF5733)")
+		if *req.Color == "" {
+			tag.Color = s.defaultColor()
+			tag.DefaultColor = true
+		} else {
+			if err := s.validateColor(*req.Color); err != nil {
+				return nil, err
+			}
+			tag.Color = *req.Color
+			tag.DefaultColor = false
 		}
-		tag.Color = *req.Color
 	}
 
 	if err := s.tagRepo.Update(tag); err != nil {
@@ -119,7 +161,7 @@ func (s *tagService) Update(userID, tagID uint, req *UpdateTagRequest) (*models.
 func (s *tagService) Delete(userID, tagID uint) error {
 	tag, err := s.tagRepo.FindByIDAndUserID(tagID, userID)
 	if err != nil {
-		return errors.NewTaskNotFoundError()
+		return errors.NewTagNotFoundError()
 	}
 
 	if err := s.tagRepo.Delete(tag.ID); err != nil {
@@ -129,6 +171,41 @@ func (s *tagService) Delete(userID, tagID uint) error {
 	return nil
 }
 
+// Palette returns the allowed tag colors, or an empty list if any hex color is allowed
+func (s *tagService) Palette() []string {
+	return s.palette
+}
+
+// normalizeTagName trims a tag name and collapses runs of whitespace inside it, so "  Work  items"
+// and "Work items" are the same tag
+func normalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// defaultColor returns the color given to tags without one: the first palette color, or DefaultTagColor
+func (s *tagService) defaultColor() string {
+	if len(s.palette) > 0 {
+		return s.palette[0]
+	}
+	return DefaultTagColor
+}
+
+// validateColor checks the hex format and, when a palette is configured, that the color belongs to it
+func (s *tagService) validateColor(color string) error {
+	if !isValidHexColor(color) {
+		return errors.NewInvalidInputError("Invalid color format. Use hex color code (e.g., #FF5733)")
+	}
+	if len(s.palette) == 0 {
+		return nil
+	}
+	for _, allowed := range s.palette {
+		if strings.EqualFold(color, allowed) {
+			return nil
+		}
+	}
+	return errors.NewInvalidInputError("Color is not in the allowed palette: " + strings.Join(s.palette, ", "))
+}
+
 // isValidHexColor validates hex color format
 func isValidHexColor(color string) bool {
 	if len(color) != 7 || color[0] != '#' {
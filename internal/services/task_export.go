@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"todo-go-backend/internal/errors"
+	"todo-go-backend/internal/models"
+)
+
+// TaskExportVersion is the version of the export format produced by Export
+const TaskExportVersion = 1
+
+// MaxImportTasks is the maximum number of tasks accepted by Import
+const MaxImportTasks = 1000
+
+// TaskExport is the portable JSON representation of a user's tasks
+type TaskExport struct {
+	Version    int            `json:"version" example:"1"`
+	ExportedAt time.Time      `json:"exported_at" example:"2024-12-31T23:59:59Z"`
+	Tasks      []ExportedTask `json:"tasks"`
+}
+
+// ExportedTask is a task without IDs or user references, so it can be recreated on another instance
+type ExportedTask struct {
+	Title       string        `json:"title" example:"Clean the house"`
+	Description string        `json:"description" example:"Clean all rooms"`
+	Type        string        `json:"type" example:"casa"`
+	Priority    string        `json:"priority" example:"media"`
+	DueDate     *time.Time    `json:"due_date" example:"2024-12-31T23:59:59Z"`
+	Completed   bool          `json:"completed" example:"false"`
+	Archived    bool          `json:"archived" example:"false"`
+	Tags        []ExportedTag `json:"tags"`
+	CreatedAt   time.Time     `json:"created_at" example:"2024-12-01T10:00:00Z"`
+}
+
+// ExportedTag is a tag identified by name
+type ExportedTag struct {
+	Name  string `json:"name" example:"Urgente"`
+	Color string `json:"color" example:"#FF5733"`
+}
+
+// ImportResult reports how many tasks were imported and which items were skipped
+type ImportResult struct {
+	Imported int              `json:"imported" example:"10"`
+	Skipped  []BatchItemError `json:"skipped"`
+}
+
+// importAliases maps common spellings found in exported data to the stored values
+var importAliases = map[string]string{
+	"saúde": "saude",
+	"média": "media",
+}
+
+// Export returns all tasks owned by the user (archived included) in the portable format
+func (s *taskService) Export(ctx context.Context, userID uint) (*TaskExport, error) {
+	tasks, err := s.taskRepo.FindAllByOwner(ctx, userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	export := &TaskExport{
+		Version:    TaskExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Tasks:      make([]ExportedTask, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		tags := make([]ExportedTag, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			tags = append(tags, ExportedTag{Name: tag.Name, Color: tag.Color})
+		}
+		export.Tasks = append(export.Tasks, ExportedTask{
+			Title:       task.Title,
+			Description: task.Description,
+			Type:        string(task.Type),
+			Priority:    string(task.Priority),
+			DueDate:     task.DueDate,
+			Completed:   task.Completed,
+			Archived:    task.Archived,
+			Tags:        tags,
+			CreatedAt:   task.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// Import recreates the exported tasks for the user, creating missing tags by name.
+// Invalid items are skipped and reported instead of aborting the import.
+func (s *taskService) Import(ctx context.Context, userID uint, export *TaskExport) (*ImportResult, error) {
+	if len(export.Tasks) > MaxImportTasks {
+		return nil, errors.NewInvalidInputError(fmt.Sprintf("An import can contain at most %d tasks", MaxImportTasks))
+	}
+
+	result := &ImportResult{Skipped: []BatchItemError{}}
+	for i, exported := range export.Tasks {
+		task, err := s.importedTask(userID, &exported)
+		if err != nil {
+			result.Skipped = append(result.Skipped, BatchItemError{Index: i, Message: err.Error()})
+			continue
+		}
+
+		if err := s.taskRepo.Create(ctx, task); err != nil {
+			result.Skipped = append(result.Skipped, BatchItemError{Index: i, Message: "Failed to save task"})
+			continue
+		}
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+// importedTask validates an exported task and builds it for the user, resolving tags by name
+func (s *taskService) importedTask(userID uint, exported *ExportedTask) (*models.Task, error) {
+	title := strings.TrimSpace(exported.Title)
+	if title == "" {
+		return nil, errors.NewInvalidInputError("Title is required")
+	}
+	if utf8.RuneCountInString(title) > 200 {
+		return nil, errors.NewInvalidInputError("Title must be at most 200 characters long")
+	}
+
+	taskType := models.TaskType(normalizeImportValue(exported.Type))
+	if !isValidTaskType(taskType) {
+		return nil, errors.NewInvalidInputError(InvalidTaskTypeMessage)
+	}
+
+	priority := models.PriorityMedia
+	if exported.Priority != "" {
+		priority = models.Priority(normalizeImportValue(exported.Priority))
+		if !isValidPriority(priority) {
+			return nil, errors.NewInvalidInputError(InvalidPriorityMessage)
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(exported.Tags))
+	seen := make(map[string]bool, len(exported.Tags))
+	for _, exportedTag := range exported.Tags {
+		name := normalizeTagName(exportedTag.Name)
+		if name == "" || utf8.RuneCountInString(name) > 50 {
+			return nil, errors.NewInvalidInputError("Tag names must have between 1 and 50 characters")
+		}
+		if seen[models.TagNameKey(name)] {
+			continue
+		}
+		seen[models.TagNameKey(name)] = true
+
+		tag := models.Tag{Name: name, Color: exportedTag.Color}
+		if !isValidHexColor(tag.Color) {
+			tag.Color = DefaultTagColor
+			tag.DefaultColor = true
+		}
+		tags = append(tags, tag)
+	}
+	if err := s.checkTagLimit(len(tags)); err != nil {
+		return nil, err
+	}
+
+	userTags, err := s.copyTagsToUser(tags, userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	return &models.Task{
+		Title:       title,
+		Description: exported.Description,
+		Type:        taskType,
+		Priority:    priority,
+		DueDate:     utcTime(exported.DueDate),
+		UserID:      userID,
+		Completed:   exported.Completed,
+		CompletedAt: completedAt(exported.Completed),
+		Archived:    exported.Archived,
+		Tags:        userTags,
+	}, nil
+}
+
+// normalizeImportValue lowercases a type or priority and maps accented spellings
+func normalizeImportValue(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if alias, ok := importAliases[value]; ok {
+		return alias
+	}
+	return value
+}
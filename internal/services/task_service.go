@@ -1,22 +1,44 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 	"todo-go-backend/internal/errors"
 	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
 	"todo-go-backend/internal/repositories"
 )
 
-// TaskService defines the interface for task operations
+// TaskService defines the interface for task operations. ctx is the request context: it is passed
+// on to the repository, so queries are cancelled with the request.
 type TaskService interface {
-	Create(userID uint, req *CreateTaskRequest) (*models.Task, error)
-	GetByID(userID, taskID uint) (*models.Task, error)
-	GetByUserID(userID uint, filters *TaskFilters) (*PaginatedTasksResponse, error)
-	GetAssignedByUser(assignedByID uint, filters *TaskFilters) (*PaginatedTasksResponse, error)
-	Update(userID, taskID uint, req *UpdateTaskRequest) (*models.Task, error)
-	Delete(userID, taskID uint) error
-	ShareTask(ownerID, taskID uint, userIDs []uint) error
-	UnshareTask(ownerID, taskID uint, sharedUserID uint) error
+	Create(ctx context.Context, userID uint, req *CreateTaskRequest) (*models.Task, error)
+	CreateMany(ctx context.Context, userID uint, reqs []*CreateTaskRequest) ([]models.Task, error)
+	GetByID(ctx context.Context, userID, taskID uint) (*models.Task, error)
+	CheckAccess(ctx context.Context, userID, taskID uint) error
+	GetByUserID(ctx context.Context, userID uint, filters *TaskFilters) (*PaginatedTasksResponse, error)
+	GetByTag(ctx context.Context, userID, tagID uint, filters *TaskFilters) (*PaginatedTasksResponse, error)
+	GetAssignedByUser(ctx context.Context, assignedByID uint, filters *TaskFilters) (*PaginatedTasksResponse, error)
+	GetAssignedByUserByAssignee(ctx context.Context, assignedByID uint, filters *TaskFilters) (*AssigneeGroupsResponse, error)
+	GetAssignedToUser(ctx context.Context, userID uint, filters *TaskFilters) (*PaginatedTasksResponse, error)
+	CountPendingAssignedToUser(ctx context.Context, userID uint) (int64, error)
+	GetUpcoming(ctx context.Context, userID uint, hours int) ([]models.Task, error)
+	GetStats(ctx context.Context, userID uint, from, to *time.Time, includeArchived bool) (*TaskStats, error)
+	Update(ctx context.Context, userID, taskID uint, req *UpdateTaskRequest) (*models.Task, error)
+	Delete(ctx context.Context, userID, taskID uint) error
+	DeleteCompleted(ctx context.Context, userID uint) (*ClearCompletedResult, error)
+	SetArchived(ctx context.Context, userID, taskID uint, archived bool) (*models.Task, error)
+	Export(ctx context.Context, userID uint) (*TaskExport, error)
+	Import(ctx context.Context, userID uint, export *TaskExport) (*ImportResult, error)
+	ShareTask(ctx context.Context, ownerID, taskID uint, userIDs []uint) error
+	UnshareTask(ctx context.Context, ownerID, taskID uint, sharedUserID uint) error
+	AssignTag(ctx context.Context, userID, tagID uint, taskIDs []uint) (*BulkResult, error)
+	UnassignTag(ctx context.Context, userID, tagID uint, taskIDs []uint) (*BulkResult, error)
 }
 
 // CreateTaskRequest represents a task creation request
@@ -26,8 +48,39 @@ type CreateTaskRequest struct {
 	Type        models.TaskType
 	Priority    *models.Priority // Optional: task priority
 	DueDate     *time.Time
-	UserID      *uint   // Optional: ID of the user to whom the task will be assigned
-	TagIDs      []uint  // Optional: IDs of tags to associate with the task
+	UserID      *uint  // Optional: ID of the user to whom the task will be assigned
+	TagIDs      []uint // Optional: IDs of tags to associate with the task
+}
+
+// MaxUpcomingHours is the widest window accepted by GetUpcoming (30 days)
+const MaxUpcomingHours = 720
+
+// MaxBatchCreateTasks is the maximum number of tasks accepted by CreateMany
+const MaxBatchCreateTasks = 100
+
+// EmptyTitleMessage is returned when a task title is empty or only whitespace
+const EmptyTitleMessage = "Title must not be empty or only whitespace"
+
+// InvalidTaskTypeMessage and InvalidPriorityMessage are returned for a task type or priority outside
+// models.TaskTypes/models.Priorities. The handlers use them for binding errors too, so both say the same thing.
+var (
+	InvalidTaskTypeMessage = "Invalid task type. Must be one of: " + joinValues(models.TaskTypes)
+	InvalidPriorityMessage = "Invalid priority. Must be one of: " + joinValues(models.Priorities)
+)
+
+// BatchItemError describes why an item of a batch was rejected
+type BatchItemError struct {
+	Index   int    `json:"index" example:"0"` // Position of the item in the request
+	Message string `json:"message" example:"User not found"`
+}
+
+// BatchCreateError is returned by CreateMany when one or more items are invalid
+type BatchCreateError struct {
+	Items []BatchItemError
+}
+
+func (e *BatchCreateError) Error() string {
+	return fmt.Sprintf("%d tasks in the batch are invalid", len(e.Items))
 }
 
 // UpdateTaskRequest represents a task update request
@@ -41,20 +94,32 @@ type UpdateTaskRequest struct {
 	TagIDs      *[]uint // Optional: IDs of tags to associate with the task (nil = no change, empty = remove all)
 }
 
+// editsCoreFields reports whether the request changes anything other than the completion status
+func (r *UpdateTaskRequest) editsCoreFields() bool {
+	return r.Title != nil || r.Description != nil || r.Type != nil || r.Priority != nil || r.DueDate != nil || r.TagIDs != nil
+}
+
 // TaskFilters defines filters for task search
 type TaskFilters struct {
-	Type        *models.TaskType
-	Completed   *bool
-	Priority    *models.Priority
-	Search      *string
-	DueDateFrom *time.Time
-	DueDateTo   *time.Time
-	AssignedBy  *uint
-	TagIDs      []uint // Filter by tag IDs
-	Page        int
-	Limit       int
-	SortBy      string // created_at, due_date, title, priority
-	Order       string // asc, desc
+	Types           []models.TaskType // Filter by any of the given types
+	Completed       *bool
+	Priorities      []models.Priority // Filter by any of the given priorities
+	Search          *string
+	DueDateFrom     *time.Time
+	DueDateTo       *time.Time
+	Overdue         bool // Only pending tasks whose due date has passed
+	IncludeArchived bool // Archived tasks are excluded unless set
+	AssignedBy      *uint
+	AssigneeID      *uint    // Only tasks owned by this user (GetAssignedByUser only)
+	TagIDs          []uint   // Filter by tag IDs
+	HasComments     *bool    // Only tasks with (true) or without (false) comments
+	IncludeCounts   bool     // Also return counts per completion bucket
+	Include         []string // Relations to preload (user, assigned_by_user, updated_by_user, shared_with, tags); nil = all
+	Page            int
+	Limit           int
+	All             bool   // Return every matching task on a single page, up to the configured cap (GetByUserID only)
+	SortBy          string // created_at, due_date, title, priority
+	Order           string // asc, desc
 }
 
 // PaginatedTasksResponse represents a paginated response
@@ -64,34 +129,186 @@ type PaginatedTasksResponse struct {
 	Page       int           `json:"page"`
 	Limit      int           `json:"limit"`
 	TotalPages int           `json:"total_pages"`
+	Counts     *TaskCounts   `json:"counts,omitempty"` // Only present when requested with include_counts
+}
+
+// TaskCounts holds the number of tasks per completion bucket, ignoring completion, overdue and pagination filters
+type TaskCounts struct {
+	Total     int64 `json:"total" example:"12"`
+	Pending   int64 `json:"pending" example:"7"`
+	Completed int64 `json:"completed" example:"5"`
+	Overdue   int64 `json:"overdue" example:"2"`
+}
+
+// AssigneeGroupsResponse lists the tasks a user assigned to others, grouped by assignee
+type AssigneeGroupsResponse struct {
+	Groups []AssigneeTaskGroup `json:"groups"`
+	Total  int64               `json:"total" example:"12"` // Tasks across all groups
+}
+
+// AssigneeTaskGroup holds the tasks assigned to one user, with their counts
+type AssigneeTaskGroup struct {
+	AssigneeID       uint          `json:"assignee_id" example:"3"`
+	AssigneeUsername string        `json:"assignee_username" example:"maria"`
+	Counts           TaskCounts    `json:"counts"`
+	Tasks            []models.Task `json:"tasks"`
+}
+
+// TaskStatsCounts holds the activity of a date range: the tasks created in it, how many of those are
+// still pending or overdue, and the tasks completed in it, whenever they were created
+type TaskStatsCounts struct {
+	Created   int64 `json:"created" example:"12"`
+	Completed int64 `json:"completed" example:"9"`
+	Pending   int64 `json:"pending" example:"7"`
+	Overdue   int64 `json:"overdue" example:"2"`
+}
+
+// TaskStats holds the counts of a date range, overall and per type
+type TaskStats struct {
+	From *time.Time `json:"from,omitempty" example:"2024-12-01T00:00:00Z"` // Omitted when the range has no start
+	To   *time.Time `json:"to,omitempty" example:"2024-12-31T23:59:59Z"`   // Omitted when the range has no end
+	TaskStatsCounts
+	ByType map[models.TaskType]TaskStatsCounts `json:"by_type"`
 }
 
 type taskService struct {
-	taskRepo repositories.TaskRepository
-	userRepo repositories.UserRepository
-	tagRepo  repositories.TagRepository
+	taskRepo              repositories.TaskRepository
+	userRepo              repositories.UserRepository
+	tagRepo               repositories.TagRepository
+	publisher             realtime.Publisher
+	notifier              TaskNotifier
+	maxTags               int
+	clearCompletedArchive bool
+	listAllMax            int
+	hideInaccessible      bool
+	commentLockClosed     bool
+}
+
+// TaskServiceOptions holds the settings of the task service
+type TaskServiceOptions struct {
+	MaxTags               int  // Maximum number of tags attached to a task
+	ClearCompletedArchive bool // DeleteCompleted archives the tasks instead of deleting them
+	ListAllMax            int  // Most tasks GetByUserID returns at once when filters.All is set
+	HideInaccessible      bool // Tasks the user can't access are answered as not found instead of forbidden
+	CommentLockClosed     bool // Comments are closed on tasks that are completed and archived, as in CommentServiceOptions
 }
 
-// NewTaskService creates a new instance of TaskService
-func NewTaskService(taskRepo repositories.TaskRepository, userRepo repositories.UserRepository, tagRepo repositories.TagRepository) TaskService {
+// NewTaskService creates a new instance of TaskService. notifier may be nil to skip assignment
+// notifications.
+func NewTaskService(taskRepo repositories.TaskRepository, userRepo repositories.UserRepository, tagRepo repositories.TagRepository, publisher realtime.Publisher, notifier TaskNotifier, opts TaskServiceOptions) TaskService {
 	return &taskService{
-		taskRepo: taskRepo,
-		userRepo: userRepo,
-		tagRepo:  tagRepo,
+		taskRepo:              taskRepo,
+		userRepo:              userRepo,
+		tagRepo:               tagRepo,
+		publisher:             publisher,
+		notifier:              notifier,
+		maxTags:               opts.MaxTags,
+		clearCompletedArchive: opts.ClearCompletedArchive,
+		listAllMax:            opts.ListAllMax,
+		hideInaccessible:      opts.HideInaccessible,
+		commentLockClosed:     opts.CommentLockClosed,
+	}
+}
+
+func (s *taskService) Create(ctx context.Context, userID uint, req *CreateTaskRequest) (*models.Task, error) {
+	task, err := s.newTask(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags are user-specific, so the assignee gets their own copy of each tag
+	if err := s.assignTagsToOwner(task); err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	// A task created for another user is shared with the creator, in the same transaction, so both have access
+	if err := s.taskRepo.Create(ctx, task); err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	// Reload with relationships
+	task, err = s.taskRepo.FindByID(ctx, task.ID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	publishTaskEvent(s.publisher, realtime.EventTaskCreated, task)
+	notifyAssigned(s.notifier, task)
+	return task, nil
+}
+
+// CreateMany validates every request and creates all tasks in a single transaction.
+// If any item is invalid nothing is created and a *BatchCreateError reports the failing items.
+func (s *taskService) CreateMany(ctx context.Context, userID uint, reqs []*CreateTaskRequest) ([]models.Task, error) {
+	if len(reqs) == 0 {
+		return nil, errors.NewInvalidInputError("At least one task is required")
+	}
+	if len(reqs) > MaxBatchCreateTasks {
+		return nil, errors.NewInvalidInputError(fmt.Sprintf("A batch can contain at most %d tasks", MaxBatchCreateTasks))
+	}
+
+	tasks := make([]*models.Task, 0, len(reqs))
+	batchErr := &BatchCreateError{}
+	for i, req := range reqs {
+		task, err := s.newTask(userID, req)
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusInternalServerError {
+			return nil, err
+		}
+		if err != nil {
+			batchErr.Items = append(batchErr.Items, BatchItemError{Index: i, Message: err.Error()})
+			continue
+		}
+		tasks = append(tasks, task)
 	}
+	if len(batchErr.Items) > 0 {
+		return nil, batchErr
+	}
+
+	for _, task := range tasks {
+		if err := s.assignTagsToOwner(task); err != nil {
+			return nil, errors.NewInternalServerError(err)
+		}
+	}
+
+	if err := s.taskRepo.CreateMany(ctx, tasks); err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	created, err := s.taskRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	for i := range created {
+		publishTaskEvent(s.publisher, realtime.EventTaskCreated, &created[i])
+		notifyAssigned(s.notifier, &created[i])
+	}
+
+	return created, nil
 }
 
-func (s *taskService) Create(userID uint, req *CreateTaskRequest) (*models.Task, error) {
+// newTask validates a creation request and builds the task without saving it.
+// Tags are resolved against the creator; see assignTagsToOwner.
+func (s *taskService) newTask(userID uint, req *CreateTaskRequest) (*models.Task, error) {
+	// A title of only whitespace would show as a blank task
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, errors.NewInvalidInputError(EmptyTitleMessage)
+	}
+
 	// Validate task type
 	if !isValidTaskType(req.Type) {
-		return nil, errors.NewInvalidInputError("Invalid task type. Must be one of: casa, trabalho, lazer, saude")
+		return nil, errors.NewInvalidInputError(InvalidTaskTypeMessage)
 	}
 
 	// Validate priority if provided
 	priority := models.PriorityMedia // Default priority
 	if req.Priority != nil {
 		if !isValidPriority(*req.Priority) {
-			return nil, errors.NewInvalidInputError("Invalid priority. Must be one of: baixa, media, alta, urgente")
+			return nil, errors.NewInvalidInputError(InvalidPriorityMessage)
 		}
 		priority = *req.Priority
 	}
@@ -107,185 +324,449 @@ func (s *taskService) Create(userID uint, req *CreateTaskRequest) (*models.Task,
 		targetUserID = *req.UserID
 	}
 
-	// Validate tags if provided
+	// Validate tags if provided. Tag IDs always refer to the creator's tags.
 	var tags []models.Tag
 	if len(req.TagIDs) > 0 {
-		foundTags, err := s.tagRepo.FindByIDs(req.TagIDs, targetUserID)
+		foundTags, err := s.findUserTags(req.TagIDs, userID)
 		if err != nil {
-			return nil, errors.NewInvalidInputError("One or more tags not found or don't belong to the user")
-		}
-		if len(foundTags) != len(req.TagIDs) {
-			return nil, errors.NewInvalidInputError("One or more tags not found or don't belong to the user")
+			return nil, err
 		}
 		tags = foundTags
 	}
 
-	// Create task (when creating for another user, AssignedBy = creator so they can see it)
-	assignedBy := &userID
-	task := &models.Task{
-		Title:       req.Title,
+	// When creating for another user, AssignedBy = creator so they can see it. Passing your own
+	// ID is a regular self-owned task, not a self-assignment.
+	var assignedBy *uint
+	if targetUserID != userID {
+		assignedBy = &userID
+	}
+	return &models.Task{
+		Title:       title,
 		Description: req.Description,
 		Type:        req.Type,
 		Priority:    priority,
-		DueDate:     req.DueDate,
+		DueDate:     utcTime(req.DueDate),
 		UserID:      targetUserID,
 		AssignedBy:  assignedBy,
 		Completed:   false,
 		Tags:        tags,
+	}, nil
+}
+
+// findUserTags loads the user's tags with the given IDs, ignoring repeated IDs. IDs that don't
+// exist or belong to another user are listed once each in a 400 error; database failures return a 500.
+func (s *taskService) findUserTags(tagIDs []uint, userID uint) ([]models.Tag, error) {
+	unique := make(map[uint]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		unique[id] = true
+	}
+	if err := s.checkTagLimit(len(unique)); err != nil {
+		return nil, err
 	}
 
-	if err := s.taskRepo.Create(task); err != nil {
+	foundTags, err := s.tagRepo.FindByIDs(tagIDs, userID)
+	if err != nil {
 		return nil, errors.NewInternalServerError(err)
 	}
 
-	// When a user creates a task for another, share it with the creator so both have access
-	if req.UserID != nil && *req.UserID != userID {
-		if err := s.taskRepo.AddSharedWith(task.ID, userID); err != nil {
-			return nil, errors.NewInternalServerError(err)
+	found := make(map[uint]bool, len(foundTags))
+	for _, tag := range foundTags {
+		found[tag.ID] = true
+	}
+	var missing []string
+	for _, id := range tagIDs {
+		if !found[id] {
+			missing = append(missing, strconv.FormatUint(uint64(id), 10))
+			found[id] = true // list each missing ID once
 		}
 	}
+	if len(missing) > 0 {
+		return nil, errors.NewInvalidInputError("Tags not found or don't belong to the user: " + strings.Join(missing, ", "))
+	}
 
-	// Reload with relationships
-	task, err := s.taskRepo.FindByID(task.ID)
+	return foundTags, nil
+}
+
+// checkTagLimit rejects attaching more than maxTags tags to a task
+func (s *taskService) checkTagLimit(count int) error {
+	if count > s.maxTags {
+		return errors.NewInvalidInputError(fmt.Sprintf("A task can have at most %d tags", s.maxTags))
+	}
+	return nil
+}
+
+// assignTagsToOwner replaces the creator's tags with the owner's copies when the task is for another user
+func (s *taskService) assignTagsToOwner(task *models.Task) error {
+	if len(task.Tags) == 0 || task.AssignedBy == nil || *task.AssignedBy == task.UserID {
+		return nil
+	}
+	tags, err := s.copyTagsToUser(task.Tags, task.UserID)
 	if err != nil {
-		return nil, errors.NewInternalServerError(err)
+		return err
 	}
+	task.Tags = tags
+	return nil
+}
 
-	return task, nil
+// copyTagsToUser returns the user's tags matching the given tags by name. The missing ones are
+// returned unsaved, with the same color, and taskRepo creates them along with the task.
+func (s *taskService) copyTagsToUser(tags []models.Tag, userID uint) ([]models.Tag, error) {
+	userTags := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		exists, err := s.tagRepo.ExistsByNameAndUserID(tag.Name, userID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			existing, err := s.tagRepo.FindByNameAndUserID(tag.Name, userID)
+			if err != nil {
+				return nil, err
+			}
+			userTags = append(userTags, *existing)
+			continue
+		}
+
+		userTags = append(userTags, models.Tag{
+			Name:         tag.Name,
+			Color:        tag.Color,
+			DefaultColor: tag.DefaultColor,
+			UserID:       userID,
+		})
+	}
+	return userTags, nil
 }
 
-func (s *taskService) GetByID(userID, taskID uint) (*models.Task, error) {
-	task, err := s.taskRepo.FindByID(taskID)
+func (s *taskService) GetByID(ctx context.Context, userID, taskID uint) (*models.Task, error) {
+	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return nil, errors.NewTaskNotFoundError()
 	}
 
-	canAccess, err := s.taskRepo.UserCanAccessTask(taskID, userID)
+	canAccess, err := s.taskRepo.UserCanAccessTask(ctx, taskID, userID)
 	if err != nil || !canAccess {
-		return nil, errors.NewForbiddenError()
+		return nil, inaccessibleTaskError(s.hideInaccessible)
 	}
 
+	task.Permissions = taskPermissions(task, userID, s.commentLockClosed)
 	return task, nil
 }
 
-func (s *taskService) GetByUserID(userID uint, filters *TaskFilters) (*PaginatedTasksResponse, error) {
-	repoFilters := &repositories.TaskFilters{}
+// CheckAccess returns a not found error if the task doesn't exist and a forbidden error if the
+// user can't access it, without loading the task and its relations
+func (s *taskService) CheckAccess(ctx context.Context, userID, taskID uint) error {
+	exists, err := s.taskRepo.Exists(ctx, taskID)
+	if err != nil {
+		return errors.NewInternalServerError(err)
+	}
+	if !exists {
+		return errors.NewTaskNotFoundError()
+	}
 
-	// Set default pagination
-	page := 1
-	limit := 10
-	if filters != nil {
-		if filters.Page > 0 {
-			page = filters.Page
+	canAccess, err := s.taskRepo.UserCanAccessTask(ctx, taskID, userID)
+	if err != nil {
+		return errors.NewInternalServerError(err)
+	}
+	if !canAccess {
+		return inaccessibleTaskError(s.hideInaccessible)
+	}
+	return nil
+}
+
+// inaccessibleTaskError returns the error for a user who can't access an existing task: forbidden,
+// or not found when hide is set so that task IDs can't be probed
+func inaccessibleTaskError(hide bool) error {
+	if hide {
+		return errors.NewTaskNotFoundError()
+	}
+	return errors.NewForbiddenError()
+}
+
+// coreFieldsOwnerOnlyError returns the error for a user who isn't the task's owner but tries to edit
+// its core fields or tags
+func coreFieldsOwnerOnlyError() error {
+	return errors.NewAppError(errors.ErrForbidden, "Only the task owner can edit title, description, type, priority, due date and tags", http.StatusForbidden)
+}
+
+// notOwnerError returns the error for a user who isn't the task's owner.
+func (s *taskService) notOwnerError(ctx context.Context, taskID, userID uint) error {
+	return deniedTaskError(ctx, s.taskRepo, s.hideInaccessible, taskID, userID)
+}
+
+// deniedTaskError returns the error for a user who may not perform an action on the task. Users with
+// access to the task get a forbidden error; the others get inaccessibleTaskError.
+func deniedTaskError(ctx context.Context, taskRepo repositories.TaskRepository, hide bool, taskID, userID uint) error {
+	if hide {
+		if canAccess, err := taskRepo.UserCanAccessTask(ctx, taskID, userID); err == nil && !canAccess {
+			return errors.NewTaskNotFoundError()
 		}
-		if filters.Limit > 0 {
-			limit = filters.Limit
-			// Maximum limit is 100
-			if limit > 100 {
-				limit = 100
-			}
+	}
+	return errors.NewForbiddenError()
+}
+
+// taskPermissions returns what the user, who has access to the task, can do with it. Editing and
+// deleting follow the owner checks in Update and Delete; commenting follows commentsClosed, with
+// lockClosed being the comment service's setting.
+func taskPermissions(task *models.Task, userID uint, lockClosed bool) *models.TaskPermissions {
+	isOwner := task.UserID == userID
+	isShared := false
+	for _, user := range task.SharedWithUsers {
+		if user.ID == userID {
+			isShared = true
+			break
 		}
-		repoFilters.Page = page
-		repoFilters.Limit = limit
+	}
+	return &models.TaskPermissions{
+		CanEdit:     isOwner,
+		CanComplete: true,
+		CanDelete:   isOwner,
+		CanComment:  !commentsClosed(task, lockClosed),
+		IsOwner:     isOwner,
+		IsShared:    isShared,
+	}
+}
 
-		// Apply filters
-		if filters.Type != nil {
-			if !isValidTaskType(*filters.Type) {
-				return nil, errors.NewInvalidInputError("Invalid task type filter")
-			}
-			repoFilters.Type = filters.Type
+func (s *taskService) GetByUserID(ctx context.Context, userID uint, filters *TaskFilters) (*PaginatedTasksResponse, error) {
+	repoFilters, err := toRepoFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	if filters != nil && filters.All {
+		// Reject requests over the cap before loading any task
+		total, err := s.taskRepo.CountByUserID(ctx, userID, repoFilters)
+		if err != nil {
+			return nil, errors.NewInternalServerError(err)
 		}
-		if filters.Priority != nil {
-			if !isValidPriority(*filters.Priority) {
-				return nil, errors.NewInvalidInputError("Invalid priority filter")
-			}
-			repoFilters.Priority = filters.Priority
+		if total > int64(s.listAllMax) {
+			return nil, errors.NewInvalidInputError(fmt.Sprintf("limit=0 returns at most %d tasks, but %d match; use pagination", s.listAllMax, total))
 		}
-		repoFilters.Completed = filters.Completed
-		repoFilters.Search = filters.Search
-		repoFilters.DueDateFrom = filters.DueDateFrom
-		repoFilters.DueDateTo = filters.DueDateTo
-		repoFilters.AssignedBy = filters.AssignedBy
-		repoFilters.TagIDs = filters.TagIDs
-		repoFilters.SortBy = filters.SortBy
-		repoFilters.Order = filters.Order
-	} else {
-		repoFilters.Page = page
-		repoFilters.Limit = limit
-	}
-
-	tasks, total, err := s.taskRepo.FindByUserID(userID, repoFilters)
+		repoFilters.Page = 1
+		repoFilters.Limit = s.listAllMax
+	}
+
+	tasks, total, err := s.taskRepo.FindByUserID(ctx, userID, repoFilters)
 	if err != nil {
 		return nil, errors.NewInternalServerError(err)
 	}
 
-	// Calculate total pages
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	if totalPages == 0 {
-		totalPages = 1
+	response := newPaginatedTasksResponse(tasks, total, repoFilters)
+
+	if filters != nil && filters.IncludeCounts {
+		counts, err := s.countTasks(ctx, userID, *repoFilters)
+		if err != nil {
+			return nil, errors.NewInternalServerError(err)
+		}
+		response.Counts = counts
 	}
 
-	return &PaginatedTasksResponse{
-		Tasks:      tasks,
-		Total:      total,
-		Page:       page,
-		Limit:      limit,
-		TotalPages: totalPages,
+	return response, nil
+}
+
+// GetByTag lists the tasks accessible to the user that have the tag, which must belong to the user
+func (s *taskService) GetByTag(ctx context.Context, userID, tagID uint, filters *TaskFilters) (*PaginatedTasksResponse, error) {
+	if _, err := s.tagRepo.FindByIDAndUserID(tagID, userID); err != nil {
+		return nil, errors.NewTagNotFoundError()
+	}
+
+	if filters == nil {
+		filters = &TaskFilters{}
+	}
+	filters.TagIDs = []uint{tagID}
+	return s.GetByUserID(ctx, userID, filters)
+}
+
+// countTasks counts the user's tasks per bucket for the given filters, without the completion and overdue filters
+func (s *taskService) countTasks(ctx context.Context, userID uint, filters repositories.TaskFilters) (*TaskCounts, error) {
+	filters.Completed = nil
+	filters.Overdue = false
+
+	total, err := s.taskRepo.CountByUserID(ctx, userID, &filters)
+	if err != nil {
+		return nil, err
+	}
+
+	completedFilters := filters
+	completed := true
+	completedFilters.Completed = &completed
+	completedCount, err := s.taskRepo.CountByUserID(ctx, userID, &completedFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	overdueFilters := filters
+	overdueFilters.Overdue = true
+	overdueCount, err := s.taskRepo.CountByUserID(ctx, userID, &overdueFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskCounts{
+		Total:     total,
+		Pending:   total - completedCount,
+		Completed: completedCount,
+		Overdue:   overdueCount,
 	}, nil
 }
 
-func (s *taskService) GetAssignedByUser(assignedByID uint, filters *TaskFilters) (*PaginatedTasksResponse, error) {
-	repoFilters := &repositories.TaskFilters{}
+func (s *taskService) GetAssignedByUser(ctx context.Context, assignedByID uint, filters *TaskFilters) (*PaginatedTasksResponse, error) {
+	repoFilters, err := toRepoFilters(filters)
+	if err != nil {
+		return nil, err
+	}
 
-	// Set default pagination
-	page := 1
-	limit := 10
-	if filters != nil {
-		if filters.Page > 0 {
-			page = filters.Page
+	if repoFilters.AssigneeID != nil {
+		if _, err := s.userRepo.FindByID(*repoFilters.AssigneeID); err != nil {
+			return nil, errors.NewUserNotFoundError()
 		}
-		if filters.Limit > 0 {
-			limit = filters.Limit
-			// Maximum limit is 100
-			if limit > 100 {
-				limit = 100
-			}
+	}
+
+	tasks, total, err := s.taskRepo.FindByAssignedBy(ctx, assignedByID, repoFilters)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	return newPaginatedTasksResponse(tasks, total, repoFilters), nil
+}
+
+// GetAssignedByUserByAssignee lists the tasks the user assigned to others grouped by assignee, with
+// the total, pending, completed and overdue counts of each group. Page and limit are ignored: every
+// matching task is returned, up to the same cap as GetByUserID with filters.All.
+func (s *taskService) GetAssignedByUserByAssignee(ctx context.Context, assignedByID uint, filters *TaskFilters) (*AssigneeGroupsResponse, error) {
+	repoFilters, err := toRepoFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	repoFilters.Page = 1
+	repoFilters.Limit = s.listAllMax
+
+	if repoFilters.AssigneeID != nil {
+		if _, err := s.userRepo.FindByID(*repoFilters.AssigneeID); err != nil {
+			return nil, errors.NewUserNotFoundError()
 		}
-		repoFilters.Page = page
-		repoFilters.Limit = limit
+	}
 
-		// Apply filters
-		if filters.Type != nil {
-			if !isValidTaskType(*filters.Type) {
-				return nil, errors.NewInvalidInputError("Invalid task type filter")
-			}
-			repoFilters.Type = filters.Type
+	// Reject requests over the cap before grouping or loading any task
+	total, err := s.taskRepo.CountByAssignedBy(ctx, assignedByID, repoFilters)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	if total > int64(s.listAllMax) {
+		return nil, errors.NewInvalidInputError(fmt.Sprintf("group_by=assignee returns at most %d tasks, but %d match; narrow the filters", s.listAllMax, total))
+	}
+
+	counts, err := s.taskRepo.CountByAssignee(ctx, assignedByID, repoFilters)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	tasks, _, err := s.taskRepo.FindByAssignedBy(ctx, assignedByID, repoFilters)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	groups := make([]AssigneeTaskGroup, len(counts))
+	groupIndex := make(map[uint]int, len(counts))
+	for i, count := range counts {
+		groups[i] = AssigneeTaskGroup{
+			AssigneeID:       count.UserID,
+			AssigneeUsername: count.Username,
+			Counts: TaskCounts{
+				Total:     count.Total,
+				Pending:   count.Total - count.Completed,
+				Completed: count.Completed,
+				Overdue:   count.Overdue,
+			},
+			Tasks: []models.Task{},
 		}
-		if filters.Priority != nil {
-			if !isValidPriority(*filters.Priority) {
-				return nil, errors.NewInvalidInputError("Invalid priority filter")
-			}
-			repoFilters.Priority = filters.Priority
+		groupIndex[count.UserID] = i
+	}
+	for _, task := range tasks {
+		if i, ok := groupIndex[task.UserID]; ok {
+			groups[i].Tasks = append(groups[i].Tasks, task)
 		}
-		repoFilters.Completed = filters.Completed
-		repoFilters.Search = filters.Search
-		repoFilters.DueDateFrom = filters.DueDateFrom
-		repoFilters.DueDateTo = filters.DueDateTo
-		repoFilters.TagIDs = filters.TagIDs
-		repoFilters.SortBy = filters.SortBy
-		repoFilters.Order = filters.Order
-	} else {
-		repoFilters.Page = page
-		repoFilters.Limit = limit
-	}
-
-	tasks, total, err := s.taskRepo.FindByAssignedBy(assignedByID, repoFilters)
+	}
+
+	return &AssigneeGroupsResponse{Groups: groups, Total: total}, nil
+}
+
+// GetAssignedToUser lists tasks owned by the user that were assigned to them by someone else
+func (s *taskService) GetAssignedToUser(ctx context.Context, userID uint, filters *TaskFilters) (*PaginatedTasksResponse, error) {
+	repoFilters, err := toRepoFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, total, err := s.taskRepo.FindAssignedToUser(ctx, userID, repoFilters)
 	if err != nil {
 		return nil, errors.NewInternalServerError(err)
 	}
 
-	// Calculate total pages
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return newPaginatedTasksResponse(tasks, total, repoFilters), nil
+}
+
+// CountPendingAssignedToUser counts the pending tasks assigned to the user by someone else
+func (s *taskService) CountPendingAssignedToUser(ctx context.Context, userID uint) (int64, error) {
+	completed := false
+	count, err := s.taskRepo.CountAssignedToUser(ctx, userID, &repositories.TaskFilters{Completed: &completed})
+	if err != nil {
+		return 0, errors.NewInternalServerError(err)
+	}
+	return count, nil
+}
+
+// toRepoFilters validates the filters and applies the pagination defaults (page 1, 10 items, max 100)
+func toRepoFilters(filters *TaskFilters) (*repositories.TaskFilters, error) {
+	repoFilters := &repositories.TaskFilters{Page: 1, Limit: 10}
+	if filters == nil {
+		return repoFilters, nil
+	}
+
+	if filters.Page > 0 {
+		repoFilters.Page = filters.Page
+	}
+	if filters.Limit > 0 {
+		repoFilters.Limit = filters.Limit
+		// Maximum limit is 100
+		if repoFilters.Limit > 100 {
+			repoFilters.Limit = 100
+		}
+	}
+
+	for _, taskType := range filters.Types {
+		if !isValidTaskType(taskType) {
+			return nil, errors.NewInvalidInputError("Invalid task type filter: " + string(taskType))
+		}
+	}
+	for _, priority := range filters.Priorities {
+		if !isValidPriority(priority) {
+			return nil, errors.NewInvalidInputError("Invalid priority filter: " + string(priority))
+		}
+	}
+	for _, relation := range filters.Include {
+		if _, ok := repositories.TaskRelations[relation]; !ok {
+			return nil, errors.NewInvalidInputError("Invalid include: " + relation + ". Must be any of: user, assigned_by_user, updated_by_user, shared_with, tags")
+		}
+	}
+
+	repoFilters.Types = filters.Types
+	repoFilters.Priorities = filters.Priorities
+	repoFilters.Completed = filters.Completed
+	repoFilters.Search = filters.Search
+	repoFilters.DueDateFrom = filters.DueDateFrom
+	repoFilters.DueDateTo = filters.DueDateTo
+	repoFilters.Overdue = filters.Overdue
+	repoFilters.IncludeArchived = filters.IncludeArchived
+	repoFilters.AssignedBy = filters.AssignedBy
+	repoFilters.AssigneeID = filters.AssigneeID
+	repoFilters.TagIDs = filters.TagIDs
+	repoFilters.HasComments = filters.HasComments
+	repoFilters.Include = filters.Include
+	repoFilters.SortBy = filters.SortBy
+	repoFilters.Order = filters.Order
+	return repoFilters, nil
+}
+
+// newPaginatedTasksResponse builds a page of tasks, calculating the total pages
+func newPaginatedTasksResponse(tasks []models.Task, total int64, filters *repositories.TaskFilters) *PaginatedTasksResponse {
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
 	if totalPages == 0 {
 		totalPages = 1
 	}
@@ -293,48 +774,108 @@ func (s *taskService) GetAssignedByUser(assignedByID uint, filters *TaskFilters)
 	return &PaginatedTasksResponse{
 		Tasks:      tasks,
 		Total:      total,
-		Page:       page,
-		Limit:      limit,
+		Page:       filters.Page,
+		Limit:      filters.Limit,
 		TotalPages: totalPages,
-	}, nil
+	}
+}
+
+// GetStats counts the tasks accessible to the user created and completed between from and to (both
+// optional and inclusive), overall and per type. Pending and overdue count the created tasks.
+func (s *taskService) GetStats(ctx context.Context, userID uint, from, to *time.Time, includeArchived bool) (*TaskStats, error) {
+	if from != nil && to != nil && from.After(*to) {
+		return nil, errors.NewInvalidInputError("from must not be after to")
+	}
+
+	counts, err := s.taskRepo.CountStatsByType(ctx, userID, from, to, includeArchived)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	stats := &TaskStats{
+		From:   utcTime(from),
+		To:     utcTime(to),
+		ByType: make(map[models.TaskType]TaskStatsCounts, len(counts)),
+	}
+	for _, count := range counts {
+		typeCounts := TaskStatsCounts{
+			Created:   count.Created,
+			Completed: count.Completed,
+			Pending:   count.Pending,
+			Overdue:   count.Overdue,
+		}
+		stats.ByType[count.Type] = typeCounts
+		stats.Created += typeCounts.Created
+		stats.Completed += typeCounts.Completed
+		stats.Pending += typeCounts.Pending
+		stats.Overdue += typeCounts.Overdue
+	}
+
+	return stats, nil
+}
+
+// GetUpcoming returns the pending tasks accessible to the user that are due within the next hours, soonest first
+func (s *taskService) GetUpcoming(ctx context.Context, userID uint, hours int) ([]models.Task, error) {
+	if hours < 1 || hours > MaxUpcomingHours {
+		return nil, errors.NewInvalidInputError(fmt.Sprintf("hours must be between 1 and %d", MaxUpcomingHours))
+	}
+
+	now := time.Now().UTC()
+	tasks, err := s.taskRepo.FindUpcomingByUserID(ctx, userID, now, now.Add(time.Duration(hours)*time.Hour))
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	return tasks, nil
 }
 
-func (s *taskService) Update(userID, taskID uint, req *UpdateTaskRequest) (*models.Task, error) {
+func (s *taskService) Update(ctx context.Context, userID, taskID uint, req *UpdateTaskRequest) (*models.Task, error) {
 	// Find task
-	task, err := s.taskRepo.FindByID(taskID)
+	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return nil, errors.NewTaskNotFoundError()
 	}
 
-	canAccess, err := s.taskRepo.UserCanAccessTask(taskID, userID)
+	canAccess, err := s.taskRepo.UserCanAccessTask(ctx, taskID, userID)
 	if err != nil || !canAccess {
-		return nil, errors.NewForbiddenError()
+		return nil, inaccessibleTaskError(s.hideInaccessible)
+	}
+
+	// Anyone with access can complete the task; only the owner can edit its core fields
+	if task.UserID != userID && req.editsCoreFields() {
+		return nil, coreFieldsOwnerOnlyError()
 	}
+	wasCompleted := task.Completed
 
 	// Update fields
 	if req.Title != nil {
-		task.Title = *req.Title
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, errors.NewInvalidInputError(EmptyTitleMessage)
+		}
+		task.Title = title
 	}
 	if req.Description != nil {
 		task.Description = *req.Description
 	}
 	if req.Type != nil {
 		if !isValidTaskType(*req.Type) {
-			return nil, errors.NewInvalidInputError("Invalid task type. Must be one of: casa, trabalho, lazer, saude")
+			return nil, errors.NewInvalidInputError(InvalidTaskTypeMessage)
 		}
 		task.Type = *req.Type
 	}
 	if req.Priority != nil {
 		if !isValidPriority(*req.Priority) {
-			return nil, errors.NewInvalidInputError("Invalid priority. Must be one of: baixa, media, alta, urgente")
+			return nil, errors.NewInvalidInputError(InvalidPriorityMessage)
 		}
 		task.Priority = *req.Priority
 	}
 	if req.DueDate != nil {
-		task.DueDate = req.DueDate
+		task.DueDate = utcTime(req.DueDate)
 	}
-	if req.Completed != nil {
+	if req.Completed != nil && *req.Completed != task.Completed {
 		task.Completed = *req.Completed
+		task.CompletedAt = completedAt(task.Completed)
 	}
 
 	// Update tags if provided
@@ -344,57 +885,107 @@ func (s *taskService) Update(userID, taskID uint, req *UpdateTaskRequest) (*mode
 			task.Tags = []models.Tag{}
 		} else {
 			// Validate and set new tags (use task owner for tag ownership)
-			foundTags, err := s.tagRepo.FindByIDs(*req.TagIDs, task.UserID)
+			foundTags, err := s.findUserTags(*req.TagIDs, task.UserID)
 			if err != nil {
-				return nil, errors.NewInvalidInputError("One or more tags not found or don't belong to the user")
-			}
-			if len(foundTags) != len(*req.TagIDs) {
-				return nil, errors.NewInvalidInputError("One or more tags not found or don't belong to the user")
+				return nil, err
 			}
 			task.Tags = foundTags
 		}
 	}
 
-	if err := s.taskRepo.Update(task); err != nil {
+	// Record who made the change; the stale preloaded user is cleared so Save does not
+	// overwrite UpdatedBy with its ID
+	task.UpdatedBy = &userID
+	task.UpdatedByUser = nil
+
+	if err := s.taskRepo.Update(ctx, task); err != nil {
 		return nil, errors.NewInternalServerError(err)
 	}
 
 	// Reload with relationships
-	task, err = s.taskRepo.FindByID(task.ID)
+	task, err = s.taskRepo.FindByID(ctx, task.ID)
 	if err != nil {
 		return nil, errors.NewInternalServerError(err)
 	}
 
+	eventType := realtime.EventTaskUpdated
+	if task.Completed && !wasCompleted {
+		eventType = realtime.EventTaskCompleted
+	}
+	publishTaskEvent(s.publisher, eventType, task)
 	return task, nil
 }
 
-func (s *taskService) Delete(userID, taskID uint) error {
+func (s *taskService) Delete(ctx context.Context, userID, taskID uint) error {
 	// Find task
-	task, err := s.taskRepo.FindByID(taskID)
+	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return errors.NewTaskNotFoundError()
 	}
 
 	// Only the task owner can delete the task
 	if task.UserID != userID {
-		return errors.NewForbiddenError()
+		return s.notOwnerError(ctx, taskID, userID)
 	}
 
-	if err := s.taskRepo.Delete(taskID); err != nil {
+	if err := s.taskRepo.Delete(ctx, taskID); err != nil {
 		return errors.NewInternalServerError(err)
 	}
 
 	return nil
 }
 
+// ClearCompletedResult reports how many completed tasks were cleared and how. Clearing runs in a
+// single query, so Failed is always empty.
+type ClearCompletedResult struct {
+	BulkResult
+	Archived bool `json:"archived" example:"false"` // True when the tasks were archived instead of deleted
+}
+
+// DeleteCompleted clears all completed tasks the user owns in one query; tasks shared with the
+// user are left alone. Depending on the configuration they are soft-deleted or archived.
+func (s *taskService) DeleteCompleted(ctx context.Context, userID uint) (*ClearCompletedResult, error) {
+	clearTasks := s.taskRepo.DeleteCompletedByOwner
+	if s.clearCompletedArchive {
+		clearTasks = s.taskRepo.ArchiveCompletedByOwner
+	}
+	count, err := clearTasks(ctx, userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	result := &ClearCompletedResult{BulkResult: *newBulkResult(), Archived: s.clearCompletedArchive}
+	result.Affected = count
+	return result, nil
+}
+
+// SetArchived archives or unarchives a task. Only the task owner can archive.
+func (s *taskService) SetArchived(ctx context.Context, userID, taskID uint, archived bool) (*models.Task, error) {
+	task, err := s.taskRepo.FindByID(ctx, taskID)
+	if err != nil {
+		return nil, errors.NewTaskNotFoundError()
+	}
+
+	if task.UserID != userID {
+		return nil, s.notOwnerError(ctx, taskID, userID)
+	}
+
+	task.Archived = archived
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+
+	publishTaskEvent(s.publisher, realtime.EventTaskUpdated, task)
+	return task, nil
+}
+
 // ShareTask adds users to the task's shared list. Only the task owner can share.
-func (s *taskService) ShareTask(ownerID, taskID uint, userIDs []uint) error {
-	task, err := s.taskRepo.FindByID(taskID)
+func (s *taskService) ShareTask(ctx context.Context, ownerID, taskID uint, userIDs []uint) error {
+	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return errors.NewTaskNotFoundError()
 	}
 	if task.UserID != ownerID {
-		return errors.NewForbiddenError()
+		return s.notOwnerError(ctx, taskID, ownerID)
 	}
 	for _, uid := range userIDs {
 		if uid == ownerID {
@@ -403,7 +994,7 @@ func (s *taskService) ShareTask(ownerID, taskID uint, userIDs []uint) error {
 		if _, err := s.userRepo.FindByID(uid); err != nil {
 			return errors.NewInvalidInputError("One or more user IDs are invalid")
 		}
-		if err := s.taskRepo.AddSharedWith(taskID, uid); err != nil {
+		if err := s.taskRepo.AddSharedWith(ctx, taskID, uid); err != nil {
 			return errors.NewInternalServerError(err)
 		}
 	}
@@ -411,37 +1002,72 @@ func (s *taskService) ShareTask(ownerID, taskID uint, userIDs []uint) error {
 }
 
 // UnshareTask removes a user from the task's shared list. Only the task owner can unshare.
-func (s *taskService) UnshareTask(ownerID, taskID uint, sharedUserID uint) error {
-	task, err := s.taskRepo.FindByID(taskID)
+func (s *taskService) UnshareTask(ctx context.Context, ownerID, taskID uint, sharedUserID uint) error {
+	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return errors.NewTaskNotFoundError()
 	}
 	if task.UserID != ownerID {
-		return errors.NewForbiddenError()
+		return s.notOwnerError(ctx, taskID, ownerID)
 	}
-	if err := s.taskRepo.RemoveSharedWith(taskID, sharedUserID); err != nil {
+	if err := s.taskRepo.RemoveSharedWith(ctx, taskID, sharedUserID); err != nil {
 		return errors.NewInternalServerError(err)
 	}
 	return nil
 }
 
+// taskAudience returns the IDs of everyone with access to the task: owner, assigner and shared users.
+// The task must have SharedWithUsers loaded.
+func taskAudience(task *models.Task) []uint {
+	userIDs := []uint{task.UserID}
+	if task.AssignedBy != nil {
+		userIDs = append(userIDs, *task.AssignedBy)
+	}
+	for _, user := range task.SharedWithUsers {
+		userIDs = append(userIDs, user.ID)
+	}
+	return userIDs
+}
+
+// publishTaskEvent sends a task event to everyone with access to the task
+func publishTaskEvent(publisher realtime.Publisher, eventType string, task *models.Task) {
+	publisher.Publish(taskAudience(task), realtime.Event{Type: eventType, TaskID: task.ID, Data: task})
+}
+
 // isValidTaskType checks if the task type is valid
 func isValidTaskType(taskType models.TaskType) bool {
-	switch taskType {
-	case models.TaskTypeCasa, models.TaskTypeTrabalho, models.TaskTypeLazer, models.TaskTypeSaude:
-		return true
-	default:
-		return false
+	return slices.Contains(models.TaskTypes, taskType)
+}
+
+// utcTime returns a copy of t in UTC, so due dates are stored the same way whatever offset the client sent
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// completedAt returns the completion time to store for a task that is now completed or not: the
+// current time in UTC, or nil for a pending task
+func completedAt(completed bool) *time.Time {
+	if !completed {
+		return nil
 	}
+	now := time.Now().UTC()
+	return &now
 }
 
 // isValidPriority checks if the priority is valid
 func isValidPriority(priority models.Priority) bool {
-	switch priority {
-	case models.PriorityBaixa, models.PriorityMedia, models.PriorityAlta, models.PriorityUrgente:
-		return true
-	default:
-		return false
-	}
+	return slices.Contains(models.Priorities, priority)
 }
 
+// joinValues joins enum values for error messages, e.g. "casa, trabalho, lazer, saude"
+func joinValues[T ~string](values []T) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = string(value)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"todo-go-backend/internal/errors"
+	"todo-go-backend/internal/models"
+	"todo-go-backend/internal/realtime"
+)
+
+// MaxBulkTagTasks is the maximum number of tasks accepted by AssignTag and UnassignTag
+const MaxBulkTagTasks = 100
+
+// AssignTag attaches the user's tag to every task in taskIDs the user owns, all at once.
+// Tasks that already have the tag, are not accessible or would exceed the tags limit are reported as
+// failed. Like editing a single task's tags, tasks only shared with or assigned by the user are
+// rejected with 403 and nothing is changed.
+func (s *taskService) AssignTag(ctx context.Context, userID, tagID uint, taskIDs []uint) (*BulkResult, error) {
+	return s.bulkTag(ctx, userID, tagID, taskIDs, true)
+}
+
+// UnassignTag removes the user's tag from every task in taskIDs the user owns, all at once.
+// Tasks without the tag or that are not accessible are reported as failed; tasks the user can
+// access but doesn't own are rejected with 403 and nothing is changed.
+func (s *taskService) UnassignTag(ctx context.Context, userID, tagID uint, taskIDs []uint) (*BulkResult, error) {
+	return s.bulkTag(ctx, userID, tagID, taskIDs, false)
+}
+
+func (s *taskService) bulkTag(ctx context.Context, userID, tagID uint, taskIDs []uint, assign bool) (*BulkResult, error) {
+	if len(taskIDs) == 0 {
+		return nil, errors.NewInvalidInputError("At least one task ID is required")
+	}
+	if len(taskIDs) > MaxBulkTagTasks {
+		return nil, errors.NewInvalidInputError(fmt.Sprintf("At most %d tasks can be tagged at once", MaxBulkTagTasks))
+	}
+
+	if _, err := s.tagRepo.FindByIDAndUserID(tagID, userID); err != nil {
+		return nil, errors.NewTagNotFoundError()
+	}
+
+	tasks, err := s.taskRepo.FindByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	byID := make(map[uint]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	result := newBulkResult()
+	seen := make(map[uint]bool, len(taskIDs))
+	var changed []uint
+	for _, id := range taskIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		// Only the owner edits a task's tags, and the tag is the caller's own
+		if task := byID[id]; task != nil && task.UserID != userID && canAccessLoadedTask(task, userID) {
+			return nil, coreFieldsOwnerOnlyError()
+		}
+		if message := s.bulkTagFailure(byID[id], userID, tagID, assign); message != "" {
+			result.Failed = append(result.Failed, BulkFailure{TaskID: id, Message: message})
+			continue
+		}
+		changed = append(changed, id)
+	}
+
+	if assign {
+		err = s.taskRepo.AddTagToTasks(ctx, tagID, changed, userID)
+	} else {
+		err = s.taskRepo.RemoveTagFromTasks(ctx, tagID, changed, userID)
+	}
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	result.Affected = int64(len(changed))
+
+	s.publishTasksUpdated(ctx, changed)
+	return result, nil
+}
+
+// bulkTagFailure returns why the task is left unchanged by a bulk tag operation, or "" if it is changed
+func (s *taskService) bulkTagFailure(task *models.Task, userID, tagID uint, assign bool) string {
+	if task == nil || !canAccessLoadedTask(task, userID) {
+		return "Task not found"
+	}
+
+	hasTag := false
+	for _, tag := range task.Tags {
+		if tag.ID == tagID {
+			hasTag = true
+			break
+		}
+	}
+	switch {
+	case assign && hasTag:
+		return "Task already has the tag"
+	case assign && len(task.Tags) >= s.maxTags:
+		return fmt.Sprintf("A task can have at most %d tags", s.maxTags)
+	case !assign && !hasTag:
+		return "Task doesn't have the tag"
+	}
+	return ""
+}
+
+// canAccessLoadedTask reports whether the user owns, assigned or has the task shared with them,
+// like TaskRepository.UserCanAccessTask. The task must be loaded with its shared users.
+func canAccessLoadedTask(task *models.Task, userID uint) bool {
+	if task.UserID == userID || (task.AssignedBy != nil && *task.AssignedBy == userID) {
+		return true
+	}
+	for _, user := range task.SharedWithUsers {
+		if user.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// publishTasksUpdated reloads the tasks and publishes an update event for each of them
+func (s *taskService) publishTasksUpdated(ctx context.Context, taskIDs []uint) {
+	if len(taskIDs) == 0 {
+		return
+	}
+	tasks, err := s.taskRepo.FindByIDs(ctx, taskIDs)
+	if err != nil {
+		return
+	}
+	for i := range tasks {
+		publishTaskEvent(s.publisher, realtime.EventTaskUpdated, &tasks[i])
+	}
+}
@@ -0,0 +1,26 @@
+// Package version holds the build information of the running binary. The variables are set at
+// build time with -ldflags, e.g.:
+//
+//	go build -ldflags "-X todo-go-backend/internal/version.Version=1.2.0 -X todo-go-backend/internal/version.Commit=$(git rev-parse --short HEAD) -X todo-go-backend/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/api
+package version
+
+var (
+	// Version is the release version ("dev" for local builds)
+	Version = "dev"
+	// Commit is the git commit the binary was built from
+	Commit = "unknown"
+	// BuildTime is when the binary was built (RFC 3339, UTC)
+	BuildTime = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version" example:"1.2.0"`
+	Commit    string `json:"commit" example:"a098ab0"`
+	BuildTime string `json:"build_time" example:"2024-12-31T23:59:59Z"`
+}
+
+// Get returns the build information
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+}
@@ -2,22 +2,55 @@ package utils
 
 import (
 	"time"
+	"todo-go-backend/internal/auth"
 	"todo-go-backend/internal/middleware"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenTTL is how long a generated token stays valid
+const TokenTTL = 24 * time.Hour
+
+// SignedToken is a signed JWT and its validity window
+type SignedToken struct {
+	Value     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
 func GenerateToken(userID uint, username, jwtSecret string) (string, error) {
+	return newToken(userID, username, time.Now()).SignedString([]byte(jwtSecret))
+}
+
+// GenerateTokenWithKeySet signs the token with the key set's current key and sets its kid header
+func GenerateTokenWithKeySet(userID uint, username string, keys *auth.KeySet) (*SignedToken, error) {
+	// JWT dates have second precision, so the reported times match the claims exactly
+	issuedAt := time.Now().Truncate(time.Second)
+
+	kid, secret := keys.SigningKey()
+	token := newToken(userID, username, issuedAt)
+	token.Header["kid"] = kid
+	value, err := token.SignedString(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedToken{
+		Value:     value,
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(TokenTTL),
+	}, nil
+}
+
+func newToken(userID uint, username string, issuedAt time.Time) *jwt.Token {
 	claims := &middleware.Claims{
 		UserID:   userID,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(TokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtSecret))
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 }
-